@@ -0,0 +1,145 @@
+package apperror
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const ContentType = "application/problem+json"
+
+const (
+	CodeBadRequest         = "bad_request"
+	CodeInvalidID          = "invalid_id"
+	CodeInvalidBody        = "invalid_body"
+	CodeInvalidUser        = "invalid_user"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeValidation         = "validation_failed"
+	CodeNotFound           = "not_found"
+	CodeRouteNotFound      = "route_not_found"
+	CodeAlreadyCompleted   = "already_completed"
+	CodeNotCompleted       = "not_completed"
+	CodeConflict           = "conflict"
+	CodeUnsupportedMedia   = "unsupported_media_type"
+	CodePreconditionFailed = "precondition_failed"
+	CodePayloadTooLarge    = "payload_too_large"
+	CodeInternal           = "internal_error"
+)
+
+// FieldError описывает ошибку валидации одного поля запроса.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error — ответ об ошибке в формате RFC 7807 (application/problem+json).
+type Error struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+
+	cause error
+}
+
+func New(status int, code, detail string) *Error {
+	return &Error{
+		Type:   "/problems/" + strings.ReplaceAll(code, "_", "-"),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Code + ": " + e.Detail + ": " + e.cause.Error()
+	}
+	return e.Code + ": " + e.Detail
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Wrap сохраняет исходную ошибку для логов, не показывая её клиенту.
+func (e *Error) Wrap(err error) *Error {
+	e.cause = err
+	return e
+}
+
+func BadRequest(code, detail string) *Error {
+	return New(fiber.StatusBadRequest, code, detail)
+}
+
+func Forbidden(detail string) *Error {
+	return New(fiber.StatusForbidden, CodeForbidden, detail)
+}
+
+func NotFound(detail string) *Error {
+	return New(fiber.StatusNotFound, CodeNotFound, detail)
+}
+
+func Conflict(code, detail string) *Error {
+	return New(fiber.StatusConflict, code, detail)
+}
+
+func Internal(err error, detail string) *Error {
+	return New(fiber.StatusInternalServerError, CodeInternal, detail).Wrap(err)
+}
+
+func Validation(fields ...FieldError) *Error {
+	e := New(fiber.StatusBadRequest, CodeValidation, "Request validation failed")
+	e.Errors = fields
+	return e
+}
+
+// Handler — общий обработчик ошибок приложения для fiber.Config.ErrorHandler.
+func Handler(c *fiber.Ctx, err error) error {
+	var e *Error
+	if !errors.As(err, &e) {
+		var fe *fiber.Error
+		if errors.As(err, &fe) {
+			e = New(fe.Code, codeForStatus(fe.Code), fe.Message)
+		} else {
+			e = Internal(err, "Internal server error")
+		}
+	}
+
+	if e.Status >= fiber.StatusInternalServerError {
+		log.Printf("%s %s: %v", c.Method(), c.OriginalURL(), e)
+	}
+
+	problem := *e
+	if problem.Instance == "" {
+		problem.Instance = c.OriginalURL()
+	}
+
+	return c.Status(problem.Status).JSON(problem, ContentType)
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case fiber.StatusForbidden:
+		return CodeForbidden
+	case fiber.StatusNotFound:
+		return CodeNotFound
+	case fiber.StatusConflict:
+		return CodeConflict
+	case fiber.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	}
+	if status >= fiber.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
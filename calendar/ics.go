@@ -0,0 +1,172 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"habit-tracker-api/models"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const ContentType = "text/calendar; charset=utf-8"
+
+const (
+	prodID   = "-//Habit Tracker API//RU"
+	uidHost  = "habit-tracker-api"
+	dateForm = "20060102"
+	timeForm = "20060102T150405Z"
+)
+
+// Feed — данные одной календарной ленты пользователя.
+type Feed struct {
+	Name   string
+	Habits []models.Habit
+	Goals  []models.Goal
+	Now    time.Time
+}
+
+// Write пишет ленту в формате iCalendar (RFC 5545): повторяющееся событие на весь день
+// для каждой привычки и событие на день TargetDate для каждой цели.
+func Write(w io.Writer, feed Feed) error {
+	bw := bufio.NewWriter(w)
+	out := &writer{w: bw}
+
+	habits := append([]models.Habit(nil), feed.Habits...)
+	sort.Slice(habits, func(i, j int) bool { return habits[i].ID < habits[j].ID })
+	goals := append([]models.Goal(nil), feed.Goals...)
+	sort.Slice(goals, func(i, j int) bool { return goals[i].ID < goals[j].ID })
+
+	stamp := feed.Now.UTC().Format(timeForm)
+
+	out.line("BEGIN:VCALENDAR")
+	out.line("VERSION:2.0")
+	out.line("PRODID:" + prodID)
+	out.line("CALSCALE:GREGORIAN")
+	out.line("METHOD:PUBLISH")
+	out.line("X-WR-CALNAME:" + escape(feed.Name))
+	// Подсказка клиентам обновлять ленту раз в час
+	out.line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	out.line("X-PUBLISHED-TTL:PT1H")
+
+	for _, habit := range habits {
+		start := habit.CreatedAt
+		if start.IsZero() {
+			start = feed.Now
+		}
+
+		out.line("BEGIN:VEVENT")
+		out.line(fmt.Sprintf("UID:habit-%d@%s", habit.ID, uidHost))
+		out.line("DTSTAMP:" + stamp)
+		out.line(fmt.Sprintf("SEQUENCE:%d", sequence(habit.Version)))
+		out.line("DTSTART;VALUE=DATE:" + start.Format(dateForm))
+		out.line("DTEND;VALUE=DATE:" + start.AddDate(0, 0, 1).Format(dateForm))
+		if rule, ok := RRule(habit.Frequency); ok {
+			out.line("RRULE:" + rule)
+		}
+		out.line("SUMMARY:" + escape(habit.Name))
+		out.line("DESCRIPTION:" + escape(habitDescription(habit)))
+		out.line("CATEGORIES:" + escape(habit.Category))
+		out.line("TRANSP:TRANSPARENT")
+		out.line("END:VEVENT")
+	}
+
+	for _, goal := range goals {
+		if goal.TargetDate.IsZero() {
+			continue
+		}
+
+		summary := goal.Title
+		if goal.Completed {
+			summary = "✓ " + summary
+		}
+
+		out.line("BEGIN:VEVENT")
+		out.line(fmt.Sprintf("UID:goal-%d@%s", goal.ID, uidHost))
+		out.line("DTSTAMP:" + stamp)
+		out.line(fmt.Sprintf("SEQUENCE:%d", sequence(goal.Version)))
+		out.line("DTSTART;VALUE=DATE:" + goal.TargetDate.Format(dateForm))
+		out.line("DTEND;VALUE=DATE:" + goal.TargetDate.AddDate(0, 0, 1).Format(dateForm))
+		out.line("SUMMARY:" + escape(summary))
+		out.line("DESCRIPTION:" + escape(goalDescription(goal)))
+		if goal.Completed {
+			out.line("CATEGORIES:goal,completed")
+			out.line("X-HABIT-TRACKER-COMPLETED:" + goal.CompletedAt.UTC().Format(timeForm))
+		} else {
+			out.line("CATEGORIES:goal")
+		}
+		out.line("STATUS:CONFIRMED")
+		out.line("TRANSP:TRANSPARENT")
+		out.line("END:VEVENT")
+	}
+
+	out.line("END:VCALENDAR")
+
+	if out.err != nil {
+		return out.err
+	}
+	return bw.Flush()
+}
+
+func habitDescription(habit models.Habit) string {
+	description := "Частота: " + habit.Frequency
+	if habit.Description != "" {
+		description = habit.Description + "\n" + description
+	}
+	return description
+}
+
+func goalDescription(goal models.Goal) string {
+	description := goal.Description
+	if goal.Completed {
+		if description != "" {
+			description += "\n"
+		}
+		description += "Выполнено " + goal.CompletedAt.Format("02.01.2006")
+	}
+	return description
+}
+
+// sequence растёт при каждом изменении записи, чтобы клиенты обновляли событие.
+func sequence(version int) int {
+	if version < 1 {
+		return 0
+	}
+	return version - 1
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(value string) string {
+	return escaper.Replace(value)
+}
+
+// writer пишет строки с CRLF и переносом длинных строк по 75 октетов (RFC 5545, 3.1).
+type writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (w *writer) line(s string) {
+	if w.err != nil {
+		return
+	}
+
+	// Строка продолжения начинается с пробела, он тоже занимает октет
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		// Не разрываем многобайтовый символ UTF-8
+		for !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if _, w.err = w.w.WriteString(s[:cut] + "\r\n "); w.err != nil {
+			return
+		}
+		s = s[cut:]
+		limit = 74
+	}
+	_, w.err = w.w.WriteString(s + "\r\n")
+}
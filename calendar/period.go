@@ -0,0 +1,69 @@
+package calendar
+
+import (
+	"slices"
+	"time"
+)
+
+// Периоды повторяющихся целей
+const (
+	Weekly    = "weekly"
+	Monthly   = "monthly"
+	Quarterly = "quarterly"
+	Yearly    = "yearly"
+)
+
+var Periods = []string{Weekly, Monthly, Quarterly, Yearly}
+
+func IsPeriod(period string) bool {
+	return slices.Contains(Periods, period)
+}
+
+// AddPeriods сдвигает дату на n периодов. Последний день месяца остаётся последним:
+// 31 марта + квартал = 30 июня, а не 1 июля. Для неизвестного периода дата не меняется.
+func AddPeriods(t time.Time, period string, n int) time.Time {
+	months := 0
+	switch period {
+	case Weekly:
+		return t.AddDate(0, 0, 7*n)
+	case Monthly:
+		months = n
+	case Quarterly:
+		months = 3 * n
+	case Yearly:
+		months = 12 * n
+	default:
+		return t
+	}
+
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if day == daysIn(year, month, t.Location()) || day > daysIn(first.Year(), first.Month(), t.Location()) {
+		day = daysIn(first.Year(), first.Month(), t.Location())
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// PeriodEnd — начало последнего дня периода, в который попадает t: воскресенье недели,
+// последний день месяца, квартала или года.
+func PeriodEnd(t time.Time, period string) time.Time {
+	year, month, day := t.Date()
+	loc := t.Location()
+	switch period {
+	case Weekly:
+		// Неделя начинается с понедельника
+		return time.Date(year, month, day+(7-int(t.Weekday()))%7, 0, 0, 0, 0, loc)
+	case Monthly:
+		return time.Date(year, month+1, 0, 0, 0, 0, 0, loc)
+	case Quarterly:
+		return time.Date(year, (month-1)/3*3+4, 0, 0, 0, 0, 0, loc)
+	case Yearly:
+		return time.Date(year, time.December, 31, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	}
+}
+
+func daysIn(year int, month time.Month, loc *time.Location) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+}
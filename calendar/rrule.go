@@ -0,0 +1,168 @@
+package calendar
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Дни недели для «N раз в неделю», разнесённые по неделе как можно равномернее
+var timesPerWeek = map[int]string{
+	1: "MO",
+	2: "TU,TH",
+	3: "MO,WE,FR",
+	4: "MO,TU,TH,FR",
+	5: "MO,TU,WE,TH,FR",
+	6: "MO,TU,WE,TH,FR,SA",
+	7: "MO,TU,WE,TH,FR,SA,SU",
+}
+
+var (
+	perWeekPattern    = regexp.MustCompile(`^(\d+)\s*(?:раза?|times?|x)\s*(?:в|a|per)\s*(?:неделю|week)$`)
+	everyDaysPattern  = regexp.MustCompile(`^(?:каждые|every)\s*(\d+)\s*(?:дн[яей]*|days?)$`)
+	everyWeeksPattern = regexp.MustCompile(`^(?:каждые|every)\s*(\d+)\s*(?:недел[иь]|weeks?)$`)
+)
+
+// RRule переводит текстовую частоту привычки в правило повторения RFC 5545.
+// Для нераспознанной частоты возвращает false, событие тогда не повторяется.
+func RRule(frequency string) (string, bool) {
+	value := strings.ToLower(strings.TrimSpace(frequency))
+
+	switch value {
+	case "ежедневно", "каждый день", "daily", "every day":
+		return "FREQ=DAILY", true
+	case "еженедельно", "раз в неделю", "каждую неделю", "weekly", "every week":
+		return "FREQ=WEEKLY", true
+	case "ежемесячно", "раз в месяц", "каждый месяц", "monthly", "every month":
+		return "FREQ=MONTHLY", true
+	case "по будням", "будни", "weekdays":
+		return "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", true
+	case "по выходным", "выходные", "weekends":
+		return "FREQ=WEEKLY;BYDAY=SA,SU", true
+	}
+
+	if m := perWeekPattern.FindStringSubmatch(value); m != nil {
+		if days, ok := timesPerWeek[atoi(m[1])]; ok {
+			return "FREQ=WEEKLY;BYDAY=" + days, true
+		}
+	}
+	if m := everyDaysPattern.FindStringSubmatch(value); m != nil && atoi(m[1]) > 0 {
+		return "FREQ=DAILY;INTERVAL=" + m[1], true
+	}
+	if m := everyWeeksPattern.FindStringSubmatch(value); m != nil && atoi(m[1]) > 0 {
+		return "FREQ=WEEKLY;INTERVAL=" + m[1], true
+	}
+
+	return "", false
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// Rule — разобранная частота привычки.
+type Rule struct {
+	Freq     string // DAILY, WEEKLY или MONTHLY
+	Interval int
+	ByDay    []time.Weekday
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// ParseFrequency разбирает текстовую частоту тем же способом, что и RRule.
+func ParseFrequency(frequency string) (Rule, bool) {
+	value, ok := RRule(frequency)
+	if !ok {
+		return Rule{}, false
+	}
+
+	rule := Rule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, param, _ := strings.Cut(part, "=")
+		switch key {
+		case "FREQ":
+			rule.Freq = param
+		case "INTERVAL":
+			rule.Interval = atoi(param)
+		case "BYDAY":
+			for _, code := range strings.Split(param, ",") {
+				rule.ByDay = append(rule.ByDay, weekdayCodes[code])
+			}
+		}
+	}
+	return rule, true
+}
+
+// RuleOf — правило частоты привычки; нераспознанная частота считается ежедневной,
+// как в напоминаниях и отчётах.
+func RuleOf(frequency string) Rule {
+	rule, ok := ParseFrequency(frequency)
+	if !ok {
+		rule = Rule{Freq: "DAILY", Interval: 1}
+	}
+	if rule.Interval < 1 {
+		rule.Interval = 1
+	}
+	return rule
+}
+
+// Expected — сколько выполнений по правилу ожидается в днях [from, to).
+// origin — день создания привычки, от него отсчитываются интервалы «каждые N дней».
+func (r Rule) Expected(from, to, origin time.Time) int {
+	if !to.After(from) {
+		return 0
+	}
+	days := 0
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		days++
+	}
+
+	switch r.Freq {
+	case "WEEKLY":
+		if len(r.ByDay) > 0 && r.Interval == 1 {
+			count := 0
+			for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+				for _, weekday := range r.ByDay {
+					if day.Weekday() == weekday {
+						count++
+					}
+				}
+			}
+			return count
+		}
+		return roundAtLeastOne(float64(days) / float64(7*r.Interval))
+	case "MONTHLY":
+		// Ежемесячная привычка в интервале меньше месяца не ожидается
+		return int(math.Round(float64(days) / 30))
+	default:
+		if r.Interval <= 1 {
+			return days
+		}
+		count := 0
+		for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+			if daysBetween(origin, day)%r.Interval == 0 {
+				count++
+			}
+		}
+		return count
+	}
+}
+
+func roundAtLeastOne(value float64) int {
+	if value <= 0 {
+		return 0
+	}
+	return max(int(math.Round(value)), 1)
+}
+
+func daysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
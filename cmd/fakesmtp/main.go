@@ -0,0 +1,154 @@
+// Команда fakesmtp — локальный SMTP-сервер для ручной проверки писем: принимает
+// письма и печатает их текстовую версию. С флагом -demo сама отправляет себе
+// напоминание и недельную сводку через ту же очередь, что и сервер.
+//
+//	go run ./cmd/fakesmtp -addr 127.0.0.1:2525
+//	SMTP_HOST=127.0.0.1 SMTP_PORT=2525 go run ./cmd
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"habit-tracker-api/email"
+	"habit-tracker-api/email/smtptest"
+	"habit-tracker-api/models"
+	"habit-tracker-api/reminders"
+	"habit-tracker-api/storage"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:2525", "listen address")
+	demo := flag.Bool("demo", false, "send a sample reminder and digest and exit")
+	locale := flag.String("locale", email.DefaultLocale, "locale for -demo: ru or en")
+	fail := flag.Int("fail", 0, "reply 451 to the first N messages to exercise retries")
+	flag.Parse()
+
+	server, err := smtptest.Listen(*addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer server.Close()
+	server.FailNext(*fail)
+	log.Printf("fake SMTP listening on %s", server.Addr())
+
+	if *demo {
+		if err := sendDemo(server, *locale); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	for {
+		select {
+		case msg := <-server.Received():
+			printMessage(msg)
+		case <-interrupt:
+			return
+		}
+	}
+}
+
+func sendDemo(server *smtptest.Server, locale string) error {
+	sender, err := email.NewSMTPSender(email.Config{
+		Host:     server.Host(),
+		Port:     server.Port(),
+		From:     "habits@example.com",
+		FromName: "Habit Tracker",
+	})
+	if err != nil {
+		return err
+	}
+	templates, err := email.LoadTemplates()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue := email.NewQueue(sender, email.QueueOptions{Backoff: func(int) time.Duration { return 100 * time.Millisecond }})
+	queue.Start(ctx)
+
+	user := &models.User{ID: 1, Name: "Анна", Email: "anna@example.com", Locale: locale}
+	habit := models.Habit{ID: 4, Name: "Тренировка", Category: "спорт", Frequency: "ежедневно"}
+	notifier := email.NewNotifier(queue, templates)
+	if err := notifier.Notify(ctx, reminders.Notification{Habit: habit, User: user, At: time.Now()}); err != nil {
+		return err
+	}
+
+	store := demoStore{user: *user, habit: habit}
+	digest := email.NewDigest(store, queue, templates, nil, time.Local)
+	if _, err := digest.Send(ctx, time.Now()); err != nil {
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-server.Received():
+			printMessage(msg)
+		case <-time.After(10 * time.Second):
+			return fmt.Errorf("timed out waiting for message %d", i+1)
+		}
+	}
+	return nil
+}
+
+func printMessage(msg smtptest.Message) {
+	parsed, err := mail.ReadMessage(bytes.NewReader(msg.Data))
+	if err != nil {
+		log.Printf("unparsable message from %s: %v", msg.From, err)
+		return
+	}
+
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	fmt.Printf("From: %s\nTo: %s\nSubject: %s\n\n", parsed.Header.Get("From"), strings.Join(msg.To, ", "), subject)
+
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		return
+	}
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err != nil {
+			break
+		}
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain") {
+			text, _ := io.ReadAll(part)
+			fmt.Printf("%s\n", text)
+		}
+	}
+	fmt.Println(strings.Repeat("-", 60))
+}
+
+type demoStore struct {
+	user  models.User
+	habit models.Habit
+}
+
+func (s demoStore) GetAllUsers() ([]models.User, error)   { return []models.User{s.user}, nil }
+func (s demoStore) GetAllHabits() ([]models.Habit, error) { return []models.Habit{s.habit}, nil }
+func (s demoStore) GetAllGoals() ([]models.Goal, error) {
+	return []models.Goal{{ID: 1, Title: "Пробежать марафон", TargetDate: time.Now().AddDate(0, 2, 0)}}, nil
+}
+func (s demoStore) GetAllTracks() ([]models.HabitTrack, error) {
+	return []models.HabitTrack{{ID: 1, HabitID: s.habit.ID, Date: time.Now().AddDate(0, 0, -5), Completed: true}}, nil
+}
+func (s demoStore) GetUserStatistics(userID int) (*storage.Statistics, error) {
+	habits, _ := s.GetAllHabits()
+	goals, _ := s.GetAllGoals()
+	tracks, _ := s.GetAllTracks()
+	return storage.ComputeStatistics(habits, goals, tracks, time.Now()), nil
+}
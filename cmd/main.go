@@ -1,9 +1,21 @@
 package main
 
 import (
+	"context"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/email"
+	"habit-tracker-api/events"
 	"habit-tracker-api/handlers"
+	"habit-tracker-api/reminders"
+	"habit-tracker-api/sse"
 	"habit-tracker-api/storage"
+	"habit-tracker-api/telegram"
+	"habit-tracker-api/templates"
+	"habit-tracker-api/webhooks"
 	"log"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -16,12 +28,90 @@ func main() {
 		log.Fatalf("Failed to initialize storage: %v", err)
 	}
 
+	bus := events.NewBus()
+	storage.SetEventBus(bus)
+
+	// Побочные эффекты изменений подписываются на шину независимо друг от друга
+	bus.Subscribe("audit", events.AuditLog(log.Default()))
+	// Отметка выполнения пишется в той же транзакции, что и выполнение привычки
+	storage.OnCommit("completion-track", events.RecordCompletion)
+
+	dispatcher := webhooks.NewDispatcher(storage, webhooks.Options{})
+	dispatcher.Start(context.Background())
+	dispatcher.Subscribe(bus)
+	go dispatcher.WatchOverdue(context.Background(), storage, time.Minute)
+	go storage.WatchRecurring(context.Background(), time.Minute)
+
+	notifiers := []reminders.Notifier{reminders.LogNotifier(log.Default())}
+
+	// Почта включается переменной SMTP_HOST
+	if cfg := email.ConfigFromEnv(); cfg.Enabled() {
+		sender, err := email.NewSMTPSender(cfg)
+		if err != nil {
+			log.Fatalf("Failed to configure SMTP: %v", err)
+		}
+		templates, err := email.LoadTemplates()
+		if err != nil {
+			log.Fatalf("Failed to load email templates: %v", err)
+		}
+
+		mailQueue := email.NewQueue(sender, email.QueueOptions{})
+		mailQueue.Start(context.Background())
+		notifiers = append(notifiers, email.NewNotifier(mailQueue, templates))
+		go email.NewDigest(storage, mailQueue, templates, nil, nil).Run(context.Background())
+	}
+
+	// Бот включается переменной TELEGRAM_BOT_TOKEN
+	if cfg := telegram.ConfigFromEnv(); cfg.Enabled() {
+		bot := telegram.NewBot(telegram.NewClient(cfg.Token, cfg.APIURL), storage, telegram.Options{})
+		notifiers = append(notifiers, bot)
+		go bot.Run(context.Background())
+	}
+
+	scheduler := reminders.NewScheduler(storage, reminders.Multi(notifiers...), reminders.Options{})
+	scheduler.Subscribe(bus)
+	go scheduler.Run(context.Background())
+
+	hub := sse.NewHub(1000, func(userID int) (interface{}, error) {
+		if userID == 0 {
+			return storage.GetStatistics()
+		}
+		return storage.GetUserStatistics(userID)
+	})
+	hub.Subscribe(bus)
+
+	// Каталог шаблонов привычек и наборов целей дополняется файлом из HABIT_TEMPLATES_FILE
+	catalog, err := templates.LoadCatalog(os.Getenv("HABIT_TEMPLATES_FILE"))
+	if err != nil {
+		log.Fatalf("Failed to load habit templates: %v", err)
+	}
+
+	// Предел распакованного файла из архива при импорте, в байтах
+	maxImportFileSize, _ := strconv.ParseInt(os.Getenv("IMPORT_MAX_FILE_SIZE"), 10, 64)
+
 	habitHandler := handlers.NewHabitHandler(storage)
 	goalHandler := handlers.NewGoalHandler(storage)
+	milestoneHandler := handlers.NewMilestoneHandler(storage)
+	progressHandler := handlers.NewProgressHandler(storage)
 	trackHandler := handlers.NewTrackHandler(storage)
+	batchHandler := handlers.NewBatchHandler(storage)
+	exportHandler := handlers.NewExportHandler(storage)
+	importHandler := handlers.NewImportHandler(storage, maxImportFileSize)
+	userHandler := handlers.NewUserHandler(storage)
+	calendarHandler := handlers.NewCalendarHandler(storage)
+	webhookHandler := handlers.NewWebhookHandler(storage, dispatcher)
+	eventsHandler := handlers.NewEventsHandler(hub)
+	socketHandler := handlers.NewSocketHandler(storage, hub)
+	syncHandler := handlers.NewSyncHandler(storage)
+	reportHandler := handlers.NewReportHandler(storage)
+	setupHandler := handlers.NewSetupHandler(storage, catalog)
+	templateHandler := handlers.NewTemplateHandler(storage, catalog)
+	statisticsHandler := handlers.NewStatisticsHandler(storage)
+	access := handlers.NewAccess(storage)
 
 	app := fiber.New(fiber.Config{
-		AppName: "Habit Tracker API",
+		AppName:      "Habit Tracker API",
+		ErrorHandler: apperror.Handler,
 	})
 
 	app.Use(logger.New())
@@ -32,41 +122,99 @@ func main() {
 	habits := api.Group("/habits")
 	{
 		habits.Get("/", habitHandler.GetAllHabits)
-		habits.Get("/:id", habitHandler.GetHabitByID)
+		habits.Get("/:id", access.Habit, habitHandler.GetHabitByID)
 		habits.Post("/", habitHandler.CreateHabit)
-		habits.Put("/:id", habitHandler.UpdateHabit)
-		habits.Delete("/:id", habitHandler.DeleteHabit)
-		habits.Put("/:id/complete", habitHandler.CompleteHabit)
+		habits.Put("/:id", access.Habit, habitHandler.UpdateHabit)
+		habits.Patch("/:id", access.Habit, habitHandler.PatchHabit)
+		habits.Delete("/:id", access.Habit, habitHandler.DeleteHabit)
+		habits.Put("/:id/complete", access.Habit, habitHandler.CompleteHabit)
+		habits.Put("/:id/reopen", access.Habit, habitHandler.ReopenHabit)
 	}
 
 	goals := api.Group("/goals")
 	{
 		goals.Get("/", goalHandler.GetAllGoals)
-		goals.Get("/:id", goalHandler.GetGoalByID)
+		goals.Get("/:id", access.Goal, goalHandler.GetGoalByID)
 		goals.Post("/", goalHandler.CreateGoal)
-		goals.Put("/:id", goalHandler.UpdateGoal)
-		goals.Delete("/:id", goalHandler.DeleteGoal)
-		goals.Put("/:id/complete", goalHandler.CompleteGoal)
+		goals.Put("/:id", access.Goal, goalHandler.UpdateGoal)
+		goals.Patch("/:id", access.Goal, goalHandler.PatchGoal)
+		goals.Delete("/:id", access.Goal, goalHandler.DeleteGoal)
+		goals.Put("/:id/complete", access.Goal, goalHandler.CompleteGoal)
+		goals.Put("/:id/reopen", access.Goal, goalHandler.ReopenGoal)
+		goals.Get("/:id/milestones", access.Goal, milestoneHandler.GetMilestones)
+		goals.Post("/:id/milestones", access.Goal, milestoneHandler.CreateMilestone)
+		goals.Get("/:id/milestones/:milestoneId", access.Goal, milestoneHandler.GetMilestone)
+		goals.Put("/:id/milestones/:milestoneId", access.Goal, milestoneHandler.UpdateMilestone)
+		goals.Patch("/:id/milestones/:milestoneId", access.Goal, milestoneHandler.PatchMilestone)
+		goals.Delete("/:id/milestones/:milestoneId", access.Goal, milestoneHandler.DeleteMilestone)
+		goals.Get("/:id/progress", access.Goal, progressHandler.GetProgress)
+		goals.Post("/:id/progress", access.Goal, progressHandler.CreateEntry)
+		goals.Delete("/:id/progress/:entryId", access.Goal, progressHandler.DeleteEntry)
 	}
 
 	tracks := api.Group("/tracks")
 	{
 		tracks.Get("/", trackHandler.GetAllTracks)
-		tracks.Get("/:id", trackHandler.GetTrackByID)
+		tracks.Get("/:id", access.Track, trackHandler.GetTrackByID)
 		tracks.Post("/", trackHandler.CreateTrack)
-		tracks.Put("/:id", trackHandler.UpdateTrack)
-		tracks.Delete("/:id", trackHandler.DeleteTrack)
+		tracks.Put("/:id", access.Track, trackHandler.UpdateTrack)
+		tracks.Patch("/:id", access.Track, trackHandler.PatchTrack)
+		tracks.Delete("/:id", access.Track, trackHandler.DeleteTrack)
 	}
 
-	api.Get("/statistics", func(c *fiber.Ctx) error {
-		stats, err := storage.GetStatistics()
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to get statistics",
-			})
-		}
-		return c.JSON(stats)
-	})
+	users := api.Group("/users")
+	{
+		users.Get("/", userHandler.GetAllUsers)
+		users.Get("/:id", access.User, userHandler.GetUserByID)
+		users.Post("/", userHandler.CreateUser)
+		users.Post("/:id/feed-token", userHandler.RotateFeedToken)
+		users.Post("/:id/telegram-link", userHandler.CreateTelegramLink)
+		users.Delete("/:id/telegram-link", userHandler.DeleteTelegramLink)
+	}
+
+	hooks := api.Group("/webhooks")
+	{
+		hooks.Get("/", webhookHandler.GetAllWebhooks)
+		hooks.Get("/:id", access.Webhook, webhookHandler.GetWebhookByID)
+		hooks.Post("/", webhookHandler.CreateWebhook)
+		hooks.Put("/:id", access.Webhook, webhookHandler.UpdateWebhook)
+		hooks.Patch("/:id", access.Webhook, webhookHandler.PatchWebhook)
+		hooks.Delete("/:id", access.Webhook, webhookHandler.DeleteWebhook)
+		hooks.Get("/:id/deliveries", access.Webhook, webhookHandler.GetDeliveries)
+		hooks.Post("/:id/ping", access.Webhook, webhookHandler.Ping)
+	}
+
+	reports := api.Group("/reports")
+	{
+		reports.Get("/weekly", reportHandler.Weekly)
+		reports.Get("/monthly", reportHandler.Monthly)
+		reports.Get("/chart.pdf", reportHandler.ChartPDF)
+		reports.Get("/chart.svg", reportHandler.ChartSVG)
+	}
+
+	habitTemplates := api.Group("/templates")
+	{
+		habitTemplates.Get("/", templateHandler.GetAllTemplates)
+		habitTemplates.Post("/:id/apply", templateHandler.Apply)
+	}
+
+	goalTemplates := api.Group("/goal-templates")
+	{
+		goalTemplates.Get("/", setupHandler.GetAllSetups)
+		goalTemplates.Get("/:id", setupHandler.GetSetup)
+		goalTemplates.Post("/:id/apply", setupHandler.Apply)
+	}
+
+	api.Post("/batch", batchHandler.ExecuteBatch)
+	api.Get("/export", exportHandler.Export)
+	api.Post("/import", importHandler.Import)
+	api.Get("/calendar.ics", calendarHandler.Feed)
+	api.Get("/events", eventsHandler.Stream)
+	api.Get("/ws", socketHandler.Upgrade, socketHandler.Serve())
+	api.Get("/sync", syncHandler.Pull)
+	api.Post("/sync", syncHandler.Push)
+
+	api.Get("/statistics", statisticsHandler.GetStatistics)
 
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
@@ -77,14 +225,24 @@ func main() {
 				"/api/v1/goals",
 				"/api/v1/tracks",
 				"/api/v1/statistics",
+				"/api/v1/batch",
+				"/api/v1/export",
+				"/api/v1/import",
+				"/api/v1/users",
+				"/api/v1/calendar.ics",
+				"/api/v1/webhooks",
+				"/api/v1/events",
+				"/api/v1/ws",
+				"/api/v1/sync",
+				"/api/v1/reports",
+				"/api/v1/templates",
+				"/api/v1/goal-templates",
 			},
 		})
 	})
 
 	app.Use(func(c *fiber.Ctx) error {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Endpoint not found",
-		})
+		return apperror.New(fiber.StatusNotFound, apperror.CodeRouteNotFound, "Endpoint not found")
 	})
 
 	log.Println("Server starting on :3000")
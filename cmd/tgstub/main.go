@@ -0,0 +1,42 @@
+// Команда tgstub — заглушка Telegram Bot API для ручной проверки бота. Строки,
+// введённые в консоль, приходят боту как сообщения из чата -chat, ответы бота печатаются.
+//
+//	go run ./cmd/tgstub -addr 127.0.0.1:8081
+//	TELEGRAM_BOT_TOKEN=test TELEGRAM_API_URL=http://127.0.0.1:8081 go run ./cmd
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"habit-tracker-api/telegram/telegramtest"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8081", "listen address")
+	chat := flag.Int64("chat", 1001, "chat ID of the simulated user")
+	flag.Parse()
+
+	server, err := telegramtest.Listen(*addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer server.Close()
+	log.Printf("Bot API stub listening on %s, type messages for chat %d", server.URL, *chat)
+
+	go func() {
+		for reply := range server.Replies() {
+			fmt.Printf("[bot → %d]\n%s\n\n", reply.ChatID, reply.Text)
+		}
+	}()
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			server.SendText(*chat, line)
+		}
+	}
+}
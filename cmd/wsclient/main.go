@@ -0,0 +1,80 @@
+// wsclient — консольный клиент канала синхронизации /api/v1/ws для ручной проверки.
+//
+//	go run ./cmd/wsclient -user 1
+//	go run ./cmd/wsclient -complete habit:4
+package main
+
+import (
+	"flag"
+	"fmt"
+	"habit-tracker-api/socket"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fasthttp/websocket"
+)
+
+func main() {
+	url := flag.String("url", "ws://localhost:3000/api/v1/ws", "адрес канала")
+	userID := flag.Int("user", 0, "ID пользователя (X-User-ID)")
+	entities := flag.String("entities", "habit,goal,track", "сущности через запятую, в том числе statistics")
+	lastEventID := flag.Int64("last", 0, "last_event_id для досылки пропущенных событий")
+	complete := flag.String("complete", "", "отметить выполненной: habit:<id> или goal:<id>")
+	duration := flag.Duration("for", 0, "сколько слушать; 0 — до прерывания")
+	flag.Parse()
+
+	header := http.Header{}
+	if *userID != 0 {
+		header.Set("X-User-ID", strconv.Itoa(*userID))
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(*url, header)
+	if err != nil {
+		log.Fatalf("dial %s: %v", *url, err)
+	}
+	defer conn.Close()
+
+	send := func(msg socket.ClientMessage) {
+		if err := conn.WriteJSON(msg); err != nil {
+			log.Fatalf("send %s: %v", msg.Type, err)
+		}
+	}
+
+	send(socket.ClientMessage{
+		Type:        socket.TypeSubscribe,
+		ID:          "sub",
+		Entities:    strings.Split(*entities, ","),
+		LastEventID: *lastEventID,
+	})
+
+	if *complete != "" {
+		entity, id, _ := strings.Cut(*complete, ":")
+		entityID, err := strconv.Atoi(id)
+		if err != nil {
+			log.Fatalf("invalid -complete %q", *complete)
+		}
+		send(socket.ClientMessage{Type: socket.TypeComplete, ID: "complete", Entity: entity, EntityID: entityID})
+	}
+
+	if *duration > 0 {
+		time.AfterFunc(*duration, func() {
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			os.Exit(0)
+		})
+	}
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return
+			}
+			log.Fatalf("read: %v", err)
+		}
+		fmt.Println(strings.TrimSpace(string(data)))
+	}
+}
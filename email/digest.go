@@ -0,0 +1,192 @@
+package email
+
+import (
+	"context"
+	"habit-tracker-api/models"
+	"habit-tracker-api/reminders"
+	"habit-tracker-api/storage"
+	"log"
+	"sort"
+	"time"
+)
+
+// DigestStore — данные для еженедельной сводки. Реализуется storage.JSONStorage.
+type DigestStore interface {
+	GetAllUsers() ([]models.User, error)
+	GetAllHabits() ([]models.Habit, error)
+	GetAllGoals() ([]models.Goal, error)
+	GetAllTracks() ([]models.HabitTrack, error)
+	GetUserStatistics(userID int) (*storage.Statistics, error)
+}
+
+type habitSummary struct {
+	Name      string
+	Frequency string
+	Done      int
+}
+
+type goalSummary struct {
+	Title     string
+	Completed bool
+	Overdue   bool
+	Deadline  string
+}
+
+type digestData struct {
+	UserName  string
+	Period    string
+	Completed int
+	Habits    []habitSummary
+	Goals     []goalSummary
+	Stats     *storage.Statistics
+}
+
+// Digest рассылает по понедельникам сводку за прошедшую неделю всем пользователям с email.
+type Digest struct {
+	store     DigestStore
+	queue     *Queue
+	templates *Templates
+	clock     reminders.Clock
+	location  *time.Location
+	// SendAt — время отправки в понедельник
+	SendAt time.Duration
+}
+
+func NewDigest(store DigestStore, queue *Queue, templates *Templates, clock reminders.Clock, location *time.Location) *Digest {
+	if clock == nil {
+		clock = reminders.SystemClock
+	}
+	if location == nil {
+		location = time.Local
+	}
+	return &Digest{
+		store:     store,
+		queue:     queue,
+		templates: templates,
+		clock:     clock,
+		location:  location,
+		SendAt:    9 * time.Hour,
+	}
+}
+
+// Run ждёт ближайшего понедельника и отправляет сводку за прошедшую неделю, до отмены ctx.
+func (d *Digest) Run(ctx context.Context) {
+	for {
+		now := d.clock.Now().In(d.location)
+		next := d.nextRun(now)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.clock.After(next.Sub(now)):
+			weekStart := monday(next).AddDate(0, 0, -7)
+			if _, err := d.Send(ctx, weekStart); err != nil {
+				log.Printf("email: weekly digest failed: %v", err)
+			}
+		}
+	}
+}
+
+func (d *Digest) nextRun(now time.Time) time.Time {
+	next := monday(now).Add(d.SendAt)
+	if !next.After(now) {
+		next = monday(now).AddDate(0, 0, 7).Add(d.SendAt)
+	}
+	return next
+}
+
+func monday(t time.Time) time.Time {
+	year, month, day := t.Date()
+	weekday := int(t.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	return time.Date(year, month, day-weekday+1, 0, 0, 0, 0, t.Location())
+}
+
+// Send ставит в очередь сводку за неделю, начинающуюся с weekStart, и возвращает число писем.
+func (d *Digest) Send(ctx context.Context, weekStart time.Time) (int, error) {
+	users, err := d.store.GetAllUsers()
+	if err != nil {
+		return 0, err
+	}
+	habits, err := d.store.GetAllHabits()
+	if err != nil {
+		return 0, err
+	}
+	goals, err := d.store.GetAllGoals()
+	if err != nil {
+		return 0, err
+	}
+	tracks, err := d.store.GetAllTracks()
+	if err != nil {
+		return 0, err
+	}
+
+	weekStart = monday(weekStart.In(d.location))
+	weekEnd := weekStart.AddDate(0, 0, 7)
+	now := d.clock.Now()
+
+	sent := 0
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+		if user.Email == "" {
+			continue
+		}
+
+		stats, err := d.store.GetUserStatistics(user.ID)
+		if err != nil {
+			return sent, err
+		}
+
+		locale := userLocale(&user)
+		data := digestData{
+			UserName: user.Name,
+			Period:   localeDate(weekStart, locale) + " – " + localeDate(weekEnd.AddDate(0, 0, -1), locale),
+			Stats:    stats,
+		}
+
+		done := make(map[int]int)
+		for _, track := range tracks {
+			date := track.Date.In(d.location)
+			if track.Completed && !date.Before(weekStart) && date.Before(weekEnd) {
+				done[track.HabitID]++
+			}
+		}
+		for _, habit := range habits {
+			if habit.UserID != 0 && habit.UserID != user.ID {
+				continue
+			}
+			data.Habits = append(data.Habits, habitSummary{Name: habit.Name, Frequency: habit.Frequency, Done: done[habit.ID]})
+			data.Completed += done[habit.ID]
+		}
+		sort.SliceStable(data.Habits, func(i, j int) bool {
+			return data.Habits[i].Done > data.Habits[j].Done
+		})
+
+		for _, goal := range goals {
+			if goal.UserID != 0 && goal.UserID != user.ID {
+				continue
+			}
+			data.Goals = append(data.Goals, goalSummary{
+				Title:     goal.Title,
+				Completed: goal.Completed,
+				Overdue:   !goal.Completed && goal.TargetDate.Before(now),
+				Deadline:  localeDate(goal.TargetDate, locale),
+			})
+		}
+
+		msg, err := d.templates.Render("digest", locale, data)
+		if err != nil {
+			return sent, err
+		}
+		msg.To = user.Email
+		if err := d.queue.Enqueue(msg); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
@@ -0,0 +1,114 @@
+package email
+
+import (
+	"context"
+	"habit-tracker-api/models"
+	"habit-tracker-api/reminders"
+	"habit-tracker-api/storage"
+	"strings"
+	"testing"
+	"time"
+)
+
+type digestStore struct {
+	users  []models.User
+	habits []models.Habit
+	goals  []models.Goal
+	tracks []models.HabitTrack
+	now    time.Time
+}
+
+func (s *digestStore) GetAllUsers() ([]models.User, error)        { return s.users, nil }
+func (s *digestStore) GetAllHabits() ([]models.Habit, error)      { return s.habits, nil }
+func (s *digestStore) GetAllGoals() ([]models.Goal, error)        { return s.goals, nil }
+func (s *digestStore) GetAllTracks() ([]models.HabitTrack, error) { return s.tracks, nil }
+func (s *digestStore) GetUserStatistics(userID int) (*storage.Statistics, error) {
+	return storage.ComputeStatistics(s.habits, s.goals, s.tracks, s.now), nil
+}
+
+// outbox — Sender, который складывает письма в канал.
+type outbox chan Message
+
+func (o outbox) Send(ctx context.Context, msg Message) error {
+	o <- msg
+	return nil
+}
+
+func TestDigestContent(t *testing.T) {
+	now := time.Date(2026, 6, 8, 9, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return time.Date(2026, 6, d, 18, 0, 0, 0, time.UTC) }
+	store := &digestStore{
+		users: []models.User{
+			{ID: 1, Name: "Анна", Email: "anna@example.com", Locale: "ru"},
+			{ID: 2, Name: "Борис"},
+		},
+		habits: []models.Habit{
+			{ID: 1, UserID: 1, Name: "Бег", Frequency: "ежедневно"},
+			{ID: 2, Name: "Вода", Frequency: "ежедневно"},
+			{ID: 3, UserID: 2, Name: "Чтение", Frequency: "ежедневно"},
+		},
+		goals: []models.Goal{
+			{ID: 1, UserID: 1, Title: "Марафон", TargetDate: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)},
+			{ID: 2, UserID: 1, Title: "Десять книг", TargetDate: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		tracks: []models.HabitTrack{
+			{HabitID: 1, Date: day(1), Completed: true},
+			{HabitID: 1, Date: day(3), Completed: true},
+			{HabitID: 1, Date: day(7), Completed: true},
+			{HabitID: 2, Date: day(2), Completed: true},
+			{HabitID: 2, Date: day(4), Completed: false},
+			// Вне недели и чужая привычка
+			{HabitID: 1, Date: day(8), Completed: true},
+			{HabitID: 3, Date: day(2), Completed: true},
+		},
+		now: now,
+	}
+
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := make(outbox, 10)
+	queue := NewQueue(sent, QueueOptions{Workers: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue.Start(ctx)
+
+	digest := NewDigest(store, queue, templates, reminders.NewFakeClock(now), time.UTC)
+	// Неделя определяется по любому её дню
+	n, err := digest.Send(ctx, day(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("sent %d digests, want 1 for the only user with email", n)
+	}
+
+	var msg Message
+	select {
+	case msg = <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("digest was not sent")
+	}
+
+	if msg.To != "anna@example.com" || msg.Subject != "Итоги недели 01.06.2026 – 07.06.2026" {
+		t.Errorf("message to %s with subject %q", msg.To, msg.Subject)
+	}
+	for _, want := range []string{
+		"Анна, ваши итоги за неделю",
+		"Выполнений за неделю: 4",
+		"• Бег (ежедневно): 3\n  • Вода (ежедневно): 1",
+		"• Марафон — до 01.09.2026",
+		"• Десять книг — просрочена (01.05.2026)",
+	} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("digest has no %q:\n%s", want, msg.Text)
+		}
+	}
+	if strings.Contains(msg.Text, "Чтение") {
+		t.Errorf("digest lists another user's habit:\n%s", msg.Text)
+	}
+	if !strings.Contains(msg.HTML, "Бег") {
+		t.Errorf("HTML version has no habits:\n%s", msg.HTML)
+	}
+}
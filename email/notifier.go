@@ -0,0 +1,43 @@
+package email
+
+import (
+	"context"
+	"habit-tracker-api/models"
+	"habit-tracker-api/reminders"
+)
+
+type reminderData struct {
+	UserName string
+	Habit    models.Habit
+	At       string
+}
+
+// Notifier отправляет напоминания письмом владельцу привычки. Привычки без
+// владельца или пользователи без адреса пропускаются.
+type Notifier struct {
+	queue     *Queue
+	templates *Templates
+}
+
+func NewNotifier(queue *Queue, templates *Templates) *Notifier {
+	return &Notifier{queue: queue, templates: templates}
+}
+
+func (n *Notifier) Notify(ctx context.Context, reminder reminders.Notification) error {
+	if reminder.User == nil || reminder.User.Email == "" {
+		return nil
+	}
+
+	locale := userLocale(reminder.User)
+	msg, err := n.templates.Render("reminder", locale, reminderData{
+		UserName: reminder.User.Name,
+		Habit:    reminder.Habit,
+		At:       localeDate(reminder.At, locale) + " " + reminder.At.Format("15:04"),
+	})
+	if err != nil {
+		return err
+	}
+	msg.To = reminder.User.Email
+
+	return n.queue.Enqueue(msg)
+}
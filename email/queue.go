@@ -0,0 +1,133 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+var ErrQueueFull = errors.New("email queue is full")
+
+type QueueOptions struct {
+	Workers     int
+	QueueSize   int
+	MaxAttempts int
+	// Backoff — задержка перед повтором после attempt-й неудачной попытки
+	Backoff func(attempt int) time.Duration
+	Logger  *log.Logger
+}
+
+func (o QueueOptions) withDefaults() QueueOptions {
+	if o.Workers <= 0 {
+		o.Workers = 2
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 500
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.Backoff == nil {
+		o.Backoff = ExponentialBackoff(30*time.Second, 30*time.Minute)
+	}
+	if o.Logger == nil {
+		o.Logger = log.Default()
+	}
+	return o
+}
+
+// ExponentialBackoff удваивает задержку после каждой попытки: base, 2*base, 4*base... но не больше max.
+func ExponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 1; i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+		if delay > max {
+			delay = max
+		}
+		return delay
+	}
+}
+
+type job struct {
+	msg     Message
+	attempt int
+}
+
+// Queue отправляет письма в фоне и повторяет неудачные отправки с растущей задержкой.
+// Очередь в памяти: письма, не отправленные до остановки сервера, теряются.
+type Queue struct {
+	sender Sender
+	opts   QueueOptions
+	jobs   chan job
+	ctx    context.Context
+}
+
+func NewQueue(sender Sender, opts QueueOptions) *Queue {
+	opts = opts.withDefaults()
+	return &Queue{
+		sender: sender,
+		opts:   opts,
+		jobs:   make(chan job, opts.QueueSize),
+		ctx:    context.Background(),
+	}
+}
+
+// Start запускает обработчики очереди. Они завершаются вместе с ctx.
+func (q *Queue) Start(ctx context.Context) {
+	q.ctx = ctx
+	for i := 0; i < q.opts.Workers; i++ {
+		go q.work(ctx)
+	}
+}
+
+// Enqueue ставит письмо в очередь, не дожидаясь отправки.
+func (q *Queue) Enqueue(msg Message) error {
+	return q.enqueue(job{msg: msg, attempt: 1})
+}
+
+func (q *Queue) enqueue(j job) error {
+	select {
+	case q.jobs <- j:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (q *Queue) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-q.jobs:
+			q.send(ctx, j)
+		}
+	}
+}
+
+func (q *Queue) send(ctx context.Context, j job) {
+	err := q.sender.Send(ctx, j.msg)
+	if err == nil {
+		return
+	}
+
+	if Permanent(err) || j.attempt >= q.opts.MaxAttempts {
+		q.opts.Logger.Printf("email: giving up on %q to %s after %d attempt(s): %v", j.msg.Subject, j.msg.To, j.attempt, err)
+		return
+	}
+
+	delay := q.opts.Backoff(j.attempt)
+	q.opts.Logger.Printf("email: %q to %s failed (attempt %d), retrying in %s: %v", j.msg.Subject, j.msg.To, j.attempt, delay, err)
+	j.attempt++
+	time.AfterFunc(delay, func() {
+		if q.ctx.Err() != nil {
+			return
+		}
+		if err := q.enqueue(j); err != nil {
+			q.opts.Logger.Printf("email: dropped retry of %q to %s: %v", j.msg.Subject, j.msg.To, err)
+		}
+	})
+}
@@ -0,0 +1,179 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"habit-tracker-api/email/smtptest"
+	"log"
+	"mime"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingSender считает попытки отправки.
+type countingSender struct {
+	Sender
+	mu    sync.Mutex
+	calls int
+}
+
+func (s *countingSender) Send(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	s.calls++
+	s.mu.Unlock()
+	return s.Sender.Send(ctx, msg)
+}
+
+func (s *countingSender) attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// logLines передаёт строки лога очереди в канал, чтобы дождаться отказа от письма.
+type logLines chan string
+
+func (l logLines) Write(p []byte) (int, error) {
+	select {
+	case l <- string(p):
+	default:
+	}
+	return len(p), nil
+}
+
+type queueFixture struct {
+	server *smtptest.Server
+	sender *countingSender
+	queue  *Queue
+	log    logLines
+}
+
+func newQueue(t *testing.T, maxAttempts int) *queueFixture {
+	t.Helper()
+	server, err := smtptest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	smtpSender, err := NewSMTPSender(Config{Host: server.Host(), Port: server.Port(), From: "habits@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &queueFixture{server: server, sender: &countingSender{Sender: smtpSender}, log: make(logLines, 100)}
+	f.queue = NewQueue(f.sender, QueueOptions{
+		Workers:     1,
+		MaxAttempts: maxAttempts,
+		Backoff:     func(int) time.Duration { return time.Millisecond },
+		Logger:      log.New(f.log, "", 0),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	f.queue.Start(ctx)
+	return f
+}
+
+func (f *queueFixture) receive(t *testing.T) smtptest.Message {
+	t.Helper()
+	select {
+	case msg := <-f.server.Received():
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not delivered")
+		return smtptest.Message{}
+	}
+}
+
+func (f *queueFixture) waitLog(t *testing.T, substr string) string {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case line := <-f.log:
+			if strings.Contains(line, substr) {
+				return line
+			}
+		case <-timeout:
+			t.Fatalf("no %q in the queue log", substr)
+			return ""
+		}
+	}
+}
+
+var testMessage = Message{To: "anna@example.com", Subject: "Напоминание: Бег", Text: "Пора на пробежку", HTML: "<p>Пора на пробежку</p>"}
+
+func TestQueueSend(t *testing.T) {
+	f := newQueue(t, 3)
+	if err := f.queue.Enqueue(testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := f.receive(t)
+	if msg.From != "habits@example.com" || len(msg.To) != 1 || msg.To[0] != "anna@example.com" {
+		t.Errorf("envelope = %s -> %v", msg.From, msg.To)
+	}
+	parsed, err := mail.ReadMessage(bytes.NewReader(msg.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != testMessage.Subject {
+		t.Errorf("subject = %q, %v", subject, err)
+	}
+	if n := f.sender.attempts(); n != 1 {
+		t.Errorf("sent in %d attempts, want 1", n)
+	}
+}
+
+func TestQueueRetriesTransientFailure(t *testing.T) {
+	f := newQueue(t, 3)
+	f.server.FailNext(2)
+	if err := f.queue.Enqueue(testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	f.receive(t)
+	if n := f.sender.attempts(); n != 3 {
+		t.Errorf("sent in %d attempts, want 3", n)
+	}
+	if n := len(f.server.Messages()); n != 1 {
+		t.Errorf("server accepted %d messages, want 1", n)
+	}
+}
+
+func TestQueueGivesUp(t *testing.T) {
+	f := newQueue(t, 3)
+	f.server.FailNext(10)
+	if err := f.queue.Enqueue(testMessage); err != nil {
+		t.Fatal(err)
+	}
+
+	line := f.waitLog(t, "giving up")
+	if !strings.Contains(line, "after 3 attempt(s)") {
+		t.Errorf("log = %q", line)
+	}
+	if n := f.sender.attempts(); n != 3 {
+		t.Errorf("sent in %d attempts, want 3", n)
+	}
+	if n := len(f.server.Messages()); n != 0 {
+		t.Errorf("server accepted %d messages", n)
+	}
+}
+
+func TestQueueDoesNotRetryInvalidAddress(t *testing.T) {
+	f := newQueue(t, 3)
+	msg := testMessage
+	msg.To = "not an address"
+	if err := f.queue.Enqueue(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	f.waitLog(t, "after 1 attempt(s)")
+	if n := f.sender.attempts(); n != 1 {
+		t.Errorf("sent in %d attempts, want 1", n)
+	}
+}
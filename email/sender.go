@@ -0,0 +1,153 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidAddress = errors.New("invalid email address")
+
+// Sender отправляет одно письмо.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config — параметры SMTP-сервера и отправителя.
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	FromName string
+	Timeout  time.Duration
+}
+
+// ConfigFromEnv читает настройки из SMTP_HOST, SMTP_PORT, SMTP_USERNAME,
+// SMTP_PASSWORD, SMTP_FROM и SMTP_FROM_NAME. Без SMTP_HOST почта выключена.
+func ConfigFromEnv() Config {
+	port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	return Config{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+		FromName: os.Getenv("SMTP_FROM_NAME"),
+	}
+}
+
+func (c Config) Enabled() bool {
+	return c.Host != ""
+}
+
+// SMTPSender отправляет письма через SMTP. Если сервер поддерживает STARTTLS,
+// соединение шифруется до авторизации.
+type SMTPSender struct {
+	cfg  Config
+	from mail.Address
+	now  func() time.Time
+}
+
+func NewSMTPSender(cfg Config) (*SMTPSender, error) {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.From == "" {
+		cfg.From = "habits@" + cfg.Host
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("invalid sender address %q: %w", cfg.From, err)
+	}
+
+	return &SMTPSender{
+		cfg:  cfg,
+		from: mail.Address{Name: cfg.FromName, Address: cfg.From},
+		now:  time.Now,
+	}, nil
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("recipient %q: %w", msg.To, ErrInvalidAddress)
+	}
+	msg.To = to.String()
+
+	body, err := msg.Bytes(s.from, s.now())
+	if err != nil {
+		return fmt.Errorf("build message: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		// PlainAuth сам откажется передавать пароль по нешифрованному соединению не на localhost
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// Permanent — ошибка, после которой повтор не поможет: неверный адрес или ответ 5xx.
+func Permanent(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 500
+	}
+	return errors.Is(err, ErrInvalidAddress)
+}
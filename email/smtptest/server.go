@@ -0,0 +1,185 @@
+// Package smtptest — локальный SMTP-сервер для проверки отправки писем,
+// по аналогии с net/http/httptest. Письма не отправляются дальше, а сохраняются в памяти.
+package smtptest
+
+import (
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Message — принятое письмо в том виде, в каком его передал клиент.
+type Message struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+type Server struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	messages []Message
+	failures int
+	received chan Message
+	wg       sync.WaitGroup
+}
+
+// NewServer запускает сервер на случайном порту 127.0.0.1.
+func NewServer() (*Server, error) {
+	return Listen("127.0.0.1:0")
+}
+
+func Listen(addr string) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: listener,
+		received: make(chan Message, 100),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *Server) Host() string {
+	host, _, _ := net.SplitHostPort(s.Addr())
+	return host
+}
+
+func (s *Server) Port() int {
+	_, port, _ := net.SplitHostPort(s.Addr())
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+// Messages возвращает копию всех принятых писем.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Received — канал принятых писем, чтобы дождаться отправки из фоновой очереди.
+func (s *Server) Received() <-chan Message {
+	return s.received
+}
+
+// FailNext отвечает временной ошибкой 451 на следующие n писем — для проверки повторов.
+func (s *Server) FailNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = n
+}
+
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(code int, text string) {
+		tp.PrintfLine("%d %s", code, text)
+	}
+
+	reply(220, "localhost fake SMTP ready")
+	var msg Message
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		command, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(command) {
+		case "EHLO":
+			tp.PrintfLine("250-localhost")
+			reply(250, "8BITMIME")
+		case "HELO":
+			reply(250, "localhost")
+		case "MAIL":
+			msg = Message{From: address(arg)}
+			reply(250, "OK")
+		case "RCPT":
+			msg.To = append(msg.To, address(arg))
+			reply(250, "OK")
+		case "DATA":
+			reply(354, "End data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			msg.Data = data
+			if s.accept(msg) {
+				reply(250, "OK: queued")
+			} else {
+				reply(451, "Temporary failure, try again later")
+			}
+			msg = Message{}
+		case "RSET":
+			msg = Message{}
+			reply(250, "OK")
+		case "NOOP":
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "Bye")
+			return
+		default:
+			reply(502, "Command not implemented")
+		}
+	}
+}
+
+func (s *Server) accept(msg Message) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failures > 0 {
+		s.failures--
+		return false
+	}
+	s.messages = append(s.messages, msg)
+	select {
+	case s.received <- msg:
+	default:
+	}
+	return true
+}
+
+// address достаёт адрес из "FROM:<user@example.com>" или "TO:<...>".
+func address(arg string) string {
+	_, value, _ := strings.Cut(arg, ":")
+	value = strings.TrimSpace(value)
+	if i := strings.IndexByte(value, ' '); i >= 0 {
+		value = value[:i]
+	}
+	return strings.Trim(value, "<>")
+}
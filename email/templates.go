@@ -0,0 +1,120 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"habit-tracker-api/models"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// DefaultLocale — язык писем, если у пользователя не задан или не поддерживается свой.
+const DefaultLocale = "ru"
+
+var Locales = []string{"ru", "en"}
+
+//go:embed templates/*
+var templateFS embed.FS
+
+var templateFuncs = map[string]interface{}{
+	"percent": func(value float64) string {
+		return fmt.Sprintf("%.0f%%", value)
+	},
+}
+
+// Templates — шаблоны писем: для каждого вида и языка текстовая версия
+// (с темой в блоке "subject") и HTML-версия.
+type Templates struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+func LoadTemplates() (*Templates, error) {
+	t := &Templates{
+		text: make(map[string]*texttemplate.Template),
+		html: make(map[string]*htmltemplate.Template),
+	}
+
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		path := "templates/" + entry.Name()
+		switch {
+		case strings.HasSuffix(entry.Name(), ".txt"):
+			tmpl, err := texttemplate.New(entry.Name()).Funcs(templateFuncs).ParseFS(templateFS, path)
+			if err != nil {
+				return nil, err
+			}
+			t.text[strings.TrimSuffix(entry.Name(), ".txt")] = tmpl
+		case strings.HasSuffix(entry.Name(), ".html"):
+			tmpl, err := htmltemplate.New(entry.Name()).Funcs(templateFuncs).ParseFS(templateFS, path)
+			if err != nil {
+				return nil, err
+			}
+			t.html[strings.TrimSuffix(entry.Name(), ".html")] = tmpl
+		}
+	}
+	return t, nil
+}
+
+// Render собирает письмо name ("reminder", "digest") на языке locale.
+func (t *Templates) Render(name, locale string, data interface{}) (Message, error) {
+	key := name + "." + locale
+	if _, ok := t.text[key]; !ok {
+		key = name + "." + DefaultLocale
+	}
+	text, ok := t.text[key]
+	if !ok {
+		return Message{}, fmt.Errorf("email template %q not found", name)
+	}
+
+	var subject, body, html bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := text.Execute(&body, data); err != nil {
+		return Message{}, err
+	}
+	if tmpl, ok := t.html[key]; ok {
+		if err := tmpl.Execute(&html, data); err != nil {
+			return Message{}, err
+		}
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    body.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// dateLayouts — формат дат в письмах для каждого языка.
+var dateLayouts = map[string]string{
+	"ru": "02.01.2006",
+	"en": "Jan 2, 2006",
+}
+
+func localeDate(t time.Time, locale string) string {
+	layout, ok := dateLayouts[locale]
+	if !ok {
+		layout = dateLayouts[DefaultLocale]
+	}
+	return t.Format(layout)
+}
+
+// userLocale — язык писем пользователя.
+func userLocale(user *models.User) string {
+	if user != nil {
+		for _, locale := range Locales {
+			if user.Locale == locale {
+				return locale
+			}
+		}
+	}
+	return DefaultLocale
+}
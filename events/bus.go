@@ -0,0 +1,182 @@
+package events
+
+import (
+	"log"
+	"sync"
+)
+
+// Bus — шина событий внутри процесса.
+//
+// Порядок: каждый подписчик получает события строго в порядке Publish. Хранилище
+// публикует события под своей блокировкой, поэтому этот порядок совпадает с порядком
+// сохранения. Подписчики обрабатывают события в своих горутинах независимо друг от друга:
+// медленный подписчик не задерживает остальных и запись в хранилище, а подписчик может
+// сам менять хранилище, не попадая во взаимную блокировку.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers []*subscriber
+	closed      bool
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+type Handler func(event Event)
+
+type subscriber struct {
+	name    string
+	handler Handler
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []Event
+	busy    bool
+	stopped bool
+	done    chan struct{}
+}
+
+// Subscribe регистрирует обработчик всех событий. name используется в логах.
+// Возвращает функцию отписки, которая дожидается обработки уже полученных событий.
+func (b *Bus) Subscribe(name string, handler Handler) (unsubscribe func()) {
+	sub := &subscriber{name: name, handler: handler, done: make(chan struct{})}
+	sub.cond = sync.NewCond(&sub.mu)
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		close(sub.done)
+		return func() {}
+	}
+	b.subscribers = append(b.subscribers, sub)
+	b.mu.Unlock()
+
+	go sub.run()
+
+	return func() {
+		b.mu.Lock()
+		for i, s := range b.subscribers {
+			if s == sub {
+				b.subscribers = append(b.subscribers[:i:i], b.subscribers[i+1:]...)
+				break
+			}
+		}
+		b.mu.Unlock()
+		sub.stop()
+	}
+}
+
+// On подписывает обработчик на события одного типа.
+func On[T Event](b *Bus, name string, handler func(event T)) (unsubscribe func()) {
+	return b.Subscribe(name, func(event Event) {
+		if typed, ok := event.(T); ok {
+			handler(typed)
+		}
+	})
+}
+
+// Publish ставит события в очередь каждого подписчика и не ждёт их обработки.
+func (b *Bus) Publish(events ...Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, sub := range b.subscribers {
+		sub.push(events)
+	}
+}
+
+// Flush ждёт, пока подписчики обработают все опубликованные события.
+func (b *Bus) Flush() {
+	b.mu.Lock()
+	subscribers := append([]*subscriber(nil), b.subscribers...)
+	b.mu.Unlock()
+
+	for _, sub := range subscribers {
+		sub.wait()
+	}
+}
+
+// Close дожидается обработки очередей и останавливает подписчиков. Новые события отбрасываются.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	subscribers := b.subscribers
+	b.subscribers = nil
+	b.closed = true
+	b.mu.Unlock()
+
+	for _, sub := range subscribers {
+		sub.stop()
+	}
+}
+
+func (s *subscriber) push(events []Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return
+	}
+	// Очередь не ограничена: иначе Publish под блокировкой хранилища мог бы ждать подписчика,
+	// который сам ждёт эту блокировку
+	s.queue = append(s.queue, events...)
+	s.cond.Broadcast()
+}
+
+func (s *subscriber) run() {
+	defer close(s.done)
+
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && !s.stopped {
+			s.cond.Wait()
+		}
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		event := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.busy = true
+		s.mu.Unlock()
+
+		s.handle(event)
+
+		s.mu.Lock()
+		s.busy = false
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	}
+}
+
+func (s *subscriber) handle(event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("events: subscriber %s panicked on %s: %v", s.name, event.Name(), r)
+		}
+	}()
+	s.handler(event)
+}
+
+func (s *subscriber) wait() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for (len(s.queue) > 0 || s.busy) && !s.stopped {
+		s.cond.Wait()
+	}
+}
+
+// stop обрабатывает оставшиеся события и завершает горутину подписчика.
+func (s *subscriber) stop() {
+	s.mu.Lock()
+	s.stopped = true
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	<-s.done
+}
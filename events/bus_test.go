@@ -0,0 +1,215 @@
+package events
+
+import (
+	"habit-tracker-api/models"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+)
+
+// number — событие для тестов шины.
+type number int
+
+func (n number) Name() string         { return "test.number" }
+func (n number) UserID() int          { return 0 }
+func (n number) Payload() interface{} { return int(n) }
+
+// recorder запоминает полученные события.
+type recorder struct {
+	mu     sync.Mutex
+	events []int
+	delay  time.Duration
+}
+
+func (r *recorder) handle(event Event) {
+	if r.delay > 0 {
+		time.Sleep(r.delay)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, int(event.(number)))
+}
+
+func (r *recorder) received() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.events...)
+}
+
+func expectSequence(t *testing.T, name string, got []int, n int) {
+	t.Helper()
+	if len(got) != n {
+		t.Fatalf("%s got %d events, want %d", name, len(got), n)
+	}
+	for i, value := range got {
+		if value != i {
+			t.Fatalf("%s got %v, want events in publish order", name, got)
+		}
+	}
+}
+
+func TestOrderPerSubscriber(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+
+	fast := &recorder{}
+	slow := &recorder{delay: 100 * time.Microsecond}
+	bus.Subscribe("fast", fast.handle)
+	bus.Subscribe("slow", slow.handle)
+
+	// События публикуются и по одному, и пачками, как их публикует Batch
+	const total = 300
+	for i := 0; i < total; {
+		size := 1 + i%4
+		batch := []Event{}
+		for j := 0; j < size && i < total; j++ {
+			batch = append(batch, number(i))
+			i++
+		}
+		bus.Publish(batch...)
+	}
+	bus.Flush()
+
+	expectSequence(t, "fast", fast.received(), total)
+	expectSequence(t, "slow", slow.received(), total)
+}
+
+func TestSlowSubscriberDoesNotBlock(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+
+	release := make(chan struct{})
+	bus.Subscribe("blocked", func(Event) { <-release })
+	delivered := make(chan Event, 1)
+	bus.Subscribe("free", func(event Event) { delivered <- event })
+
+	published := make(chan struct{})
+	go func() {
+		bus.Publish(number(1))
+		close(published)
+	}()
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("Publish waits for a blocked subscriber")
+	}
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Fatal("blocked subscriber delays the others")
+	}
+	close(release)
+}
+
+func TestFlush(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+
+	sub := &recorder{delay: time.Millisecond}
+	bus.Subscribe("sub", sub.handle)
+
+	bus.Publish(number(0), number(1), number(2))
+	bus.Publish(number(3))
+	bus.Flush()
+	expectSequence(t, "after flush", sub.received(), 4)
+
+	// Flush без событий не блокируется
+	bus.Flush()
+}
+
+func TestClose(t *testing.T) {
+	bus := NewBus()
+
+	sub := &recorder{delay: time.Millisecond}
+	bus.Subscribe("sub", sub.handle)
+	bus.Publish(number(0), number(1), number(2), number(3), number(4))
+
+	// Close дожидается обработки уже опубликованных событий
+	bus.Close()
+	expectSequence(t, "after close", sub.received(), 5)
+
+	bus.Publish(number(5))
+	late := &recorder{}
+	unsubscribe := bus.Subscribe("late", late.handle)
+	bus.Publish(number(6))
+	bus.Flush()
+	unsubscribe()
+
+	if got := sub.received(); len(got) != 5 {
+		t.Errorf("closed bus delivered %v", got)
+	}
+	if got := late.received(); len(got) != 0 {
+		t.Errorf("subscriber added after close got %v", got)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+
+	sub := &recorder{delay: time.Millisecond}
+	unsubscribe := bus.Subscribe("sub", sub.handle)
+	other := &recorder{}
+	bus.Subscribe("other", other.handle)
+
+	bus.Publish(number(0), number(1))
+	unsubscribe()
+	expectSequence(t, "unsubscribed", sub.received(), 2)
+
+	bus.Publish(number(2))
+	bus.Flush()
+	expectSequence(t, "unsubscribed", sub.received(), 2)
+	expectSequence(t, "other", other.received(), 3)
+}
+
+func TestPanicRecovery(t *testing.T) {
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(output)
+
+	bus := NewBus()
+	defer bus.Close()
+
+	survivor := &recorder{}
+	bus.Subscribe("panics", func(event Event) {
+		if event.(number) == 1 {
+			panic("boom")
+		}
+		survivor.handle(event)
+	})
+	other := &recorder{}
+	bus.Subscribe("other", other.handle)
+
+	bus.Publish(number(0), number(1), number(2))
+	bus.Flush()
+
+	if got := survivor.received(); len(got) != 2 || got[0] != 0 || got[1] != 2 {
+		t.Errorf("subscriber after panic got %v, want [0 2]", got)
+	}
+	expectSequence(t, "other", other.received(), 3)
+}
+
+func TestOn(t *testing.T) {
+	bus := NewBus()
+	defer bus.Close()
+
+	var mu sync.Mutex
+	var names []string
+	On(bus, "typed", func(event HabitCompleted) {
+		mu.Lock()
+		defer mu.Unlock()
+		names = append(names, event.Habit.Name)
+	})
+
+	bus.Publish(number(0), HabitCreated{}, HabitCompleted{Habit: models.Habit{Name: "Бег"}})
+	bus.Flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(names) != 1 || names[0] != "Бег" {
+		t.Errorf("typed handler got %v, want [Бег]", names)
+	}
+}
@@ -0,0 +1,81 @@
+package events
+
+import "habit-tracker-api/models"
+
+// Event — изменение данных, о котором сообщает хранилище после сохранения.
+type Event interface {
+	// Name — имя события вида "habit.completed", совпадает с именем события вебхуков
+	Name() string
+	// UserID — владелец изменённой записи, 0 для общих записей
+	UserID() int
+	// Payload — запись после изменения (для удаления — удалённая запись)
+	Payload() interface{}
+}
+
+type HabitCreated struct{ Habit models.Habit }
+type HabitUpdated struct{ Habit models.Habit }
+type HabitDeleted struct{ Habit models.Habit }
+type HabitCompleted struct{ Habit models.Habit }
+type HabitReopened struct{ Habit models.Habit }
+
+func (e HabitCreated) Name() string           { return "habit.created" }
+func (e HabitCreated) UserID() int            { return e.Habit.UserID }
+func (e HabitCreated) Payload() interface{}   { return e.Habit }
+func (e HabitUpdated) Name() string           { return "habit.updated" }
+func (e HabitUpdated) UserID() int            { return e.Habit.UserID }
+func (e HabitUpdated) Payload() interface{}   { return e.Habit }
+func (e HabitDeleted) Name() string           { return "habit.deleted" }
+func (e HabitDeleted) UserID() int            { return e.Habit.UserID }
+func (e HabitDeleted) Payload() interface{}   { return e.Habit }
+func (e HabitCompleted) Name() string         { return "habit.completed" }
+func (e HabitCompleted) UserID() int          { return e.Habit.UserID }
+func (e HabitCompleted) Payload() interface{} { return e.Habit }
+func (e HabitReopened) Name() string          { return "habit.reopened" }
+func (e HabitReopened) UserID() int           { return e.Habit.UserID }
+func (e HabitReopened) Payload() interface{}  { return e.Habit }
+
+type GoalCreated struct{ Goal models.Goal }
+type GoalUpdated struct{ Goal models.Goal }
+type GoalDeleted struct{ Goal models.Goal }
+type GoalCompleted struct{ Goal models.Goal }
+type GoalReopened struct{ Goal models.Goal }
+
+func (e GoalCreated) Name() string           { return "goal.created" }
+func (e GoalCreated) UserID() int            { return e.Goal.UserID }
+func (e GoalCreated) Payload() interface{}   { return e.Goal }
+func (e GoalUpdated) Name() string           { return "goal.updated" }
+func (e GoalUpdated) UserID() int            { return e.Goal.UserID }
+func (e GoalUpdated) Payload() interface{}   { return e.Goal }
+func (e GoalDeleted) Name() string           { return "goal.deleted" }
+func (e GoalDeleted) UserID() int            { return e.Goal.UserID }
+func (e GoalDeleted) Payload() interface{}   { return e.Goal }
+func (e GoalCompleted) Name() string         { return "goal.completed" }
+func (e GoalCompleted) UserID() int          { return e.Goal.UserID }
+func (e GoalCompleted) Payload() interface{} { return e.Goal }
+func (e GoalReopened) Name() string          { return "goal.reopened" }
+func (e GoalReopened) UserID() int           { return e.Goal.UserID }
+func (e GoalReopened) Payload() interface{}  { return e.Goal }
+
+// События отметок несут владельца привычки, у самой отметки владельца нет.
+type TrackCreated struct {
+	Track models.HabitTrack
+	Owner int
+}
+type TrackUpdated struct {
+	Track models.HabitTrack
+	Owner int
+}
+type TrackDeleted struct {
+	Track models.HabitTrack
+	Owner int
+}
+
+func (e TrackCreated) Name() string         { return "track.created" }
+func (e TrackCreated) UserID() int          { return e.Owner }
+func (e TrackCreated) Payload() interface{} { return e.Track }
+func (e TrackUpdated) Name() string         { return "track.updated" }
+func (e TrackUpdated) UserID() int          { return e.Owner }
+func (e TrackUpdated) Payload() interface{} { return e.Track }
+func (e TrackDeleted) Name() string         { return "track.deleted" }
+func (e TrackDeleted) UserID() int          { return e.Owner }
+func (e TrackDeleted) Payload() interface{} { return e.Track }
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"encoding/json"
+	"habit-tracker-api/models"
+	"log"
+	"time"
+)
+
+// TrackStore — запись отметок. Реализуется транзакцией хранилища storage.Tx.
+type TrackStore interface {
+	CreateTrack(track *models.HabitTrack) error
+}
+
+// CompletionNote — заметка отметки, которую создаёт RecordCompletion.
+const CompletionNote = "Marked as completed via API"
+
+// RecordCompletion при выполнении привычки добавляет выполненную отметку на момент
+// выполнения. Подключается хуком транзакции storage.OnCommit, а не подпиской на шину:
+// отметка должна сохраниться вместе с привычкой.
+func RecordCompletion[S TrackStore](store S, event Event) error {
+	completed, ok := event.(HabitCompleted)
+	if !ok {
+		return nil
+	}
+	at := time.Now()
+	if history := completed.Habit.History; len(history) > 0 {
+		at = history[len(history)-1].At
+	}
+	return store.CreateTrack(&models.HabitTrack{
+		HabitID:   completed.Habit.ID,
+		Date:      at,
+		Completed: true,
+		Notes:     CompletionNote,
+	})
+}
+
+// AuditLog пишет каждое событие одной строкой в журнал.
+func AuditLog(logger *log.Logger) Handler {
+	return func(event Event) {
+		payload, err := json.Marshal(event.Payload())
+		if err != nil {
+			payload = []byte(`null`)
+		}
+		logger.Printf("audit: %s user=%d %s", event.Name(), event.UserID(), payload)
+	}
+}
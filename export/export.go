@@ -0,0 +1,420 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"habit-tracker-api/models"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaVersion — версия формата JSON/NDJSON-выгрузки. Увеличивается при несовместимых изменениях.
+const SchemaVersion = 1
+
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// trackPageSize — сколько записей отслеживания читается из хранилища за раз.
+const trackPageSize = 500
+
+// Source — данные для выгрузки. Реализуется storage.JSONStorage.
+type Source interface {
+	GetAllHabits() ([]models.Habit, error)
+	GetAllGoals() ([]models.Goal, error)
+	TracksPage(afterID, limit int) ([]models.HabitTrack, error)
+}
+
+// Document — JSON-выгрузка целиком. При записи поток формируется по частям,
+// структура используется для чтения выгрузки при импорте.
+type Document struct {
+	SchemaVersion int                 `json:"schema_version"`
+	ExportedAt    time.Time           `json:"exported_at"`
+	Habits        []models.Habit      `json:"habits"`
+	Goals         []models.Goal       `json:"goals"`
+	Tracks        []models.HabitTrack `json:"tracks"`
+}
+
+// Record — одна строка NDJSON-выгрузки. Первая строка имеет тип "meta".
+type Record struct {
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schema_version,omitempty"`
+	ExportedAt    *time.Time      `json:"exported_at,omitempty"`
+	Data          json.RawMessage `json:"data,omitempty"`
+}
+
+const (
+	RecordMeta  = "meta"
+	RecordHabit = "habit"
+	RecordGoal  = "goal"
+	RecordTrack = "track"
+)
+
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "application/zip"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json"
+	}
+}
+
+func FileName(format string, now time.Time) string {
+	ext := format
+	if format == FormatCSV {
+		ext = "zip"
+	}
+	return fmt.Sprintf("habit-tracker-export-%s.%s", now.Format("20060102"), ext)
+}
+
+func Write(w io.Writer, format string, src Source, now time.Time) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, src)
+	case FormatNDJSON:
+		return WriteNDJSON(w, src, now)
+	default:
+		return WriteJSON(w, src, now)
+	}
+}
+
+func sortedHabits(src Source) ([]models.Habit, error) {
+	habits, err := src.GetAllHabits()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(habits, func(i, j int) bool { return habits[i].ID < habits[j].ID })
+	return habits, nil
+}
+
+func sortedGoals(src Source) ([]models.Goal, error) {
+	goals, err := src.GetAllGoals()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(goals, func(i, j int) bool { return goals[i].ID < goals[j].ID })
+	return goals, nil
+}
+
+// eachTrack обходит все записи отслеживания страницами по trackPageSize.
+func eachTrack(src Source, fn func(track models.HabitTrack) error) error {
+	afterID := 0
+	for {
+		tracks, err := src.TracksPage(afterID, trackPageSize)
+		if err != nil {
+			return err
+		}
+		if len(tracks) == 0 {
+			return nil
+		}
+		for _, track := range tracks {
+			if err := fn(track); err != nil {
+				return err
+			}
+		}
+		afterID = tracks[len(tracks)-1].ID
+	}
+}
+
+// WriteJSON пишет версионированный документ, не собирая его целиком в памяти.
+func WriteJSON(w io.Writer, src Source, now time.Time) error {
+	bw := bufio.NewWriter(w)
+
+	exportedAt, err := json.Marshal(now)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(bw, "{\"schema_version\":%d,\"exported_at\":%s,\"habits\":[", SchemaVersion, exportedAt)
+
+	habits, err := sortedHabits(src)
+	if err != nil {
+		return err
+	}
+	for i, habit := range habits {
+		if err := writeJSONItem(bw, i, habit); err != nil {
+			return err
+		}
+	}
+
+	bw.WriteString("],\"goals\":[")
+	goals, err := sortedGoals(src)
+	if err != nil {
+		return err
+	}
+	for i, goal := range goals {
+		if err := writeJSONItem(bw, i, goal); err != nil {
+			return err
+		}
+	}
+
+	bw.WriteString("],\"tracks\":[")
+	i := 0
+	err = eachTrack(src, func(track models.HabitTrack) error {
+		err := writeJSONItem(bw, i, track)
+		i++
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	bw.WriteString("]}\n")
+
+	return bw.Flush()
+}
+
+func writeJSONItem(w *bufio.Writer, index int, item interface{}) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if index > 0 {
+		w.WriteByte(',')
+	}
+	w.WriteString("\n")
+	_, err = w.Write(data)
+	return err
+}
+
+// WriteNDJSON пишет по одной записи на строку: сначала meta, затем привычки, цели и отслеживания.
+func WriteNDJSON(w io.Writer, src Source, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	if err := enc.Encode(Record{Type: RecordMeta, SchemaVersion: SchemaVersion, ExportedAt: &now}); err != nil {
+		return err
+	}
+
+	writeRecord := func(recordType string, item interface{}) error {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		return enc.Encode(Record{Type: recordType, Data: data})
+	}
+
+	habits, err := sortedHabits(src)
+	if err != nil {
+		return err
+	}
+	for _, habit := range habits {
+		if err := writeRecord(RecordHabit, habit); err != nil {
+			return err
+		}
+	}
+
+	goals, err := sortedGoals(src)
+	if err != nil {
+		return err
+	}
+	for _, goal := range goals {
+		if err := writeRecord(RecordGoal, goal); err != nil {
+			return err
+		}
+	}
+
+	err = eachTrack(src, func(track models.HabitTrack) error {
+		return writeRecord(RecordTrack, track)
+	})
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+var (
+	HabitColumns = []string{"id", "name", "description", "category", "frequency", "created_at", "completed", "version"}
+	GoalColumns  = []string{"id", "title", "description", "target_date", "created_at", "completed", "completed_at", "habit_ids", "version"}
+	TrackColumns = []string{"id", "habit_id", "date", "completed", "notes", "version"}
+)
+
+// WriteCSV пишет zip-архив с habits.csv, goals.csv и tracks.csv.
+func WriteCSV(w io.Writer, src Source) error {
+	zw := zip.NewWriter(w)
+
+	habits, err := sortedHabits(src)
+	if err != nil {
+		return err
+	}
+	err = writeCSVFile(zw, "habits.csv", HabitColumns, func(cw *csv.Writer) error {
+		for _, habit := range habits {
+			if err := cw.Write([]string{
+				strconv.Itoa(habit.ID),
+				habit.Name,
+				habit.Description,
+				habit.Category,
+				habit.Frequency,
+				formatTime(habit.CreatedAt),
+				strconv.FormatBool(habit.Completed),
+				strconv.Itoa(habit.Version),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	goals, err := sortedGoals(src)
+	if err != nil {
+		return err
+	}
+	err = writeCSVFile(zw, "goals.csv", GoalColumns, func(cw *csv.Writer) error {
+		for _, goal := range goals {
+			habitIDs := make([]string, len(goal.HabitIDs))
+			for i, id := range goal.HabitIDs {
+				habitIDs[i] = strconv.Itoa(id)
+			}
+			if err := cw.Write([]string{
+				strconv.Itoa(goal.ID),
+				goal.Title,
+				goal.Description,
+				formatTime(goal.TargetDate),
+				formatTime(goal.CreatedAt),
+				strconv.FormatBool(goal.Completed),
+				formatTime(goal.CompletedAt),
+				strings.Join(habitIDs, ";"),
+				strconv.Itoa(goal.Version),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = writeCSVFile(zw, "tracks.csv", TrackColumns, func(cw *csv.Writer) error {
+		return eachTrack(src, func(track models.HabitTrack) error {
+			return cw.Write([]string{
+				strconv.Itoa(track.ID),
+				strconv.Itoa(track.HabitID),
+				formatTime(track.Date),
+				strconv.FormatBool(track.Completed),
+				track.Notes,
+				strconv.Itoa(track.Version),
+			})
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func writeCSVFile(zw *zip.Writer, name string, header []string, rows func(cw *csv.Writer) error) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(f)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := rows(cw); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatTime оставляет пустую ячейку для нулевого времени.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// ForUser ограничивает выгрузку записями пользователя userID и общими записями без владельца,
+// как в отчётах и синхронизации. Отметки выгружаются только у видимых привычек.
+// Для userID 0 источник возвращается как есть.
+func ForUser(src Source, userID int) Source {
+	if userID == 0 {
+		return src
+	}
+	return &userSource{src: src, userID: userID}
+}
+
+type userSource struct {
+	src    Source
+	userID int
+	// visible — привычки пользователя на момент первого запроса
+	visible map[int]bool
+}
+
+func (s *userSource) owns(ownerID int) bool {
+	return ownerID == 0 || ownerID == s.userID
+}
+
+func (s *userSource) GetAllHabits() ([]models.Habit, error) {
+	habits, err := s.src.GetAllHabits()
+	if err != nil {
+		return nil, err
+	}
+	owned := habits[:0:0]
+	s.visible = make(map[int]bool)
+	for _, habit := range habits {
+		if s.owns(habit.UserID) {
+			owned = append(owned, habit)
+			s.visible[habit.ID] = true
+		}
+	}
+	return owned, nil
+}
+
+func (s *userSource) GetAllGoals() ([]models.Goal, error) {
+	goals, err := s.src.GetAllGoals()
+	if err != nil {
+		return nil, err
+	}
+	owned := goals[:0:0]
+	for _, goal := range goals {
+		if s.owns(goal.UserID) {
+			owned = append(owned, goal)
+		}
+	}
+	return owned, nil
+}
+
+// TracksPage читает страницы источника, пока не наберёт видимые отметки: пустая страница
+// означает конец выгрузки.
+func (s *userSource) TracksPage(afterID, limit int) ([]models.HabitTrack, error) {
+	if s.visible == nil {
+		if _, err := s.GetAllHabits(); err != nil {
+			return nil, err
+		}
+	}
+	for {
+		tracks, err := s.src.TracksPage(afterID, limit)
+		if err != nil || len(tracks) == 0 {
+			return nil, err
+		}
+		var owned []models.HabitTrack
+		for _, track := range tracks {
+			if s.visible[track.HabitID] {
+				owned = append(owned, track)
+			}
+		}
+		if len(owned) > 0 {
+			return owned, nil
+		}
+		afterID = tracks[len(tracks)-1].ID
+	}
+}
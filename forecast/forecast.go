@@ -0,0 +1,213 @@
+package forecast
+
+import (
+	"habit-tracker-api/calendar"
+	"habit-tracker-api/models"
+	"math"
+	"time"
+)
+
+// Статус цели относительно темпа, нужного к сроку
+const (
+	OnTrack  = "on_track"
+	AtRisk   = "at_risk"
+	OffTrack = "off_track"
+)
+
+// По чему оценивается цель
+const (
+	BasisValue      = "value"      // числовая цель: значение и прогноз по журналу
+	BasisMilestones = "milestones" // доля выполненных вех
+	BasisHabits     = "habits"     // выполнение связанных привычек по расписанию
+	BasisDeadline   = "deadline"   // оценивать не по чему, но срок уже прошёл
+)
+
+const (
+	// Доля ожидаемого темпа, начиная с которой цель идёт по плану и под угрозой
+	onTrackRatio = 0.9
+	atRiskRatio  = 0.7
+	// grace — в начале срока (меньше этой доли пройденного времени) отставание не считается
+	grace = 0.1
+	// minExpected — меньше стольких ожидаемых выполнений привычек оценивать рано
+	minExpected = 3
+)
+
+// Forecast — оценка цели. Actual и Expected — проценты: сделано и сколько должно было
+// быть сделано к этому моменту при равномерном темпе.
+type Forecast struct {
+	Status   string  `json:"status"`
+	Basis    string  `json:"basis"`
+	Actual   float64 `json:"actual"`
+	Expected float64 `json:"expected"`
+	// DaysLeft — дней до срока; отрицательное — срок прошёл
+	DaysLeft      int        `json:"days_left"`
+	ProjectedDate *time.Time `json:"projected_completion_date,omitempty"`
+}
+
+// Goal оценивает невыполненную цель на момент now. Пусто для выполненной цели
+// и для цели, которую не по чему оценить до наступления срока.
+//
+// Числовая цель с прогнозом идёт по плану, если прогноз не позже срока, и под угрозой,
+// если опаздывает меньше чем на десятую часть срока. Остальные цели сравнивают
+// фактический процент (значение, вехи или выполнение привычек) с ожидаемым.
+func Goal(goal models.Goal, habits []models.Habit, tracks []models.HabitTrack, now time.Time) *Forecast {
+	if goal.Completed {
+		return nil
+	}
+
+	start, deadline := goal.CreatedAt, goal.TargetDate
+	total := deadline.Sub(start)
+	elapsed := 1.0
+	if total > 0 {
+		elapsed = math.Min(1, math.Max(0, now.Sub(start).Seconds()/total.Seconds()))
+	}
+
+	forecast := &Forecast{
+		Expected: round(elapsed * 100),
+		DaysLeft: int(math.Ceil(deadline.Sub(now).Hours() / 24)),
+	}
+
+	switch {
+	case goal.TargetValue != nil && goal.Progress != nil:
+		forecast.Basis = BasisValue
+		forecast.Actual = *goal.Progress
+		forecast.ProjectedDate = goal.ProjectedDate
+	case len(goal.Milestones) > 0 && goal.Progress != nil:
+		forecast.Basis = BasisMilestones
+		forecast.Actual = *goal.Progress
+	case len(goal.HabitIDs) > 0:
+		rate, ok := habitRate(goal, habits, tracks, now)
+		if !ok {
+			return deadlineOnly(forecast, now, deadline)
+		}
+		// Выполнение привычек — уже доля от ожидаемого: сравниваем его с полным темпом
+		forecast.Basis = BasisHabits
+		forecast.Actual = round(rate * 100)
+		forecast.Expected = 100
+		forecast.Status = status(rate, 1)
+		if !now.Before(deadline) {
+			forecast.Status = OffTrack
+		}
+		return forecast
+	default:
+		return deadlineOnly(forecast, now, deadline)
+	}
+
+	switch {
+	case !now.Before(deadline):
+		forecast.Status = OffTrack
+	case forecast.ProjectedDate != nil:
+		late := forecast.ProjectedDate.Sub(deadline)
+		switch {
+		case late <= 0:
+			forecast.Status = OnTrack
+		case late.Seconds() <= total.Seconds()*(1-onTrackRatio):
+			forecast.Status = AtRisk
+		default:
+			forecast.Status = OffTrack
+		}
+	case elapsed < grace:
+		forecast.Status = OnTrack
+	default:
+		forecast.Status = status(forecast.Actual/100, elapsed)
+	}
+	return forecast
+}
+
+func deadlineOnly(forecast *Forecast, now, deadline time.Time) *Forecast {
+	if now.Before(deadline) {
+		return nil
+	}
+	forecast.Basis = BasisDeadline
+	forecast.Status = OffTrack
+	return forecast
+}
+
+func status(actual, expected float64) string {
+	switch ratio := actual / expected; {
+	case ratio >= onTrackRatio:
+		return OnTrack
+	case ratio >= atRiskRatio:
+		return AtRisk
+	default:
+		return OffTrack
+	}
+}
+
+// habitRate — доля выполнений связанных привычек от ожидаемого по их расписанию
+// с создания цели по вчерашний день: сегодняшний день ещё не закончился.
+func habitRate(goal models.Goal, habits []models.Habit, tracks []models.HabitTrack, now time.Time) (float64, bool) {
+	loc := now.Location()
+	today := startOfDay(now)
+	linked := make(map[int]models.Habit)
+	for _, habit := range habits {
+		for _, id := range goal.HabitIDs {
+			if habit.ID == id {
+				linked[id] = habit
+			}
+		}
+	}
+
+	// Дни с выполненными отметками: несколько отметок за день считаются одной
+	done := make(map[int]map[int64]bool)
+	for _, track := range tracks {
+		if _, ok := linked[track.HabitID]; !ok || !track.Completed {
+			continue
+		}
+		day := startOfDay(track.Date.In(loc))
+		if day.Before(startOfDay(goal.CreatedAt.In(loc))) || !day.Before(today) {
+			continue
+		}
+		if done[track.HabitID] == nil {
+			done[track.HabitID] = make(map[int64]bool)
+		}
+		done[track.HabitID][day.Unix()] = true
+	}
+
+	expected, completed := 0, 0
+	for id, habit := range linked {
+		created := startOfDay(habit.CreatedAt.In(loc))
+		from := startOfDay(goal.CreatedAt.In(loc))
+		if created.After(from) {
+			from = created
+		}
+		n := calendar.RuleOf(habit.Frequency).Expected(from, today, created)
+		expected += n
+		completed += min(len(done[id]), n)
+	}
+
+	if expected < minExpected {
+		return 0, false
+	}
+	return float64(completed) / float64(expected), true
+}
+
+// Summary — число невыполненных целей по статусам.
+type Summary struct {
+	OnTrack  int `json:"on_track"`
+	AtRisk   int `json:"at_risk"`
+	OffTrack int `json:"off_track"`
+}
+
+func (s *Summary) Add(forecast *Forecast) {
+	if forecast == nil {
+		return
+	}
+	switch forecast.Status {
+	case OnTrack:
+		s.OnTrack++
+	case AtRisk:
+		s.AtRisk++
+	case OffTrack:
+		s.OffTrack++
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func round(value float64) float64 {
+	return math.Round(value*10) / 10
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Access — промежуточные обработчики маршрутов с :id. Пользователь из X-User-ID
+// работает только со своими и общими записями (без владельца); чужая запись отвечает
+// 404, как несуществующая. Без заголовка доступны все записи.
+type Access struct {
+	storage *storage.JSONStorage
+}
+
+func NewAccess(storage *storage.JSONStorage) *Access {
+	return &Access{storage: storage}
+}
+
+// Habit проверяет привычку :id.
+func (a *Access) Habit(c *fiber.Ctx) error {
+	return a.check(c, "habit", "Habit", func(id int) (int, bool) {
+		habit, err := a.storage.GetHabitByID(id)
+		if err != nil {
+			return 0, false
+		}
+		return habit.UserID, true
+	})
+}
+
+// Goal проверяет цель :id — и для маршрутов её вех и прогресса.
+func (a *Access) Goal(c *fiber.Ctx) error {
+	return a.check(c, "goal", "Goal", func(id int) (int, bool) {
+		goal, err := a.storage.GetGoalByID(id)
+		if err != nil {
+			return 0, false
+		}
+		return goal.UserID, true
+	})
+}
+
+// Track проверяет отметку :id по владельцу её привычки.
+func (a *Access) Track(c *fiber.Ctx) error {
+	return a.check(c, "track", "Track", func(id int) (int, bool) {
+		track, err := a.storage.GetTrackByID(id)
+		if err != nil {
+			return 0, false
+		}
+		habit, err := a.storage.GetHabitByID(track.HabitID)
+		if err != nil {
+			return 0, true
+		}
+		return habit.UserID, true
+	})
+}
+
+// Webhook проверяет подписку :id. Подписка без владельца получает события всех
+// пользователей, поэтому общей не считается: её видят только запросы без X-User-ID.
+func (a *Access) Webhook(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(c, "webhook")
+	if err != nil {
+		return err
+	}
+	if webhook, err := a.storage.GetWebhookByID(id); err == nil && !webhookVisibleTo(webhook.UserID, userID) {
+		return apperror.NotFound("Webhook not found")
+	}
+	return c.Next()
+}
+
+// User пропускает к пользователю :id только его самого.
+func (a *Access) User(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(c, "user")
+	if err != nil {
+		return err
+	}
+	if userID != 0 && id != userID {
+		return apperror.NotFound("User not found")
+	}
+	return c.Next()
+}
+
+// check пропускает запрос, если запись доступна. Несуществующую запись
+// обработчик найдёт сам и ответит 404.
+func (a *Access) check(c *fiber.Ctx, entity, title string, owner func(id int) (int, bool)) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(c, entity)
+	if err != nil {
+		return err
+	}
+	if ownerID, exists := owner(id); exists && !visibleTo(ownerID, userID) {
+		return apperror.NotFound(title + " not found")
+	}
+	return c.Next()
+}
+
+func webhookVisibleTo(ownerID, userID int) bool {
+	return userID == 0 || ownerID == userID
+}
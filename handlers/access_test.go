@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"habit-tracker-api/models"
+	"habit-tracker-api/webhooks"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newAccessApp подключает обработчики с проверкой доступа так же, как cmd/main.go.
+func newAccessApp(f *ownership) *fiber.App {
+	access := NewAccess(f.store)
+	habits := NewHabitHandler(f.store)
+	goals := NewGoalHandler(f.store)
+	milestones := NewMilestoneHandler(f.store)
+	tracks := NewTrackHandler(f.store)
+	users := NewUserHandler(f.store)
+	hooks := NewWebhookHandler(f.store, webhooks.NewDispatcher(f.store, webhooks.Options{}))
+
+	app := newTestApp()
+	app.Get("/habits", habits.GetAllHabits)
+	app.Get("/habits/:id", access.Habit, habits.GetHabitByID)
+	app.Put("/habits/:id/complete", access.Habit, habits.CompleteHabit)
+	app.Delete("/habits/:id", access.Habit, habits.DeleteHabit)
+	app.Get("/goals", goals.GetAllGoals)
+	app.Get("/goals/:id", access.Goal, goals.GetGoalByID)
+	app.Get("/goals/:id/milestones", access.Goal, milestones.GetMilestones)
+	app.Get("/tracks", tracks.GetAllTracks)
+	app.Get("/tracks/:id", access.Track, tracks.GetTrackByID)
+	app.Post("/tracks", tracks.CreateTrack)
+	app.Patch("/tracks/:id", access.Track, tracks.PatchTrack)
+	app.Get("/users", users.GetAllUsers)
+	app.Get("/users/:id", access.User, users.GetUserByID)
+	app.Get("/webhooks", hooks.GetAllWebhooks)
+	app.Post("/webhooks", hooks.CreateWebhook)
+	app.Get("/webhooks/:id", access.Webhook, hooks.GetWebhookByID)
+	app.Get("/statistics", NewStatisticsHandler(f.store).GetStatistics)
+	return app
+}
+
+func TestAccessByID(t *testing.T) {
+	f := newOwnership(t)
+	app := newAccessApp(f)
+
+	foreignHabit := "/habits/" + strconv.Itoa(f.foreign.ID)
+	foreignGoal := "/goals/" + strconv.Itoa(f.goal.ID)
+	foreignTrack := "/tracks/" + strconv.Itoa(f.track.ID)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		userID int
+		body   interface{}
+		status int
+	}{
+		{"foreign habit", http.MethodGet, foreignHabit, 1, nil, http.StatusNotFound},
+		{"complete foreign habit", http.MethodPut, foreignHabit + "/complete", 1, nil, http.StatusNotFound},
+		{"delete foreign habit", http.MethodDelete, foreignHabit, 1, nil, http.StatusNotFound},
+		{"own habit", http.MethodGet, foreignHabit, 2, nil, http.StatusOK},
+		{"shared habit", http.MethodGet, "/habits/" + strconv.Itoa(f.shared.ID), 2, nil, http.StatusOK},
+		{"without user", http.MethodGet, foreignHabit, 0, nil, http.StatusOK},
+		{"missing habit", http.MethodGet, "/habits/99", 1, nil, http.StatusNotFound},
+		{"invalid user", http.MethodGet, foreignHabit, -1, nil, http.StatusBadRequest},
+		{"foreign goal", http.MethodGet, foreignGoal, 1, nil, http.StatusNotFound},
+		{"foreign milestones", http.MethodGet, foreignGoal + "/milestones", 1, nil, http.StatusNotFound},
+		{"foreign track", http.MethodGet, foreignTrack, 1, nil, http.StatusNotFound},
+		{"track on foreign habit", http.MethodPost, "/tracks", 1,
+			map[string]interface{}{"habit_id": f.foreign.ID, "date": time.Now(), "completed": true}, http.StatusNotFound},
+		{"move track to foreign habit", http.MethodPatch, foreignTrack, 2,
+			map[string]int{"habit_id": f.own.ID}, http.StatusNotFound},
+		{"foreign user", http.MethodGet, "/users/2", 1, nil, http.StatusNotFound},
+		{"self", http.MethodGet, "/users/1", 1, nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []string
+			userID := tt.userID
+			if userID < 0 {
+				headers = []string{HeaderUserID, "abc"}
+				userID = 0
+			}
+			resp := call(t, app, tt.method, tt.path, userID, tt.body, headers...)
+			if resp.status != tt.status {
+				t.Errorf("status = %d, want %d: %s", resp.status, tt.status, resp.body)
+			}
+		})
+	}
+
+	habit, err := f.store.GetHabitByID(f.foreign.ID)
+	if err != nil || habit.Completed {
+		t.Errorf("foreign habit = %+v, %v", habit, err)
+	}
+	track, err := f.store.GetTrackByID(f.track.ID)
+	if err != nil || track.HabitID != f.foreign.ID {
+		t.Errorf("foreign track = %+v, %v", track, err)
+	}
+}
+
+func TestAccessLists(t *testing.T) {
+	f := newOwnership(t)
+	app := newAccessApp(f)
+
+	var habits struct {
+		Habits []models.Habit `json:"habits"`
+	}
+	call(t, app, http.MethodGet, "/habits", 1, nil).decode(t, &habits)
+	if len(habits.Habits) != 2 {
+		t.Errorf("user 1 sees %d habits, want own and shared", len(habits.Habits))
+	}
+	for _, habit := range habits.Habits {
+		if habit.UserID == 2 {
+			t.Errorf("user 1 sees habit %q of user 2", habit.Name)
+		}
+	}
+	call(t, app, http.MethodGet, "/habits", 0, nil).decode(t, &habits)
+	if len(habits.Habits) != 3 {
+		t.Errorf("request without user sees %d habits, want 3", len(habits.Habits))
+	}
+
+	var goals struct {
+		Count int `json:"count"`
+	}
+	call(t, app, http.MethodGet, "/goals", 1, nil).decode(t, &goals)
+	if goals.Count != 0 {
+		t.Errorf("user 1 sees %d goals of user 2", goals.Count)
+	}
+
+	var tracks struct {
+		Count int `json:"count"`
+	}
+	call(t, app, http.MethodGet, "/tracks", 1, nil).decode(t, &tracks)
+	if tracks.Count != 0 {
+		t.Errorf("user 1 sees %d tracks of user 2", tracks.Count)
+	}
+	call(t, app, http.MethodGet, "/tracks", 2, nil).decode(t, &tracks)
+	if tracks.Count != 1 {
+		t.Errorf("user 2 sees %d tracks, want 1", tracks.Count)
+	}
+
+	var stats struct {
+		TotalHabits int `json:"total_habits"`
+		TotalGoals  int `json:"total_goals"`
+	}
+	call(t, app, http.MethodGet, "/statistics", 1, nil).decode(t, &stats)
+	if stats.TotalHabits != 2 || stats.TotalGoals != 0 {
+		t.Errorf("user 1 statistics = %+v, want 2 habits and no goals", stats)
+	}
+
+	var users struct {
+		Users []UserResponse `json:"users"`
+	}
+	call(t, app, http.MethodGet, "/users", 1, nil).decode(t, &users)
+	if len(users.Users) != 1 || users.Users[0].ID != 1 {
+		t.Errorf("user 1 sees users %+v, want only self", users.Users)
+	}
+
+	resp := call(t, app, http.MethodPost, "/webhooks", 2, map[string]interface{}{
+		"url": "https://example.com/hook", "events": []string{"*"},
+	})
+	var created struct {
+		Webhook WebhookResponse `json:"webhook"`
+	}
+	resp.decode(t, &created)
+	var hooks struct {
+		Count int `json:"count"`
+	}
+	call(t, app, http.MethodGet, "/webhooks", 1, nil).decode(t, &hooks)
+	if hooks.Count != 0 {
+		t.Errorf("user 1 sees %d webhooks of user 2", hooks.Count)
+	}
+	if resp := call(t, app, http.MethodGet, "/webhooks/"+strconv.Itoa(created.Webhook.ID), 1, nil); resp.status != http.StatusNotFound {
+		t.Errorf("foreign webhook: %d", resp.status)
+	}
+	call(t, app, http.MethodGet, "/webhooks", 2, nil).decode(t, &hooks)
+	if hooks.Count != 1 {
+		t.Errorf("user 2 sees %d webhooks, want 1", hooks.Count)
+	}
+}
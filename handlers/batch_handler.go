@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/storage"
+	"habit-tracker-api/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+const (
+	BatchStatusCreated    = "created"
+	BatchStatusUpdated    = "updated"
+	BatchStatusDeleted    = "deleted"
+	BatchStatusFailed     = "failed"
+	BatchStatusRolledBack = "rolled_back"
+	BatchStatusSkipped    = "skipped"
+)
+
+type BatchHandler struct {
+	storage *storage.JSONStorage
+}
+
+func NewBatchHandler(storage *storage.JSONStorage) *BatchHandler {
+	return &BatchHandler{storage: storage}
+}
+
+// BatchOperation — одна операция пакета. Для update поле data — JSON Merge Patch.
+type BatchOperation struct {
+	Op      string          `json:"op" validate:"required,oneof=create update delete"`
+	Entity  string          `json:"entity" validate:"required,oneof=habit goal track"`
+	ID      int             `json:"id" validate:"required_unless=Op create,gte=0"`
+	Version int             `json:"version" validate:"gte=0"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations" validate:"required,min=1,max=1000,dive"`
+}
+
+type BatchResult struct {
+	Index  int             `json:"index"`
+	Op     string          `json:"op"`
+	Entity string          `json:"entity"`
+	ID     int             `json:"id,omitempty"`
+	Status string          `json:"status"`
+	Data   interface{}     `json:"data,omitempty"`
+	Error  *apperror.Error `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Committed bool          `json:"committed"`
+	Results   []BatchResult `json:"results"`
+}
+
+var batchEntityTitles = map[string]string{
+	"habit": "Habit",
+	"goal":  "Goal",
+	"track": "Track",
+}
+
+func (h *BatchHandler) ExecuteBatch(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req BatchRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	results := make([]BatchResult, len(req.Operations))
+	for i, op := range req.Operations {
+		results[i] = BatchResult{
+			Index:  i,
+			Op:     op.Op,
+			Entity: op.Entity,
+			ID:     op.ID,
+			Status: BatchStatusSkipped,
+		}
+	}
+
+	failed := -1
+	err = h.storage.Batch(func(tx *storage.Tx) error {
+		for i, op := range req.Operations {
+			id, data, err := applyBatchOperation(tx, op, userID)
+			if err != nil {
+				failed = i
+				return err
+			}
+			results[i].ID = id
+			results[i].Status = batchSuccessStatus(op.Op)
+			if op.Op != BatchDelete {
+				results[i].Data = data
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		// Ошибка при сохранении файла, а не в конкретной операции
+		if failed < 0 {
+			return storageError(err, "Batch", "apply batch")
+		}
+
+		op := req.Operations[failed]
+		var problem *apperror.Error
+		if !errors.As(storageError(err, batchEntityTitles[op.Entity], op.Op+" "+op.Entity), &problem) {
+			return err
+		}
+
+		for i := 0; i < failed; i++ {
+			results[i].Status = BatchStatusRolledBack
+			results[i].ID = req.Operations[i].ID
+			results[i].Data = nil
+		}
+		results[failed].Status = BatchStatusFailed
+		results[failed].Error = problem
+
+		return c.Status(problem.Status).JSON(BatchResponse{
+			Committed: false,
+			Results:   results,
+		})
+	}
+
+	return c.JSON(BatchResponse{
+		Committed: true,
+		Results:   results,
+	})
+}
+
+func batchSuccessStatus(op string) string {
+	switch op {
+	case BatchCreate:
+		return BatchStatusCreated
+	case BatchUpdate:
+		return BatchStatusUpdated
+	default:
+		return BatchStatusDeleted
+	}
+}
+
+// applyBatchOperation выполняет операцию в транзакции и возвращает id и итоговую запись.
+// Создаваемые привычки и цели принадлежат пользователю userID. Менять и удалять он
+// может только свои и общие записи: чужие для него не существуют.
+func applyBatchOperation(tx *storage.Tx, op BatchOperation, userID int) (int, interface{}, error) {
+	if op.Op != BatchCreate && !visibleTo(syncOwner(tx, op.Entity, op.ID), userID) {
+		return 0, nil, apperror.NotFound(batchEntityTitles[op.Entity] + " not found")
+	}
+	if op.Op == BatchUpdate {
+		if err := checkMergePatch(op.Data); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	switch op.Entity {
+	case "habit":
+		switch op.Op {
+		case BatchCreate:
+			var req CreateHabitRequest
+			if err := decodeBatchData(op.Data, &req); err != nil {
+				return 0, nil, err
+			}
+			if err := validation.Struct(&req); err != nil {
+				return 0, nil, err
+			}
+			habit := req.habit()
+			habit.UserID = userID
+			if err := tx.CreateHabit(habit); err != nil {
+				return 0, nil, err
+			}
+			return habit.ID, habit, nil
+		case BatchUpdate:
+			habit, err := tx.UpdateHabit(op.ID, op.Version, patchHabit(op.Data))
+			if err != nil {
+				return 0, nil, err
+			}
+			return habit.ID, habit, nil
+		default:
+			habit, err := tx.DeleteHabit(op.ID, op.Version)
+			if err != nil {
+				return 0, nil, err
+			}
+			return habit.ID, habit, nil
+		}
+
+	case "goal":
+		switch op.Op {
+		case BatchCreate:
+			var req CreateGoalRequest
+			if err := decodeBatchData(op.Data, &req); err != nil {
+				return 0, nil, err
+			}
+			goal := req.goal()
+			goal.UserID = userID
+			if err := validation.Struct(&req, goal); err != nil {
+				return 0, nil, err
+			}
+			if err := tx.CreateGoal(goal); err != nil {
+				return 0, nil, err
+			}
+			return goal.ID, goal, nil
+		case BatchUpdate:
+			goal, err := tx.UpdateGoal(op.ID, op.Version, patchGoal(op.Data))
+			if err != nil {
+				return 0, nil, err
+			}
+			return goal.ID, goal, nil
+		default:
+			goal, err := tx.DeleteGoal(op.ID, op.Version)
+			if err != nil {
+				return 0, nil, err
+			}
+			return goal.ID, goal, nil
+		}
+
+	default:
+		switch op.Op {
+		case BatchCreate:
+			var req CreateTrackRequest
+			if err := decodeBatchData(op.Data, &req); err != nil {
+				return 0, nil, err
+			}
+			if err := validation.Struct(&req); err != nil {
+				return 0, nil, err
+			}
+			track := req.track()
+			if habit := tx.Habit(track.HabitID); habit != nil && !visibleTo(habit.UserID, userID) {
+				return 0, nil, apperror.NotFound("Habit not found")
+			}
+			if err := tx.CreateTrack(track); err != nil {
+				return 0, nil, err
+			}
+			return track.ID, track, nil
+		case BatchUpdate:
+			track, err := tx.UpdateTrack(op.ID, op.Version, patchTrack(op.Data))
+			if err != nil {
+				return 0, nil, err
+			}
+			// Отметку нельзя перенести на чужую привычку
+			if habit := tx.Habit(track.HabitID); habit != nil && !visibleTo(habit.UserID, userID) {
+				return 0, nil, apperror.NotFound("Habit not found")
+			}
+			return track.ID, track, nil
+		default:
+			track, err := tx.DeleteTrack(op.ID, op.Version)
+			if err != nil {
+				return 0, nil, err
+			}
+			return track.ID, track, nil
+		}
+	}
+}
+
+func decodeBatchData(data json.RawMessage, out interface{}) error {
+	if len(data) == 0 {
+		return apperror.BadRequest(apperror.CodeInvalidBody, "Operation data is required")
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid operation data").Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"habit-tracker-api/models"
+	"habit-tracker-api/storage"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// ownership — записи двух пользователей: Анны (1) и Бориса (2).
+type ownership struct {
+	store       *storage.JSONStorage
+	own, shared models.Habit
+	foreign     models.Habit
+	goal        models.Goal
+	track       models.HabitTrack
+}
+
+func newOwnership(t *testing.T) *ownership {
+	t.Helper()
+	f := &ownership{store: newTestStorage(t, "Анна", "Борис")}
+	f.own = models.Habit{UserID: 1, Name: "Бег", Category: "спорт", Frequency: "ежедневно", CreatedAt: time.Now()}
+	f.shared = models.Habit{Name: "Вода", Category: "здоровье", Frequency: "ежедневно", CreatedAt: time.Now()}
+	f.foreign = models.Habit{UserID: 2, Name: "Чтение", Category: "обучение", Frequency: "ежедневно", CreatedAt: time.Now()}
+	for _, habit := range []*models.Habit{&f.own, &f.shared, &f.foreign} {
+		if err := f.store.CreateHabit(habit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.goal = models.Goal{UserID: 2, Title: "Книги", TargetDate: time.Now().AddDate(0, 1, 0), CreatedAt: time.Now(), HabitIDs: []int{}}
+	if err := f.store.CreateGoal(&f.goal); err != nil {
+		t.Fatal(err)
+	}
+	f.track = models.HabitTrack{HabitID: f.foreign.ID, Date: time.Now(), Completed: true}
+	if err := f.store.CreateTrack(&f.track); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestBatchOwnership(t *testing.T) {
+	f := newOwnership(t)
+	app := newTestApp()
+	app.Post("/batch", NewBatchHandler(f.store).ExecuteBatch)
+
+	rename := map[string]string{"name": "Чужая"}
+	tests := []struct {
+		name   string
+		userID int
+		op     map[string]interface{}
+		status int
+	}{
+		{"update foreign habit", 1, map[string]interface{}{"op": "update", "entity": "habit", "id": f.foreign.ID, "data": rename}, http.StatusNotFound},
+		{"delete foreign habit", 1, map[string]interface{}{"op": "delete", "entity": "habit", "id": f.foreign.ID}, http.StatusNotFound},
+		{"update foreign goal", 1, map[string]interface{}{"op": "update", "entity": "goal", "id": f.goal.ID, "data": map[string]string{"title": "Чужая"}}, http.StatusNotFound},
+		{"delete foreign goal", 1, map[string]interface{}{"op": "delete", "entity": "goal", "id": f.goal.ID}, http.StatusNotFound},
+		{"update foreign track", 1, map[string]interface{}{"op": "update", "entity": "track", "id": f.track.ID, "data": map[string]string{"notes": "чужая"}}, http.StatusNotFound},
+		{"delete foreign track", 1, map[string]interface{}{"op": "delete", "entity": "track", "id": f.track.ID}, http.StatusNotFound},
+		{"track on foreign habit", 1, map[string]interface{}{"op": "create", "entity": "track", "data": map[string]interface{}{"habit_id": f.foreign.ID, "date": time.Now(), "completed": true}}, http.StatusNotFound},
+		{"move track to foreign habit", 2, map[string]interface{}{"op": "update", "entity": "track", "id": f.track.ID, "data": map[string]int{"habit_id": f.own.ID}}, http.StatusNotFound},
+		{"update own habit", 1, map[string]interface{}{"op": "update", "entity": "habit", "id": f.own.ID, "data": rename}, http.StatusOK},
+		{"update shared habit", 1, map[string]interface{}{"op": "update", "entity": "habit", "id": f.shared.ID, "data": rename}, http.StatusOK},
+		{"track on shared habit", 2, map[string]interface{}{"op": "create", "entity": "track", "data": map[string]interface{}{"habit_id": f.shared.ID, "date": time.Now(), "completed": true}}, http.StatusOK},
+		{"without user", 0, map[string]interface{}{"op": "update", "entity": "goal", "id": f.goal.ID, "data": map[string]string{"title": "Общая"}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := call(t, app, http.MethodPost, "/batch", tt.userID, map[string]interface{}{
+				"operations": []interface{}{tt.op},
+			})
+			if resp.status != tt.status {
+				t.Errorf("status = %d, want %d: %s", resp.status, tt.status, resp.body)
+			}
+		})
+	}
+
+	habit, err := f.store.GetHabitByID(f.foreign.ID)
+	if err != nil {
+		t.Fatalf("foreign habit: %v", err)
+	}
+	if habit.Name != f.foreign.Name {
+		t.Errorf("foreign habit renamed to %q", habit.Name)
+	}
+	track, err := f.store.GetTrackByID(f.track.ID)
+	if err != nil {
+		t.Fatalf("foreign track: %v", err)
+	}
+	if track.HabitID != f.foreign.ID || track.Notes != "" {
+		t.Errorf("foreign track changed: %+v", track)
+	}
+}
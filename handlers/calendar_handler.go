@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/calendar"
+	"habit-tracker-api/storage"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CalendarHandler struct {
+	storage *storage.JSONStorage
+}
+
+func NewCalendarHandler(storage *storage.JSONStorage) *CalendarHandler {
+	return &CalendarHandler{storage: storage}
+}
+
+// Feed отдаёт ленту iCalendar. Календарные приложения не умеют передавать заголовки,
+// поэтому токен принимается в параметре token или в заголовке Authorization: Bearer.
+func (h *CalendarHandler) Feed(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		token = strings.TrimSpace(strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "))
+	}
+
+	user, err := h.storage.GetUserByFeedToken(token)
+	if errors.Is(err, storage.ErrNotFound) {
+		return apperror.New(fiber.StatusUnauthorized, apperror.CodeUnauthorized, "Invalid or missing feed token").Wrap(err)
+	}
+	if err != nil {
+		return storageError(err, "User", "get calendar feed")
+	}
+
+	habits, err := h.storage.GetAllHabits()
+	if err != nil {
+		return storageError(err, "Habit", "get habits")
+	}
+	goals, err := h.storage.GetAllGoals()
+	if err != nil {
+		return storageError(err, "Goal", "get goals")
+	}
+
+	feed := calendar.Feed{
+		Name: "Habit Tracker — " + user.Name,
+		Now:  time.Now(),
+	}
+	for _, habit := range habits {
+		if ownedBy(habit.UserID, user.ID) {
+			feed.Habits = append(feed.Habits, habit)
+		}
+	}
+	for _, goal := range goals {
+		if ownedBy(goal.UserID, user.ID) {
+			feed.Goals = append(feed.Goals, goal)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := calendar.Write(&buf, feed); err != nil {
+		return apperror.Internal(err, "Failed to render calendar feed")
+	}
+
+	c.Set(fiber.HeaderContentType, calendar.ContentType)
+	c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+	return c.Send(buf.Bytes())
+}
+
+// ownedBy — запись принадлежит пользователю. Записи без владельца видны всем.
+func ownedBy(ownerID, userID int) bool {
+	return ownerID == 0 || ownerID == userID
+}
+
+// visibleTo — запись доступна запросу от userID. Без X-User-ID (userID 0) доступны все записи.
+func visibleTo(ownerID, userID int) bool {
+	return userID == 0 || ownedBy(ownerID, userID)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"errors"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/storage"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// storageError переводит ошибки хранилища в ошибки API.
+func storageError(err error, entity, action string) error {
+	var apiErr *apperror.Error
+	switch {
+	case errors.As(err, &apiErr):
+		return err
+	case errors.Is(err, storage.ErrNotFound):
+		return apperror.NotFound(entity + " not found").Wrap(err)
+	case errors.Is(err, storage.ErrAlreadyCompleted):
+		return apperror.Conflict(apperror.CodeAlreadyCompleted, entity+" is already completed").Wrap(err)
+	case errors.Is(err, storage.ErrNotCompleted):
+		return apperror.Conflict(apperror.CodeNotCompleted, entity+" is not completed").Wrap(err)
+	case errors.Is(err, storage.ErrVersionMismatch):
+		return apperror.New(fiber.StatusPreconditionFailed, apperror.CodePreconditionFailed,
+			entity+" has been modified, If-Match does not match the current version").Wrap(err)
+	case errors.Is(err, storage.ErrConflict):
+		return apperror.Conflict(apperror.CodeConflict, err.Error()).Wrap(err)
+	default:
+		return apperror.Internal(err, "Failed to "+action)
+	}
+}
+
+func parseID(c *fiber.Ctx, entity string) (int, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return 0, apperror.BadRequest(apperror.CodeInvalidID, "Invalid "+entity+" ID")
+	}
+	return id, nil
+}
+
+func parseBody(c *fiber.Ctx, out interface{}) error {
+	if err := c.BodyParser(out); err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body").Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/storage"
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// computedETag — ETag ответа с полями, которые вычисляются при запросе (прогноз цели):
+// к версии добавляется хеш этих полей, чтобы 304 не отдавал устаревший расчёт.
+// If-Match по такому тегу сверяет только версию.
+func computedETag(version int, computed interface{}) string {
+	data, err := json.Marshal(computed)
+	if err != nil || string(data) == "null" {
+		return versionETag(version)
+	}
+	hash := fnv.New32a()
+	hash.Write(data)
+	return fmt.Sprintf(`"%d-%x"`, version, hash.Sum32())
+}
+
+// collectionETag строит слабый ETag списка по парам (id, version), не зависящий от порядка.
+func collectionETag(versions map[int]int) string {
+	ids := make([]int, 0, len(versions))
+	for id := range versions {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	hash := fnv.New64a()
+	for _, id := range ids {
+		fmt.Fprintf(hash, "%d:%d;", id, versions[id])
+	}
+	return fmt.Sprintf(`W/"%x"`, hash.Sum64())
+}
+
+// ifMatchVersion разбирает If-Match (RFC 9110, раздел 13.1.1). Без заголовка или со "*"
+// версия не проверяется. Сравнение строгое: слабые теги (W/"3") не совпадают ни с чем.
+// Если в списке несколько тегов, выбирается тот, что совпадает с текущей версией записи
+// entity/id; хранилище всё равно сверяет её ещё раз при изменении.
+func ifMatchVersion(c *fiber.Ctx, store *storage.JSONStorage, entity string, id int) (int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" {
+		return storage.AnyVersion, nil
+	}
+
+	var versions []int
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return storage.AnyVersion, nil
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		value, _, _ := strings.Cut(tag[1:len(tag)-1], "-")
+		if version, err := strconv.Atoi(value); err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+
+	switch {
+	case len(versions) == 1:
+		return versions[0], nil
+	case len(versions) > 1:
+		current, exists := store.Version(entity, id)
+		if !exists {
+			// Запись не найдена: ответ 404 вернёт само изменение
+			return versions[0], nil
+		}
+		if slices.Contains(versions, current) {
+			return current, nil
+		}
+	}
+	return 0, apperror.New(fiber.StatusPreconditionFailed, apperror.CodePreconditionFailed,
+		"If-Match does not match the current version")
+}
+
+// notModified проверяет If-None-Match и выставляет ETag ответа.
+func notModified(c *fiber.Ctx, etag string) bool {
+	c.Set(fiber.HeaderETag, etag)
+
+	header := c.Get(fiber.HeaderIfNoneMatch)
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bufio"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/sse"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sseHeartbeat — интервал комментариев-пингов, по которым прокси не закрывают соединение,
+// а сервер замечает отключившихся клиентов.
+const sseHeartbeat = 15 * time.Second
+
+type EventsHandler struct {
+	hub *sse.Hub
+}
+
+func NewEventsHandler(hub *sse.Hub) *EventsHandler {
+	return &EventsHandler{hub: hub}
+}
+
+// Stream отдаёт события в формате Server-Sent Events. EventSource в браузере не умеет
+// передавать заголовки, поэтому пользователь принимается и параметром user_id.
+func (h *EventsHandler) Stream(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	if userID == 0 && c.Query("user_id") != "" {
+		userID, err = strconv.Atoi(c.Query("user_id"))
+		if err != nil || userID <= 0 {
+			return apperror.BadRequest(apperror.CodeInvalidUser, "Invalid user_id")
+		}
+	}
+
+	lastEventID := c.Get("Last-Event-ID", c.Query("last_event_id"))
+	var lastID int64
+	if lastEventID != "" {
+		lastID, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || lastID < 0 {
+			return apperror.BadRequest(apperror.CodeBadRequest, "Invalid Last-Event-ID")
+		}
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// Отключает буферизацию ответа в nginx
+	c.Set("X-Accel-Buffering", "no")
+
+	client, replay := h.hub.Connect(userID, lastID)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer h.hub.Disconnect(client)
+
+		// Браузер переподключится через 3 секунды и сам передаст Last-Event-ID
+		w.WriteString("retry: 3000\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for _, msg := range replay {
+			if err := msg.WriteTo(w); err != nil {
+				return
+			}
+		}
+
+		heartbeat := time.NewTicker(sseHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case msg, ok := <-client.Messages():
+				if !ok {
+					return
+				}
+				if err := msg.WriteTo(w); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				w.WriteString(": ping\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
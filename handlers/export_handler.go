@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"bufio"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/export"
+	"habit-tracker-api/storage"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ExportHandler struct {
+	storage *storage.JSONStorage
+}
+
+func NewExportHandler(storage *storage.JSONStorage) *ExportHandler {
+	return &ExportHandler{storage: storage}
+}
+
+func (h *ExportHandler) Export(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	format := c.Query("format", export.FormatJSON)
+	switch format {
+	case export.FormatCSV, export.FormatJSON, export.FormatNDJSON:
+	default:
+		return apperror.Validation(apperror.FieldError{
+			Field:   "format",
+			Code:    "oneof",
+			Message: "format must be one of: csv, json, ndjson",
+		})
+	}
+
+	src := export.ForUser(h.storage, userID)
+	now := time.Now()
+	c.Set(fiber.HeaderContentType, export.ContentType(format))
+	c.Attachment(export.FileName(format, now))
+
+	// Ответ пишется потоком: после начала передачи статус уже не изменить, ошибки только логируем
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := export.Write(w, format, src, now); err != nil {
+			log.Printf("export %s: %v", format, err)
+		}
+	})
+
+	return nil
+}
@@ -1,9 +1,10 @@
 package handlers
 
 import (
+	"habit-tracker-api/forecast"
 	"habit-tracker-api/models"
 	"habit-tracker-api/storage"
-	"strconv"
+	"habit-tracker-api/validation"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -18,23 +19,121 @@ func NewGoalHandler(storage *storage.JSONStorage) *GoalHandler {
 }
 
 type CreateGoalRequest struct {
-	Title       string    `json:"title" validate:"required,min=1"`
-	Description string    `json:"description"`
-	TargetDate  time.Time `json:"target_date" validate:"required"`
+	Title        string    `json:"title" validate:"required,min=1,max=200"`
+	Description  string    `json:"description" validate:"max=1000"`
+	TargetDate   time.Time `json:"target_date" validate:"required"`
+	AutoComplete bool      `json:"auto_complete"`
+	TargetValue  *float64  `json:"target_value" validate:"omitempty,nefield=StartValue"`
+	StartValue   float64   `json:"start_value"`
+	Unit         string    `json:"unit" validate:"max=20"`
+	HabitIDs     []int     `json:"habit_ids" validate:"max=50,unique,dive,gt=0"`
+	Recurrence   string    `json:"recurrence" validate:"omitempty,oneof=weekly monthly quarterly yearly"`
 }
 
 type UpdateGoalRequest struct {
-	Title       string    `json:"title" validate:"required,min=1"`
-	Description string    `json:"description"`
-	TargetDate  time.Time `json:"target_date" validate:"required"`
+	Title        string    `json:"title" validate:"required,min=1,max=200"`
+	Description  string    `json:"description" validate:"max=1000"`
+	TargetDate   time.Time `json:"target_date" validate:"required"`
+	AutoComplete bool      `json:"auto_complete"`
+	TargetValue  *float64  `json:"target_value" validate:"omitempty,nefield=StartValue"`
+	StartValue   float64   `json:"start_value"`
+	Unit         string    `json:"unit" validate:"max=20"`
+	HabitIDs     []int     `json:"habit_ids" validate:"max=50,unique,dive,gt=0"`
+	Recurrence   string    `json:"recurrence" validate:"omitempty,oneof=weekly monthly quarterly yearly"`
+}
+
+func (r CreateGoalRequest) goal() *models.Goal {
+	return &models.Goal{
+		Title:        r.Title,
+		Description:  r.Description,
+		TargetDate:   r.TargetDate,
+		CreatedAt:    time.Now(),
+		Completed:    false,
+		CompletedAt:  time.Time{},
+		HabitIDs:     habitIDs(r.HabitIDs),
+		AutoComplete: r.AutoComplete,
+		TargetValue:  r.TargetValue,
+		StartValue:   r.StartValue,
+		Unit:         r.Unit,
+		Recurrence:   r.Recurrence,
+	}
+}
+
+// GoalResponse — цель с оценкой: успевает ли она к сроку.
+type GoalResponse struct {
+	models.Goal
+	Forecast *forecast.Forecast `json:"forecast,omitempty"`
+}
+
+func updateGoalRequestFrom(goal models.Goal) UpdateGoalRequest {
+	return UpdateGoalRequest{
+		Title:        goal.Title,
+		Description:  goal.Description,
+		TargetDate:   goal.TargetDate,
+		AutoComplete: goal.AutoComplete,
+		TargetValue:  goal.TargetValue,
+		StartValue:   goal.StartValue,
+		Unit:         goal.Unit,
+		HabitIDs:     goal.HabitIDs,
+		Recurrence:   goal.Recurrence,
+	}
+}
+
+func (r UpdateGoalRequest) apply(goal *models.Goal) {
+	goal.Title = r.Title
+	goal.Description = r.Description
+	goal.TargetDate = r.TargetDate
+	goal.AutoComplete = r.AutoComplete
+	goal.TargetValue = r.TargetValue
+	goal.StartValue = r.StartValue
+	goal.Unit = r.Unit
+	goal.HabitIDs = habitIDs(r.HabitIDs)
+	goal.Recurrence = r.Recurrence
+}
+
+// habitIDs — связанные привычки цели; в JSON — пустой массив, а не null.
+func habitIDs(ids []int) []int {
+	if ids == nil {
+		return []int{}
+	}
+	return ids
+}
+
+// patchGoal применяет merge patch к цели с той же валидацией, что и PUT.
+func patchGoal(patch []byte) func(goal *models.Goal) error {
+	return func(goal *models.Goal) error {
+		req, err := applyMergePatch(updateGoalRequestFrom(*goal), patch)
+		if err != nil {
+			return err
+		}
+		req.apply(goal)
+		return validation.Struct(&req, goal)
+	}
 }
 
 func (h *GoalHandler) GetAllGoals(c *fiber.Ctx) error {
-	goals, err := h.storage.GetAllGoals()
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	all, err := h.storage.GetAllGoals()
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get goals",
-		})
+		return storageError(err, "Goal", "get goals")
+	}
+	goals := make([]models.Goal, 0, len(all))
+	for _, goal := range all {
+		if visibleTo(goal.UserID, userID) {
+			goals = append(goals, goal)
+		}
+	}
+
+	versions := make(map[int]int, len(goals))
+	for _, goal := range goals {
+		versions[goal.ID] = goal.Version
+	}
+	if notModified(c, collectionETag(versions)) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	return c.JSON(fiber.Map{
@@ -44,160 +143,137 @@ func (h *GoalHandler) GetAllGoals(c *fiber.Ctx) error {
 }
 
 func (h *GoalHandler) GetGoalByID(c *fiber.Ctx) error {
-	id, err := strconv.Atoi(c.Params("id"))
+	id, err := parseID(c, "goal")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid goal ID",
-		})
+		return err
 	}
 
 	goal, err := h.storage.GetGoalByID(id)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get goal",
-		})
+		return storageError(err, "Goal", "get goal")
 	}
 
-	if goal == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Goal not found",
-		})
+	response := GoalResponse{
+		Goal:     *goal,
+		Forecast: h.storage.GoalForecast(*goal, time.Now()),
+	}
+	if notModified(c, computedETag(goal.Version, response.Forecast)) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
-	return c.JSON(goal)
+	return c.JSON(response)
 }
 
 func (h *GoalHandler) CreateGoal(c *fiber.Ctx) error {
-	var req CreateGoalRequest
-
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
 	}
 
-	if req.Title == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Goal title is required",
-		})
+	var req CreateGoalRequest
+
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	now := time.Now()
-	goal := &models.Goal{
-		Title:       req.Title,
-		Description: req.Description,
-		TargetDate:  req.TargetDate,
-		CreatedAt:   now,
-		Completed:   false,
-		CompletedAt: time.Time{},
-		HabitIDs:    []int{},
+	goal := req.goal()
+	goal.UserID = userID
+	if err := validation.Struct(&req, goal); err != nil {
+		return err
 	}
 
 	if err := h.storage.CreateGoal(goal); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create goal",
-		})
+		return storageError(err, "Goal", "create goal")
 	}
 
+	c.Set(fiber.HeaderETag, versionETag(goal.Version))
 	return c.Status(fiber.StatusCreated).JSON(goal)
 }
 
 func (h *GoalHandler) UpdateGoal(c *fiber.Ctx) error {
-	id, err := strconv.Atoi(c.Params("id"))
+	id, err := parseID(c, "goal")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid goal ID",
-		})
+		return err
 	}
 
 	var req UpdateGoalRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	if req.Title == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Goal title is required",
-		})
+	version, err := ifMatchVersion(c, h.storage, "goal", id)
+	if err != nil {
+		return err
 	}
 
-	existingGoal, err := h.storage.GetGoalByID(id)
+	updatedGoal, err := h.storage.UpdateGoal(id, version, func(goal *models.Goal) error {
+		req.apply(goal)
+		return validation.Struct(&req, goal)
+	})
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get goal",
-		})
+		return storageError(err, "Goal", "update goal")
 	}
 
-	if existingGoal == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Goal not found",
-		})
+	c.Set(fiber.HeaderETag, versionETag(updatedGoal.Version))
+	return c.JSON(updatedGoal)
+}
+
+func (h *GoalHandler) PatchGoal(c *fiber.Ctx) error {
+	id, err := parseID(c, "goal")
+	if err != nil {
+		return err
 	}
 
-	updatedGoal := &models.Goal{
-		ID:          id,
-		Title:       req.Title,
-		Description: req.Description,
-		TargetDate:  req.TargetDate,
-		CreatedAt:   existingGoal.CreatedAt,
-		Completed:   existingGoal.Completed,
-		CompletedAt: existingGoal.CompletedAt,
-		HabitIDs:    existingGoal.HabitIDs,
+	patch, err := mergePatchBody(c)
+	if err != nil {
+		return err
 	}
 
-	if err := h.storage.UpdateGoal(id, updatedGoal); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update goal",
-		})
+	version, err := ifMatchVersion(c, h.storage, "goal", id)
+	if err != nil {
+		return err
 	}
 
+	updatedGoal, err := h.storage.UpdateGoal(id, version, patchGoal(patch))
+	if err != nil {
+		return storageError(err, "Goal", "update goal")
+	}
+
+	c.Set(fiber.HeaderETag, versionETag(updatedGoal.Version))
 	return c.JSON(updatedGoal)
 }
 
 func (h *GoalHandler) DeleteGoal(c *fiber.Ctx) error {
-	id, err := strconv.Atoi(c.Params("id"))
+	id, err := parseID(c, "goal")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid goal ID",
-		})
+		return err
 	}
 
-	goal, err := h.storage.GetGoalByID(id)
+	version, err := ifMatchVersion(c, h.storage, "goal", id)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get goal",
-		})
+		return err
 	}
 
-	if goal == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Goal not found",
-		})
-	}
-
-	if err := h.storage.DeleteGoal(id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to delete goal",
-		})
+	if _, err := h.storage.DeleteGoal(id, version); err != nil {
+		return storageError(err, "Goal", "delete goal")
 	}
 
 	return c.Status(fiber.StatusNoContent).Send(nil)
 }
 
 func (h *GoalHandler) CompleteGoal(c *fiber.Ctx) error {
-	id, err := strconv.Atoi(c.Params("id"))
+	id, err := parseID(c, "goal")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid goal ID",
-		})
+		return err
 	}
 
-	if err := h.storage.CompleteGoal(id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to complete goal",
-		})
+	version, err := ifMatchVersion(c, h.storage, "goal", id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.storage.CompleteGoal(id, version); err != nil {
+		return storageError(err, "Goal", "complete goal")
 	}
 
 	return c.JSON(fiber.Map{
@@ -205,3 +281,24 @@ func (h *GoalHandler) CompleteGoal(c *fiber.Ctx) error {
 		"id":      id,
 	})
 }
+
+func (h *GoalHandler) ReopenGoal(c *fiber.Ctx) error {
+	id, err := parseID(c, "goal")
+	if err != nil {
+		return err
+	}
+
+	version, err := ifMatchVersion(c, h.storage, "goal", id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.storage.ReopenGoal(id, version); err != nil {
+		return storageError(err, "Goal", "reopen goal")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Goal reopened",
+		"id":      id,
+	})
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"habit-tracker-api/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoalETagFollowsForecast(t *testing.T) {
+	store := newTestStorage(t)
+	created := time.Now().AddDate(0, 0, -10)
+	habit := models.Habit{Name: "Бег", Category: "спорт", Frequency: "ежедневно", CreatedAt: created}
+	if err := store.CreateHabit(&habit); err != nil {
+		t.Fatal(err)
+	}
+	goal := models.Goal{Title: "Марафон", TargetDate: time.Now().AddDate(0, 0, 20), CreatedAt: created, HabitIDs: []int{habit.ID}}
+	if err := store.CreateGoal(&goal); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewGoalHandler(store)
+	app := newTestApp()
+	app.Get("/goals/:id", h.GetGoalByID)
+	app.Patch("/goals/:id", h.PatchGoal)
+	path := "/goals/" + strconv.Itoa(goal.ID)
+
+	first := call(t, app, http.MethodGet, path, 0, nil)
+	if first.status != http.StatusOK || !strings.Contains(string(first.body), `"forecast"`) {
+		t.Fatalf("get goal: %d %s", first.status, first.body)
+	}
+	if !strings.HasPrefix(first.etag, `"1-`) {
+		t.Errorf("ETag = %s, want version with forecast hash", first.etag)
+	}
+	if resp := call(t, app, http.MethodGet, path, 0, nil, "If-None-Match", first.etag); resp.status != http.StatusNotModified {
+		t.Errorf("unchanged goal: %d, want 304", resp.status)
+	}
+
+	// Отметка связанной привычки меняет прогноз, но не версию цели
+	track := models.HabitTrack{HabitID: habit.ID, Date: time.Now().AddDate(0, 0, -1), Completed: true}
+	if err := store.CreateTrack(&track); err != nil {
+		t.Fatal(err)
+	}
+	second := call(t, app, http.MethodGet, path, 0, nil, "If-None-Match", first.etag)
+	if second.status != http.StatusOK {
+		t.Fatalf("goal with a new track: %d, want the new forecast", second.status)
+	}
+	if second.etag == first.etag {
+		t.Errorf("ETag %s did not change with the forecast", second.etag)
+	}
+
+	// If-Match по такому тегу сверяет версию цели
+	resp := call(t, app, http.MethodPatch, path, 0, map[string]string{"title": "Полумарафон"},
+		"Content-Type", "application/merge-patch+json", "If-Match", second.etag)
+	if resp.status != http.StatusOK {
+		t.Fatalf("patch with If-Match %s: %d %s", second.etag, resp.status, resp.body)
+	}
+	resp = call(t, app, http.MethodPatch, path, 0, map[string]string{"title": "Марафон"},
+		"Content-Type", "application/merge-patch+json", "If-Match", second.etag)
+	if resp.status != http.StatusPreconditionFailed {
+		t.Errorf("patch with stale If-Match: %d, want 412", resp.status)
+	}
+}
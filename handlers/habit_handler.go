@@ -3,7 +3,7 @@ package handlers
 import (
 	"habit-tracker-api/models"
 	"habit-tracker-api/storage"
-	"strconv"
+	"habit-tracker-api/validation"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -18,25 +18,136 @@ func NewHabitHandler(storage *storage.JSONStorage) *HabitHandler {
 }
 
 type CreateHabitRequest struct {
-	Name        string `json:"name" validate:"required,min=1"`
-	Description string `json:"description"`
-	Category    string `json:"category" validate:"required"`
-	Frequency   string `json:"frequency" validate:"required"`
+	Name        string           `json:"name" validate:"required,min=1,max=100"`
+	Description string           `json:"description" validate:"max=1000"`
+	Category    string           `json:"category" validate:"required,category"`
+	Frequency   string           `json:"frequency" validate:"required,max=50"`
+	Target      *float64         `json:"target" validate:"omitempty,gt=0"`
+	Unit        string           `json:"unit" validate:"max=20"`
+	Reminder    *ReminderRequest `json:"reminder"`
 }
 
 type UpdateHabitRequest struct {
-	Name        string `json:"name" validate:"required,min=1"`
-	Description string `json:"description"`
-	Category    string `json:"category" validate:"required"`
-	Frequency   string `json:"frequency" validate:"required"`
+	Name        string           `json:"name" validate:"required,min=1,max=100"`
+	Description string           `json:"description" validate:"max=1000"`
+	Category    string           `json:"category" validate:"required,category"`
+	Frequency   string           `json:"frequency" validate:"required,max=50"`
+	Target      *float64         `json:"target" validate:"omitempty,gt=0"`
+	Unit        string           `json:"unit" validate:"max=20"`
+	Reminder    *ReminderRequest `json:"reminder"`
+}
+
+type ReminderRequest struct {
+	Enabled    bool     `json:"enabled"`
+	Times      []string `json:"times" validate:"required_if=Enabled true,max=24,unique,dive,clock"`
+	Weekdays   []int    `json:"weekdays" validate:"max=7,unique,dive,min=1,max=7"`
+	QuietStart string   `json:"quiet_start" validate:"required_with=QuietEnd,omitempty,clock"`
+	QuietEnd   string   `json:"quiet_end" validate:"required_with=QuietStart,omitempty,clock"`
+	Timezone   string   `json:"timezone" validate:"omitempty,timezone"`
+}
+
+func (r *ReminderRequest) reminder() *models.Reminder {
+	if r == nil {
+		return nil
+	}
+	return &models.Reminder{
+		Enabled:    r.Enabled,
+		Times:      r.Times,
+		Weekdays:   r.Weekdays,
+		QuietStart: r.QuietStart,
+		QuietEnd:   r.QuietEnd,
+		Timezone:   r.Timezone,
+	}
+}
+
+func reminderRequestFrom(reminder *models.Reminder) *ReminderRequest {
+	if reminder == nil {
+		return nil
+	}
+	return &ReminderRequest{
+		Enabled:    reminder.Enabled,
+		Times:      reminder.Times,
+		Weekdays:   reminder.Weekdays,
+		QuietStart: reminder.QuietStart,
+		QuietEnd:   reminder.QuietEnd,
+		Timezone:   reminder.Timezone,
+	}
+}
+
+func (r CreateHabitRequest) habit() *models.Habit {
+	return &models.Habit{
+		Name:        r.Name,
+		Description: r.Description,
+		Category:    r.Category,
+		Frequency:   r.Frequency,
+		Target:      r.Target,
+		Unit:        r.Unit,
+		Reminder:    r.Reminder.reminder(),
+		CreatedAt:   time.Now(),
+		Completed:   false,
+	}
+}
+
+func updateHabitRequestFrom(habit models.Habit) UpdateHabitRequest {
+	return UpdateHabitRequest{
+		Name:        habit.Name,
+		Description: habit.Description,
+		Category:    habit.Category,
+		Frequency:   habit.Frequency,
+		Target:      habit.Target,
+		Unit:        habit.Unit,
+		Reminder:    reminderRequestFrom(habit.Reminder),
+	}
+}
+
+func (r UpdateHabitRequest) apply(habit *models.Habit) {
+	habit.Name = r.Name
+	habit.Description = r.Description
+	habit.Category = r.Category
+	habit.Frequency = r.Frequency
+	habit.Target = r.Target
+	habit.Unit = r.Unit
+	habit.Reminder = r.Reminder.reminder()
+}
+
+// patchHabit применяет merge patch к привычке с той же валидацией, что и PUT.
+func patchHabit(patch []byte) func(habit *models.Habit) error {
+	return func(habit *models.Habit) error {
+		req, err := applyMergePatch(updateHabitRequestFrom(*habit), patch)
+		if err != nil {
+			return err
+		}
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+		req.apply(habit)
+		return nil
+	}
 }
 
 func (h *HabitHandler) GetAllHabits(c *fiber.Ctx) error {
-	habits, err := h.storage.GetAllHabits()
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	all, err := h.storage.GetAllHabits()
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get habits",
-		})
+		return storageError(err, "Habit", "get habits")
+	}
+	habits := make([]models.Habit, 0, len(all))
+	for _, habit := range all {
+		if visibleTo(habit.UserID, userID) {
+			habits = append(habits, habit)
+		}
+	}
+
+	versions := make(map[int]int, len(habits))
+	for _, habit := range habits {
+		versions[habit.ID] = habit.Version
+	}
+	if notModified(c, collectionETag(versions)) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	return c.JSON(fiber.Map{
@@ -46,182 +157,137 @@ func (h *HabitHandler) GetAllHabits(c *fiber.Ctx) error {
 }
 
 func (h *HabitHandler) GetHabitByID(c *fiber.Ctx) error {
-	id, err := strconv.Atoi(c.Params("id"))
+	id, err := parseID(c, "habit")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid habit ID",
-		})
+		return err
 	}
 
 	habit, err := h.storage.GetHabitByID(id)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get habit",
-		})
+		return storageError(err, "Habit", "get habit")
 	}
 
-	if habit == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Habit not found",
-		})
+	if notModified(c, versionETag(habit.Version)) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	return c.JSON(habit)
 }
 
 func (h *HabitHandler) CreateHabit(c *fiber.Ctx) error {
-	var req CreateHabitRequest
-
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-
-	if req.Name == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Habit name is required",
-		})
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
 	}
 
-	if req.Category == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Category is required",
-		})
-	}
+	var req CreateHabitRequest
 
-	if req.Frequency == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Frequency is required",
-		})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	now := time.Now()
-	habit := &models.Habit{
-		Name:        req.Name,
-		Description: req.Description,
-		Category:    req.Category,
-		Frequency:   req.Frequency,
-		CreatedAt:   now,
-		Completed:   false,
+	if err := validation.Struct(&req); err != nil {
+		return err
 	}
 
+	habit := req.habit()
+	habit.UserID = userID
 	if err := h.storage.CreateHabit(habit); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create habit",
-		})
+		return storageError(err, "Habit", "create habit")
 	}
 
+	c.Set(fiber.HeaderETag, versionETag(habit.Version))
 	return c.Status(fiber.StatusCreated).JSON(habit)
 }
 
 func (h *HabitHandler) UpdateHabit(c *fiber.Ctx) error {
-	id, err := strconv.Atoi(c.Params("id"))
+	id, err := parseID(c, "habit")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid habit ID",
-		})
+		return err
 	}
 
 	var req UpdateHabitRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	if req.Name == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Habit name is required",
-		})
+	if err := validation.Struct(&req); err != nil {
+		return err
 	}
 
-	if req.Category == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Category is required",
-		})
+	version, err := ifMatchVersion(c, h.storage, "habit", id)
+	if err != nil {
+		return err
 	}
 
-	if req.Frequency == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Frequency is required",
-		})
+	updatedHabit, err := h.storage.UpdateHabit(id, version, func(habit *models.Habit) error {
+		req.apply(habit)
+		return nil
+	})
+	if err != nil {
+		return storageError(err, "Habit", "update habit")
 	}
 
-	existingHabit, err := h.storage.GetHabitByID(id)
+	c.Set(fiber.HeaderETag, versionETag(updatedHabit.Version))
+	return c.JSON(updatedHabit)
+}
+
+func (h *HabitHandler) PatchHabit(c *fiber.Ctx) error {
+	id, err := parseID(c, "habit")
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get habit",
-		})
+		return err
 	}
 
-	if existingHabit == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Habit not found",
-		})
+	patch, err := mergePatchBody(c)
+	if err != nil {
+		return err
 	}
 
-	updatedHabit := &models.Habit{
-		ID:          id,
-		Name:        req.Name,
-		Description: req.Description,
-		Category:    req.Category,
-		Frequency:   req.Frequency,
-		CreatedAt:   existingHabit.CreatedAt,
-		Completed:   existingHabit.Completed,
+	version, err := ifMatchVersion(c, h.storage, "habit", id)
+	if err != nil {
+		return err
 	}
 
-	if err := h.storage.UpdateHabit(id, updatedHabit); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update habit",
-		})
+	updatedHabit, err := h.storage.UpdateHabit(id, version, patchHabit(patch))
+	if err != nil {
+		return storageError(err, "Habit", "update habit")
 	}
 
+	c.Set(fiber.HeaderETag, versionETag(updatedHabit.Version))
 	return c.JSON(updatedHabit)
 }
 
 func (h *HabitHandler) DeleteHabit(c *fiber.Ctx) error {
-	id, err := strconv.Atoi(c.Params("id"))
+	id, err := parseID(c, "habit")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid habit ID",
-		})
+		return err
 	}
 
-	habit, err := h.storage.GetHabitByID(id)
+	version, err := ifMatchVersion(c, h.storage, "habit", id)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get habit",
-		})
-	}
-
-	if habit == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Habit not found",
-		})
+		return err
 	}
 
-	if err := h.storage.DeleteHabit(id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to delete habit",
-		})
+	if _, err := h.storage.DeleteHabit(id, version); err != nil {
+		return storageError(err, "Habit", "delete habit")
 	}
 
 	return c.Status(fiber.StatusNoContent).Send(nil)
 }
 
 func (h *HabitHandler) CompleteHabit(c *fiber.Ctx) error {
-	id, err := strconv.Atoi(c.Params("id"))
+	id, err := parseID(c, "habit")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid habit ID",
-		})
+		return err
 	}
 
-	if err := h.storage.CompleteHabit(id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to complete habit",
-		})
+	version, err := ifMatchVersion(c, h.storage, "habit", id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.storage.CompleteHabit(id, version); err != nil {
+		return storageError(err, "Habit", "complete habit")
 	}
 
 	return c.JSON(fiber.Map{
@@ -229,3 +295,24 @@ func (h *HabitHandler) CompleteHabit(c *fiber.Ctx) error {
 		"id":      id,
 	})
 }
+
+func (h *HabitHandler) ReopenHabit(c *fiber.Ctx) error {
+	id, err := parseID(c, "habit")
+	if err != nil {
+		return err
+	}
+
+	version, err := ifMatchVersion(c, h.storage, "habit", id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.storage.ReopenHabit(id, version); err != nil {
+		return storageError(err, "Habit", "reopen habit")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Habit reopened",
+		"id":      id,
+	})
+}
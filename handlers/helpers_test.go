@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/models"
+	"habit-tracker-api/storage"
+	"io"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newTestStorage создаёт хранилище во временном каталоге с пользователями names.
+func newTestStorage(t *testing.T, names ...string) *storage.JSONStorage {
+	t.Helper()
+	store, err := storage.NewJSONStorage(filepath.Join(t.TempDir(), "habits.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if err := store.CreateUser(&models.User{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func newTestApp() *fiber.App {
+	return fiber.New(fiber.Config{ErrorHandler: apperror.Handler})
+}
+
+// response — статус, заголовки и тело ответа тестового запроса.
+type response struct {
+	status int
+	etag   string
+	body   []byte
+}
+
+func (r response) decode(t *testing.T, value interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(r.body, value); err != nil {
+		t.Fatalf("decode %s: %v", r.body, err)
+	}
+}
+
+// call выполняет запрос от пользователя userID (0 — без X-User-ID). body
+// кодируется в JSON, если это не nil.
+func call(t *testing.T, app *fiber.App, method, path string, userID int, body interface{}, headers ...string) response {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	if userID != 0 {
+		req.Header.Set(HeaderUserID, strconv.Itoa(userID))
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response{status: resp.StatusCode, etag: resp.Header.Get(fiber.HeaderETag), body: data}
+}
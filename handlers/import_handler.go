@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/importer"
+	"habit-tracker-api/storage"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ImportHandler struct {
+	storage     *storage.JSONStorage
+	maxFileSize int64
+}
+
+// NewImportHandler создаёт обработчик импорта. maxFileSize ограничивает распакованный
+// размер файла из архива, 0 — importer.DefaultMaxFileSize.
+func NewImportHandler(storage *storage.JSONStorage, maxFileSize int64) *ImportHandler {
+	return &ImportHandler{storage: storage, maxFileSize: maxFileSize}
+}
+
+// Import принимает файл в поле "file" multipart-формы или телом запроса.
+func (h *ImportHandler) Import(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	format := c.Query("format")
+	parser, ok := importer.Lookup(format)
+	if !ok {
+		return apperror.Validation(apperror.FieldError{
+			Field:   "format",
+			Code:    "oneof",
+			Message: "format must be one of: " + strings.Join(importer.Formats(), ", "),
+		})
+	}
+
+	data, err := importData(c)
+	if err != nil {
+		return err
+	}
+
+	opts := importer.Options{
+		DefaultCategory: c.Query("default_category", importer.DefaultCategory),
+		DateLayout:      c.Query("date_layout"),
+		Columns: importer.ColumnMapping{
+			Habit:     c.Query("habit_column"),
+			Date:      c.Query("date_column"),
+			Completed: c.Query("completed_column", "completed"),
+			Notes:     c.Query("notes_column", "notes"),
+			Category:  c.Query("category_column", "category"),
+			Frequency: c.Query("frequency_column", "frequency"),
+		},
+		MaxFileSize: h.maxFileSize,
+	}
+
+	ds, err := parser.Parse(data, opts)
+	if errors.Is(err, importer.ErrFileTooLarge) {
+		return apperror.New(fiber.StatusRequestEntityTooLarge, apperror.CodePayloadTooLarge, "Import file is too large: "+err.Error())
+	}
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidBody, "Failed to parse "+format+" file: "+err.Error()).Wrap(err)
+	}
+
+	dryRun := c.QueryBool("dry_run")
+	var report *importer.Report
+	err = h.storage.Batch(func(tx *storage.Tx) error {
+		var err error
+		report, err = importer.Import(tx, ds, userID, dryRun)
+		return err
+	})
+	if err != nil {
+		return storageError(err, "Import", "import data")
+	}
+	report.Format = format
+
+	if dryRun {
+		return c.JSON(report)
+	}
+	return c.Status(fiber.StatusCreated).JSON(report)
+}
+
+func importData(c *fiber.Ctx) ([]byte, error) {
+	var data []byte
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, apperror.BadRequest(apperror.CodeInvalidBody, "Form field \"file\" is required").Wrap(err)
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, apperror.Internal(err, "Failed to read uploaded file")
+		}
+		defer file.Close()
+		if data, err = io.ReadAll(file); err != nil {
+			return nil, apperror.Internal(err, "Failed to read uploaded file")
+		}
+	} else {
+		data = c.Body()
+	}
+
+	if len(data) == 0 {
+		return nil, apperror.BadRequest(apperror.CodeInvalidBody, "Import file is empty")
+	}
+	return data, nil
+}
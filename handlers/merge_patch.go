@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"habit-tracker-api/apperror"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+// mergePatchBody проверяет тип содержимого и возвращает тело PATCH-запроса.
+func mergePatchBody(c *fiber.Ctx) ([]byte, error) {
+	contentType := strings.TrimSpace(strings.SplitN(c.Get(fiber.HeaderContentType), ";", 2)[0])
+	if !c.Is("json") && !strings.EqualFold(contentType, mergePatchContentType) {
+		return nil, apperror.New(fiber.StatusUnsupportedMediaType, apperror.CodeUnsupportedMedia,
+			"Content-Type must be "+mergePatchContentType)
+	}
+
+	if err := checkMergePatch(c.Body()); err != nil {
+		return nil, err
+	}
+
+	return c.Body(), nil
+}
+
+// checkMergePatch проверяет, что патч — JSON-объект: замена ресурса целиком не поддерживается.
+func checkMergePatch(patch []byte) error {
+	var object map[string]interface{}
+	if err := json.Unmarshal(patch, &object); err != nil || object == nil {
+		return apperror.BadRequest(apperror.CodeInvalidBody, "Merge patch must be a JSON object")
+	}
+	return nil
+}
+
+// applyMergePatch применяет RFC 7396 merge patch к копии current.
+func applyMergePatch[T any](current T, patch []byte) (T, error) {
+	var result T
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return result, err
+	}
+
+	var target, changes interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return result, apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body").Wrap(err)
+	}
+
+	merged, err := json.Marshal(mergePatch(target, changes))
+	if err != nil {
+		return result, err
+	}
+
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return result, apperror.BadRequest(apperror.CodeInvalidBody, "Invalid request body").Wrap(err)
+	}
+
+	return result, nil
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+
+	return targetObject
+}
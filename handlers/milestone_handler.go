@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"errors"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/models"
+	"habit-tracker-api/storage"
+	"habit-tracker-api/validation"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MilestoneHandler — вехи цели. Вехи хранятся внутри цели, поэтому ETag и If-Match —
+// версия цели, а каждое изменение вехи увеличивает её.
+type MilestoneHandler struct {
+	storage *storage.JSONStorage
+}
+
+func NewMilestoneHandler(storage *storage.JSONStorage) *MilestoneHandler {
+	return &MilestoneHandler{storage: storage}
+}
+
+type MilestoneRequest struct {
+	Title       string     `json:"title" validate:"required,min=1,max=200"`
+	DueDate     *time.Time `json:"due_date"`
+	TargetValue *float64   `json:"target_value" validate:"omitempty,gt=0"`
+	Value       float64    `json:"value" validate:"gte=0"`
+	Completed   bool       `json:"completed"`
+}
+
+func (r MilestoneRequest) milestone() *models.Milestone {
+	milestone := &models.Milestone{}
+	r.apply(milestone)
+	return milestone
+}
+
+func milestoneRequestFrom(milestone models.Milestone) MilestoneRequest {
+	return MilestoneRequest{
+		Title:       milestone.Title,
+		DueDate:     milestone.DueDate,
+		TargetValue: milestone.TargetValue,
+		Value:       milestone.Value,
+		Completed:   milestone.Completed,
+	}
+}
+
+func (r MilestoneRequest) apply(milestone *models.Milestone) {
+	milestone.Title = r.Title
+	milestone.DueDate = r.DueDate
+	milestone.TargetValue = r.TargetValue
+	milestone.Value = r.Value
+	milestone.Completed = r.Completed
+}
+
+func (h *MilestoneHandler) GetMilestones(c *fiber.Ctx) error {
+	goalID, err := parseID(c, "goal")
+	if err != nil {
+		return err
+	}
+
+	goal, err := h.storage.GetGoalByID(goalID)
+	if err != nil {
+		return storageError(err, "Goal", "get milestones")
+	}
+
+	if notModified(c, versionETag(goal.Version)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	milestones := goal.Milestones
+	if milestones == nil {
+		milestones = []models.Milestone{}
+	}
+	return c.JSON(fiber.Map{
+		"milestones": milestones,
+		"count":      len(milestones),
+		"progress":   goal.Progress,
+		"completed":  goal.Completed,
+	})
+}
+
+func (h *MilestoneHandler) GetMilestone(c *fiber.Ctx) error {
+	goalID, milestoneID, err := parseMilestoneIDs(c)
+	if err != nil {
+		return err
+	}
+
+	goal, err := h.storage.GetGoalByID(goalID)
+	if err != nil {
+		return storageError(err, "Goal", "get milestone")
+	}
+
+	if notModified(c, versionETag(goal.Version)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return milestoneResponse(c, goal, milestoneID, fiber.StatusOK)
+}
+
+func (h *MilestoneHandler) CreateMilestone(c *fiber.Ctx) error {
+	goalID, err := parseID(c, "goal")
+	if err != nil {
+		return err
+	}
+
+	var req MilestoneRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	version, err := ifMatchVersion(c, h.storage, "goal", goalID)
+	if err != nil {
+		return err
+	}
+
+	milestone := req.milestone()
+	goal, err := h.storage.CreateMilestone(goalID, version, milestone)
+	if err != nil {
+		return milestoneError(err, "create milestone")
+	}
+
+	return milestoneResponse(c, goal, milestone.ID, fiber.StatusCreated)
+}
+
+func (h *MilestoneHandler) UpdateMilestone(c *fiber.Ctx) error {
+	goalID, milestoneID, err := parseMilestoneIDs(c)
+	if err != nil {
+		return err
+	}
+
+	var req MilestoneRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	version, err := ifMatchVersion(c, h.storage, "goal", goalID)
+	if err != nil {
+		return err
+	}
+
+	goal, err := h.storage.UpdateMilestone(goalID, milestoneID, version, func(milestone *models.Milestone) error {
+		req.apply(milestone)
+		return validation.Struct(&req)
+	})
+	if err != nil {
+		return milestoneError(err, "update milestone")
+	}
+
+	return milestoneResponse(c, goal, milestoneID, fiber.StatusOK)
+}
+
+func (h *MilestoneHandler) PatchMilestone(c *fiber.Ctx) error {
+	goalID, milestoneID, err := parseMilestoneIDs(c)
+	if err != nil {
+		return err
+	}
+
+	patch, err := mergePatchBody(c)
+	if err != nil {
+		return err
+	}
+
+	version, err := ifMatchVersion(c, h.storage, "goal", goalID)
+	if err != nil {
+		return err
+	}
+
+	goal, err := h.storage.UpdateMilestone(goalID, milestoneID, version, func(milestone *models.Milestone) error {
+		req, err := applyMergePatch(milestoneRequestFrom(*milestone), patch)
+		if err != nil {
+			return err
+		}
+		req.apply(milestone)
+		return validation.Struct(&req)
+	})
+	if err != nil {
+		return milestoneError(err, "update milestone")
+	}
+
+	return milestoneResponse(c, goal, milestoneID, fiber.StatusOK)
+}
+
+func (h *MilestoneHandler) DeleteMilestone(c *fiber.Ctx) error {
+	goalID, milestoneID, err := parseMilestoneIDs(c)
+	if err != nil {
+		return err
+	}
+
+	version, err := ifMatchVersion(c, h.storage, "goal", goalID)
+	if err != nil {
+		return err
+	}
+
+	goal, err := h.storage.DeleteMilestone(goalID, milestoneID, version)
+	if err != nil {
+		return milestoneError(err, "delete milestone")
+	}
+
+	c.Set(fiber.HeaderETag, versionETag(goal.Version))
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+func parseMilestoneIDs(c *fiber.Ctx) (goalID, milestoneID int, err error) {
+	if goalID, err = parseID(c, "goal"); err != nil {
+		return 0, 0, err
+	}
+	milestoneID, err = strconv.Atoi(c.Params("milestoneId"))
+	if err != nil {
+		return 0, 0, apperror.BadRequest(apperror.CodeInvalidID, "Invalid milestone ID")
+	}
+	return goalID, milestoneID, nil
+}
+
+// milestoneError отличает отсутствующую веху от отсутствующей цели.
+func milestoneError(err error, action string) error {
+	if errors.Is(err, storage.ErrMilestoneNotFound) {
+		return storageError(err, "Milestone", action)
+	}
+	return storageError(err, "Goal", action)
+}
+
+// milestoneResponse отдаёт веху после изменения: её состояние могло измениться
+// при пересчёте (веха с числовой целью выполняется сама).
+func milestoneResponse(c *fiber.Ctx, goal *models.Goal, milestoneID, status int) error {
+	for _, milestone := range goal.Milestones {
+		if milestone.ID == milestoneID {
+			c.Set(fiber.HeaderETag, versionETag(goal.Version))
+			return c.Status(status).JSON(milestone)
+		}
+	}
+	return apperror.NotFound("Milestone not found")
+}
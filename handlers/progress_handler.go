@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/models"
+	"habit-tracker-api/storage"
+	"habit-tracker-api/validation"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProgressHandler — журнал числовой цели. Как и вехи, записи хранятся внутри цели:
+// ETag и If-Match — версия цели.
+type ProgressHandler struct {
+	storage *storage.JSONStorage
+}
+
+func NewProgressHandler(storage *storage.JSONStorage) *ProgressHandler {
+	return &ProgressHandler{storage: storage}
+}
+
+// ProgressEntryRequest — изменение значения (delta) или новое значение (value), но не оба сразу.
+type ProgressEntryRequest struct {
+	Date  time.Time `json:"date" validate:"notfuture"`
+	Delta *float64  `json:"delta" validate:"required_without=Value,excluded_with=Value"`
+	Value *float64  `json:"value" validate:"required_without=Delta"`
+	Note  string    `json:"note" validate:"max=500"`
+}
+
+func (r ProgressEntryRequest) entry() *models.ProgressEntry {
+	date := r.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	return &models.ProgressEntry{
+		Date:  date,
+		Delta: r.Delta,
+		Value: r.Value,
+		Note:  r.Note,
+	}
+}
+
+func (h *ProgressHandler) GetProgress(c *fiber.Ctx) error {
+	goalID, err := parseID(c, "goal")
+	if err != nil {
+		return err
+	}
+
+	goal, err := h.storage.GetGoalByID(goalID)
+	if err != nil {
+		return storageError(err, "Goal", "get progress")
+	}
+
+	if notModified(c, versionETag(goal.Version)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	entries := goal.Entries
+	if entries == nil {
+		entries = []models.ProgressEntry{}
+	}
+	return c.JSON(fiber.Map{
+		"entries":                   entries,
+		"count":                     len(entries),
+		"unit":                      goal.Unit,
+		"start_value":               goal.StartValue,
+		"target_value":              goal.TargetValue,
+		"current_value":             goal.CurrentValue,
+		"progress":                  goal.Progress,
+		"projected_completion_date": goal.ProjectedDate,
+		"completed":                 goal.Completed,
+	})
+}
+
+func (h *ProgressHandler) CreateEntry(c *fiber.Ctx) error {
+	goalID, err := parseID(c, "goal")
+	if err != nil {
+		return err
+	}
+
+	var req ProgressEntryRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	version, err := ifMatchVersion(c, h.storage, "goal", goalID)
+	if err != nil {
+		return err
+	}
+
+	// В ответе — цель целиком: запись меняет её значение, прогресс и прогноз
+	goal, err := h.storage.CreateProgressEntry(goalID, version, req.entry())
+	if err != nil {
+		return storageError(err, "Goal", "add progress entry")
+	}
+
+	c.Set(fiber.HeaderETag, versionETag(goal.Version))
+	return c.Status(fiber.StatusCreated).JSON(goal)
+}
+
+func (h *ProgressHandler) DeleteEntry(c *fiber.Ctx) error {
+	goalID, err := parseID(c, "goal")
+	if err != nil {
+		return err
+	}
+	entryID, err := strconv.Atoi(c.Params("entryId"))
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeInvalidID, "Invalid progress entry ID")
+	}
+
+	version, err := ifMatchVersion(c, h.storage, "goal", goalID)
+	if err != nil {
+		return err
+	}
+
+	goal, err := h.storage.DeleteProgressEntry(goalID, entryID, version)
+	if errors.Is(err, storage.ErrEntryNotFound) {
+		return storageError(err, "Progress entry", "delete progress entry")
+	}
+	if err != nil {
+		return storageError(err, "Goal", "delete progress entry")
+	}
+
+	c.Set(fiber.HeaderETag, versionETag(goal.Version))
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
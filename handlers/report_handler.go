@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/reports"
+	"habit-tracker-api/storage"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReportHandler struct {
+	storage *storage.JSONStorage
+}
+
+func NewReportHandler(storage *storage.JSONStorage) *ReportHandler {
+	return &ReportHandler{storage: storage}
+}
+
+func (h *ReportHandler) Weekly(c *fiber.Ctx) error {
+	return h.report(c, reports.Weekly)
+}
+
+func (h *ReportHandler) Monthly(c *fiber.Ctx) error {
+	return h.report(c, reports.Monthly)
+}
+
+// report строит отчёт за неделю (?week=2026-W42) или месяц (?month=2026-10), по умолчанию — текущие.
+// Формат выбирается параметром format или заголовком Accept.
+func (h *ReportHandler) report(c *fiber.Ctx, kind string) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	period := reports.CurrentWeek(now)
+	if kind == reports.Monthly {
+		period = reports.CurrentMonth(now)
+	}
+	if value := c.Query("week"); kind == reports.Weekly && value != "" {
+		period, err = reports.ParseWeek(value, now.Location())
+	}
+	if value := c.Query("month"); kind == reports.Monthly && value != "" {
+		period, err = reports.ParseMonth(value, now.Location())
+	}
+	if err != nil {
+		return apperror.BadRequest(apperror.CodeBadRequest, err.Error())
+	}
+
+	format, err := reportFormat(c)
+	if err != nil {
+		return err
+	}
+
+	data, err := h.reportData(userID)
+	if err != nil {
+		return storageError(err, "Report", "build report")
+	}
+	report := reports.Build(period, data, now)
+
+	if format == reports.FormatJSON {
+		return c.JSON(report)
+	}
+
+	var buf bytes.Buffer
+	if err := reports.Render(&buf, report, format, requestLocale(c, h.storage, userID)); err != nil {
+		return apperror.Internal(err, "Failed to render report")
+	}
+	c.Set(fiber.HeaderContentType, reports.ContentTypes[format])
+	return c.Send(buf.Bytes())
+}
+
+// ChartPDF — таблица привычек за месяц (?month=2026-10) для печати.
+func (h *ReportHandler) ChartPDF(c *fiber.Ctx) error {
+	return h.chart(c, "pdf")
+}
+
+// ChartSVG — та же таблица в SVG для встраивания в страницы.
+func (h *ReportHandler) ChartSVG(c *fiber.Ctx) error {
+	return h.chart(c, "svg")
+}
+
+func (h *ReportHandler) chart(c *fiber.Ctx, format string) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	period := reports.CurrentMonth(now)
+	if value := c.Query("month"); value != "" {
+		if period, err = reports.ParseMonth(value, now.Location()); err != nil {
+			return apperror.BadRequest(apperror.CodeBadRequest, err.Error())
+		}
+	}
+
+	data, err := h.reportData(userID)
+	if err != nil {
+		return storageError(err, "Report", "build chart")
+	}
+	chart := reports.BuildChart(period, data, now)
+	locale := requestLocale(c, h.storage, userID)
+
+	var buf bytes.Buffer
+	contentType := "image/svg+xml; charset=utf-8"
+	if format == "pdf" {
+		contentType = "application/pdf"
+		err = reports.WriteChartPDF(&buf, chart, locale)
+	} else {
+		err = reports.WriteChartSVG(&buf, chart, locale)
+	}
+	if err != nil {
+		return apperror.Internal(err, "Failed to render chart")
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="habits-%s.%s"`, period.Label, format))
+	return c.Send(buf.Bytes())
+}
+
+func reportFormat(c *fiber.Ctx) (string, error) {
+	switch format := c.Query("format"); format {
+	case reports.FormatJSON, reports.FormatMarkdown, reports.FormatHTML:
+		return format, nil
+	case "md":
+		return reports.FormatMarkdown, nil
+	case "":
+	default:
+		return "", apperror.BadRequest(apperror.CodeBadRequest, "format must be one of: json, markdown, html")
+	}
+
+	switch c.Accepts(fiber.MIMEApplicationJSON, "text/markdown", fiber.MIMETextHTML) {
+	case "text/markdown":
+		return reports.FormatMarkdown, nil
+	case fiber.MIMETextHTML:
+		return reports.FormatHTML, nil
+	default:
+		return reports.FormatJSON, nil
+	}
+}
+
+// reportData — записи пользователя и общие записи; без пользователя — все.
+func (h *ReportHandler) reportData(userID int) (reports.Data, error) {
+	var data reports.Data
+
+	habits, err := h.storage.GetAllHabits()
+	if err != nil {
+		return data, err
+	}
+	goals, err := h.storage.GetAllGoals()
+	if err != nil {
+		return data, err
+	}
+	tracks, err := h.storage.GetAllTracks()
+	if err != nil {
+		return data, err
+	}
+
+	visible := make(map[int]bool)
+	for _, habit := range habits {
+		if userID == 0 || ownedBy(habit.UserID, userID) {
+			data.Habits = append(data.Habits, habit)
+			visible[habit.ID] = true
+		}
+	}
+	for _, goal := range goals {
+		if userID == 0 || ownedBy(goal.UserID, userID) {
+			data.Goals = append(data.Goals, goal)
+		}
+	}
+	for _, track := range tracks {
+		if visible[track.HabitID] {
+			data.Tracks = append(data.Tracks, track)
+		}
+	}
+	return data, nil
+}
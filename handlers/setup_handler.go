@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/storage"
+	"habit-tracker-api/templates"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupHandler отдаёт наборы «цель и привычки» из каталога шаблонов и применяет их.
+type SetupHandler struct {
+	storage *storage.JSONStorage
+	catalog *templates.Catalog
+}
+
+func NewSetupHandler(storage *storage.JSONStorage, catalog *templates.Catalog) *SetupHandler {
+	return &SetupHandler{storage: storage, catalog: catalog}
+}
+
+func (h *SetupHandler) GetAllSetups(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	setups := h.catalog.SetupViews(requestLocale(c, h.storage, userID))
+	return c.JSON(fiber.Map{
+		"templates": setups,
+		"count":     len(setups),
+	})
+}
+
+func (h *SetupHandler) GetSetup(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	setup, ok := h.catalog.LookupSetup(c.Params("id"), requestLocale(c, h.storage, userID))
+	if !ok {
+		return apperror.NotFound("Goal template not found")
+	}
+	return c.JSON(setup)
+}
+
+// Apply создаёт цель и привычки набора для текущего пользователя одной транзакцией.
+func (h *SetupHandler) Apply(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	setup, ok := h.catalog.LookupSetup(c.Params("id"), requestLocale(c, h.storage, userID))
+	if !ok {
+		return apperror.NotFound("Goal template not found")
+	}
+
+	var result *templates.Result
+	err = h.storage.Batch(func(tx *storage.Tx) error {
+		var err error
+		result, err = templates.ApplySetup(tx, setup, userID, time.Now())
+		return err
+	})
+	if err != nil {
+		return storageError(err, "Goal template", "apply goal template")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(result)
+}
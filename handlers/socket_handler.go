@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/socket"
+	"habit-tracker-api/sse"
+	"habit-tracker-api/storage"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SocketHandler struct {
+	storage *storage.JSONStorage
+	hub     *sse.Hub
+}
+
+func NewSocketHandler(storage *storage.JSONStorage, hub *sse.Hub) *SocketHandler {
+	return &SocketHandler{storage: storage, hub: hub}
+}
+
+// Upgrade проверяет запрос до переключения протокола: ошибки ещё можно вернуть обычным ответом.
+func (h *SocketHandler) Upgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return apperror.New(fiber.StatusUpgradeRequired, apperror.CodeBadRequest, "WebSocket upgrade required")
+	}
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	c.Locals("userID", userID)
+
+	return c.Next()
+}
+
+func (h *SocketHandler) Serve() fiber.Handler {
+	return websocket.New(func(conn *websocket.Conn) {
+		userID, _ := conn.Locals("userID").(int)
+		socket.NewSession(conn.Conn, h.hub, h.execute, userID).Serve()
+	})
+}
+
+// execute выполняет команду complete с теми же проверками версии, что и PUT /:id/complete.
+// Подключение пользователя может менять только его и общие записи. Владелец проверяется
+// в той же операции, что и выполнение: между проверкой и записью запись не подменить.
+func (h *SocketHandler) execute(userID int, msg socket.ClientMessage) (interface{}, error) {
+	var result interface{}
+	switch msg.Entity {
+	case "habit":
+		err := h.storage.Batch(func(tx *storage.Tx) error {
+			habit := tx.Habit(msg.EntityID)
+			if habit == nil || (userID != 0 && !ownedBy(habit.UserID, userID)) {
+				return apperror.NotFound("Habit not found")
+			}
+			completed, err := tx.CompleteHabit(msg.EntityID, msg.Version)
+			result = completed
+			return err
+		})
+		if err != nil {
+			return nil, storageError(err, "Habit", "complete habit")
+		}
+		return result, nil
+
+	case "goal":
+		err := h.storage.Batch(func(tx *storage.Tx) error {
+			goal := tx.Goal(msg.EntityID)
+			if goal == nil || (userID != 0 && !ownedBy(goal.UserID, userID)) {
+				return apperror.NotFound("Goal not found")
+			}
+			completed, err := tx.CompleteGoal(msg.EntityID, msg.Version)
+			result = completed
+			return err
+		})
+		if err != nil {
+			return nil, storageError(err, "Goal", "complete goal")
+		}
+		return result, nil
+
+	default:
+		return nil, apperror.Validation(apperror.FieldError{
+			Field:   "entity",
+			Code:    "oneof",
+			Message: "entity must be one of: habit, goal",
+		})
+	}
+}
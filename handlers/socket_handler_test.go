@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/events"
+	"habit-tracker-api/models"
+	"habit-tracker-api/socket"
+	"habit-tracker-api/storage"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSocketExecute(t *testing.T) {
+	store, err := storage.NewJSONStorage(filepath.Join(t.TempDir(), "habits.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.OnCommit("completion-track", events.RecordCompletion)
+	for _, user := range []*models.User{{Name: "Анна"}, {Name: "Борис"}} {
+		if err := store.CreateUser(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+	own := models.Habit{UserID: 1, Name: "Бег", Category: "спорт", Frequency: "ежедневно", CreatedAt: time.Now()}
+	foreign := models.Habit{UserID: 2, Name: "Чтение", Category: "обучение", Frequency: "ежедневно", CreatedAt: time.Now()}
+	for _, habit := range []*models.Habit{&own, &foreign} {
+		if err := store.CreateHabit(habit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	goal := models.Goal{UserID: 2, Title: "Книги", TargetDate: time.Now().AddDate(0, 1, 0), CreatedAt: time.Now(), HabitIDs: []int{}}
+	if err := store.CreateGoal(&goal); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewSocketHandler(store, nil)
+	tests := []struct {
+		name   string
+		userID int
+		msg    socket.ClientMessage
+		status int
+	}{
+		{"foreign habit", 1, socket.ClientMessage{Entity: "habit", EntityID: foreign.ID}, 404},
+		{"foreign goal", 1, socket.ClientMessage{Entity: "goal", EntityID: goal.ID}, 404},
+		{"missing habit", 1, socket.ClientMessage{Entity: "habit", EntityID: 99}, 404},
+		{"stale version", 1, socket.ClientMessage{Entity: "habit", EntityID: own.ID, Version: own.Version + 1}, 412},
+		{"unknown entity", 1, socket.ClientMessage{Entity: "track", EntityID: 1}, 400},
+		{"own habit", 1, socket.ClientMessage{Entity: "habit", EntityID: own.ID, Version: own.Version}, 0},
+		{"completed habit", 1, socket.ClientMessage{Entity: "habit", EntityID: own.ID}, 409},
+		{"without user", 0, socket.ClientMessage{Entity: "goal", EntityID: goal.ID}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := h.execute(tt.userID, tt.msg)
+			if tt.status == 0 {
+				if err != nil || result == nil {
+					t.Fatalf("execute = %v, %v", result, err)
+				}
+				return
+			}
+			var problem *apperror.Error
+			if !errors.As(err, &problem) || problem.Status != tt.status {
+				t.Fatalf("execute error = %v, want status %d", err, tt.status)
+			}
+		})
+	}
+
+	habit, err := store.GetHabitByID(foreign.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if habit.Completed {
+		t.Error("foreign habit was completed")
+	}
+	tracks, err := store.GetAllTracks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 1 || tracks[0].HabitID != own.ID {
+		t.Errorf("tracks = %+v, want one completion track of habit %d", tracks, own.ID)
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StatisticsHandler struct {
+	storage *storage.JSONStorage
+}
+
+func NewStatisticsHandler(storage *storage.JSONStorage) *StatisticsHandler {
+	return &StatisticsHandler{storage: storage}
+}
+
+// GetStatistics — сводка по всем записям, а с X-User-ID — по записям пользователя и общим.
+func (h *StatisticsHandler) GetStatistics(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var stats *storage.Statistics
+	if userID == 0 {
+		stats, err = h.storage.GetStatistics()
+	} else {
+		stats, err = h.storage.GetUserStatistics(userID)
+	}
+	if err != nil {
+		return apperror.Internal(err, "Failed to get statistics")
+	}
+	return c.JSON(stats)
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/models"
+	"habit-tracker-api/storage"
+	"habit-tracker-api/validation"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	SyncUpsert = "upsert"
+	SyncDelete = "delete"
+)
+
+const (
+	SyncStatusCreated     = "created"
+	SyncStatusApplied     = "applied"
+	SyncStatusOverwritten = "overwritten"
+	SyncStatusRejected    = "rejected"
+	SyncStatusDeleted     = "deleted"
+	SyncStatusFailed      = "failed"
+)
+
+const (
+	SyncConflictVersion = "version"
+	SyncConflictDeleted = "deleted"
+)
+
+type SyncHandler struct {
+	storage *storage.JSONStorage
+}
+
+func NewSyncHandler(storage *storage.JSONStorage) *SyncHandler {
+	return &SyncHandler{storage: storage}
+}
+
+type SyncResponse struct {
+	Token   string              `json:"token"`
+	Reset   bool                `json:"reset"`
+	Habits  []models.Habit      `json:"habits"`
+	Goals   []models.Goal       `json:"goals"`
+	Tracks  []models.HabitTrack `json:"tracks"`
+	Deleted []storage.Tombstone `json:"deleted"`
+}
+
+// SyncChange — изменение, сделанное клиентом офлайн. Для upsert поле data — JSON Merge Patch
+// с изменёнными полями, для новой записи (id = 0) — тело как в POST.
+type SyncChange struct {
+	Entity      string          `json:"entity" validate:"required,oneof=habit goal track"`
+	Op          string          `json:"op" validate:"required,oneof=upsert delete"`
+	ID          int             `json:"id" validate:"required_if=Op delete,gte=0"`
+	ClientID    string          `json:"client_id" validate:"max=100"`
+	BaseVersion int             `json:"base_version" validate:"gte=0"`
+	ModifiedAt  time.Time       `json:"modified_at"`
+	Data        json.RawMessage `json:"data"`
+}
+
+type SyncPushRequest struct {
+	Changes []SyncChange `json:"changes" validate:"required,min=1,max=1000,dive"`
+}
+
+type SyncResult struct {
+	Index    int             `json:"index"`
+	Entity   string          `json:"entity"`
+	ClientID string          `json:"client_id,omitempty"`
+	ID       int             `json:"id,omitempty"`
+	Status   string          `json:"status"`
+	Conflict string          `json:"conflict,omitempty"`
+	Version  int             `json:"version,omitempty"`
+	Data     interface{}     `json:"data,omitempty"`
+	Error    *apperror.Error `json:"error,omitempty"`
+}
+
+type SyncPushResponse struct {
+	Token   string       `json:"token"`
+	Results []SyncResult `json:"results"`
+}
+
+func syncToken(revision int64) string {
+	return strconv.FormatInt(revision, 10)
+}
+
+// Pull возвращает записи, изменённые после токена since, и удалённые записи.
+// Без since — полный снимок.
+func (h *SyncHandler) Pull(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var since int64
+	if token := c.Query("since"); token != "" {
+		since, err = strconv.ParseInt(token, 10, 64)
+		if err != nil || since < 0 {
+			return apperror.BadRequest(apperror.CodeBadRequest, "Invalid sync token")
+		}
+	}
+
+	set, err := h.storage.ChangesSince(since)
+	if err != nil {
+		return storageError(err, "Sync", "get changes")
+	}
+
+	response := SyncResponse{
+		Token:   syncToken(set.Revision),
+		Reset:   set.Reset,
+		Habits:  []models.Habit{},
+		Goals:   []models.Goal{},
+		Tracks:  []models.HabitTrack{},
+		Deleted: []storage.Tombstone{},
+	}
+
+	visible := func(ownerID int) bool {
+		return userID == 0 || ownedBy(ownerID, userID)
+	}
+
+	for _, habit := range set.Habits {
+		if visible(habit.UserID) {
+			response.Habits = append(response.Habits, habit)
+		}
+	}
+	for _, goal := range set.Goals {
+		if visible(goal.UserID) {
+			response.Goals = append(response.Goals, goal)
+		}
+	}
+	for _, track := range set.Tracks {
+		if visible(set.Owners[track.HabitID]) {
+			response.Tracks = append(response.Tracks, track)
+		}
+	}
+	for _, tombstone := range set.Deleted {
+		if visible(tombstone.UserID) {
+			response.Deleted = append(response.Deleted, tombstone)
+		}
+	}
+
+	return c.JSON(response)
+}
+
+// Push применяет изменения клиента. Каждое изменение применяется независимо: ошибка или
+// конфликт в одном не отменяет остальные. Конфликт — запись изменилась на сервере после
+// base_version; побеждает более позднее изменение (last writer wins) по modified_at.
+func (h *SyncHandler) Push(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req SyncPushRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	results := make([]SyncResult, len(req.Changes))
+	var revision int64
+
+	err = h.storage.Batch(func(tx *storage.Tx) error {
+		for i, change := range req.Changes {
+			if change.ModifiedAt.IsZero() || change.ModifiedAt.After(now) {
+				change.ModifiedAt = now
+			}
+
+			result := applySyncChange(tx, change, userID)
+			result.Index = i
+			result.Entity = change.Entity
+			result.ClientID = change.ClientID
+			results[i] = result
+		}
+		revision = tx.Revision()
+		return nil
+	})
+	if err != nil {
+		return storageError(err, "Sync", "apply changes")
+	}
+
+	return c.JSON(SyncPushResponse{
+		Token:   syncToken(revision),
+		Results: results,
+	})
+}
+
+func applySyncChange(tx *storage.Tx, change SyncChange, userID int) SyncResult {
+	// Новая запись создаётся так же, как операция create пакета
+	if change.Op == SyncUpsert && change.ID == 0 {
+		id, data, err := applyBatchOperation(tx, BatchOperation{Op: BatchCreate, Entity: change.Entity, Data: change.Data}, userID)
+		if err != nil {
+			return syncFailed(err, change)
+		}
+		return SyncResult{ID: id, Status: SyncStatusCreated, Version: 1, Data: data}
+	}
+
+	if tombstone, deleted := tx.Tombstone(change.Entity, change.ID); deleted {
+		if change.Op == SyncDelete {
+			return SyncResult{ID: change.ID, Status: SyncStatusDeleted, Version: tombstone.Version}
+		}
+		// Правка удалённой записи не восстанавливает её
+		return SyncResult{ID: change.ID, Status: SyncStatusRejected, Conflict: SyncConflictDeleted}
+	}
+
+	version, modifiedAt, exists := tx.LastChange(change.Entity, change.ID)
+	if !exists || (userID != 0 && !ownedBy(syncOwner(tx, change.Entity, change.ID), userID)) {
+		return syncFailed(apperror.NotFound(batchEntityTitles[change.Entity]+" not found"), change)
+	}
+
+	result := SyncResult{ID: change.ID}
+	if change.BaseVersion != version {
+		result.Conflict = SyncConflictVersion
+		if !change.ModifiedAt.After(modifiedAt) {
+			// Изменение на сервере новее: оставляем серверную запись, клиент получит её в ответе
+			result.Status = SyncStatusRejected
+			result.Version = version
+			result.Data = syncRecord(tx, change.Entity, change.ID)
+			return result
+		}
+	}
+
+	op := BatchOperation{Op: BatchUpdate, Entity: change.Entity, ID: change.ID, Data: change.Data}
+	if change.Op == SyncDelete {
+		op.Op = BatchDelete
+	}
+	_, data, err := applyBatchOperation(tx, op, userID)
+	if err != nil {
+		return syncFailed(err, change)
+	}
+
+	switch {
+	case change.Op == SyncDelete:
+		result.Status = SyncStatusDeleted
+		return result
+	case result.Conflict != "":
+		result.Status = SyncStatusOverwritten
+	default:
+		result.Status = SyncStatusApplied
+	}
+	result.Version, _, _ = tx.LastChange(change.Entity, change.ID)
+	result.Data = data
+	return result
+}
+
+func syncRecord(tx *storage.Tx, entity string, id int) interface{} {
+	switch entity {
+	case "habit":
+		return tx.Habit(id)
+	case "goal":
+		return tx.Goal(id)
+	default:
+		return tx.Track(id)
+	}
+}
+
+func syncOwner(tx *storage.Tx, entity string, id int) int {
+	switch entity {
+	case "habit":
+		if habit := tx.Habit(id); habit != nil {
+			return habit.UserID
+		}
+	case "goal":
+		if goal := tx.Goal(id); goal != nil {
+			return goal.UserID
+		}
+	default:
+		if track := tx.Track(id); track != nil {
+			if habit := tx.Habit(track.HabitID); habit != nil {
+				return habit.UserID
+			}
+		}
+	}
+	return 0
+}
+
+func syncFailed(err error, change SyncChange) SyncResult {
+	var problem *apperror.Error
+	if !errors.As(storageError(err, batchEntityTitles[change.Entity], change.Op+" "+change.Entity), &problem) {
+		problem = apperror.Internal(err, "Failed to apply change")
+	}
+	return SyncResult{ID: change.ID, Status: SyncStatusFailed, Error: problem}
+}
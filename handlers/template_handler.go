@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/models"
+	"habit-tracker-api/storage"
+	"habit-tracker-api/templates"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TemplateHandler отдаёт каталог шаблонов привычек и создаёт привычки по ним.
+type TemplateHandler struct {
+	storage *storage.JSONStorage
+	catalog *templates.Catalog
+}
+
+func NewTemplateHandler(storage *storage.JSONStorage, catalog *templates.Catalog) *TemplateHandler {
+	return &TemplateHandler{storage: storage, catalog: catalog}
+}
+
+func (h *TemplateHandler) GetAllTemplates(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	locale := requestLocale(c, h.storage, userID)
+	habits := h.catalog.Habits(locale)
+	return c.JSON(fiber.Map{
+		"templates": habits,
+		"packs":     h.catalog.PackViews(locale),
+		"count":     len(habits),
+	})
+}
+
+// Apply создаёт текущему пользователю привычку шаблона или все привычки стартового набора.
+func (h *TemplateHandler) Apply(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	habitTemplates, ok := h.catalog.Lookup(c.Params("id"), requestLocale(c, h.storage, userID))
+	if !ok {
+		return apperror.NotFound("Template not found")
+	}
+
+	var (
+		habits  []models.Habit
+		created int
+	)
+	err = h.storage.Batch(func(tx *storage.Tx) error {
+		var err error
+		habits, created, err = templates.CreateHabits(tx, habitTemplates, userID, time.Now())
+		return err
+	})
+	if err != nil {
+		return storageError(err, "Template", "apply template")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"habits":         habits,
+		"habits_created": created,
+	})
+}
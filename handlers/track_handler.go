@@ -1,9 +1,10 @@
 package handlers
 
 import (
+	"habit-tracker-api/apperror"
 	"habit-tracker-api/models"
 	"habit-tracker-api/storage"
-	"strconv"
+	"habit-tracker-api/validation"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -18,25 +19,90 @@ func NewTrackHandler(storage *storage.JSONStorage) *TrackHandler {
 }
 
 type CreateTrackRequest struct {
-	HabitID   int       `json:"habit_id" validate:"required"`
-	Date      time.Time `json:"date" validate:"required"`
+	HabitID   int       `json:"habit_id" validate:"required,gt=0"`
+	Date      time.Time `json:"date" validate:"required,notfuture"`
 	Completed bool      `json:"completed"`
-	Notes     string    `json:"notes"`
+	Notes     string    `json:"notes" validate:"max=1000"`
 }
 
 type UpdateTrackRequest struct {
-	HabitID   int       `json:"habit_id" validate:"required"`
-	Date      time.Time `json:"date" validate:"required"`
+	HabitID   int       `json:"habit_id" validate:"required,gt=0"`
+	Date      time.Time `json:"date" validate:"required,notfuture"`
 	Completed bool      `json:"completed"`
-	Notes     string    `json:"notes"`
+	Notes     string    `json:"notes" validate:"max=1000"`
+}
+
+func (r CreateTrackRequest) track() *models.HabitTrack {
+	return &models.HabitTrack{
+		HabitID:   r.HabitID,
+		Date:      r.Date,
+		Completed: r.Completed,
+		Notes:     r.Notes,
+	}
+}
+
+func updateTrackRequestFrom(track models.HabitTrack) UpdateTrackRequest {
+	return UpdateTrackRequest{
+		HabitID:   track.HabitID,
+		Date:      track.Date,
+		Completed: track.Completed,
+		Notes:     track.Notes,
+	}
+}
+
+func (r UpdateTrackRequest) apply(track *models.HabitTrack) {
+	track.HabitID = r.HabitID
+	track.Date = r.Date
+	track.Completed = r.Completed
+	track.Notes = r.Notes
+}
+
+// patchTrack применяет merge patch к записи с той же валидацией, что и PUT.
+func patchTrack(patch []byte) func(track *models.HabitTrack) error {
+	return func(track *models.HabitTrack) error {
+		req, err := applyMergePatch(updateTrackRequestFrom(*track), patch)
+		if err != nil {
+			return err
+		}
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+		req.apply(track)
+		return nil
+	}
 }
 
 func (h *TrackHandler) GetAllTracks(c *fiber.Ctx) error {
-	tracks, err := h.storage.GetAllTracks()
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	all, err := h.storage.GetAllTracks()
+	if err != nil {
+		return storageError(err, "Track", "get tracks")
+	}
+	habits, err := h.storage.GetAllHabits()
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get tracks",
-		})
+		return storageError(err, "Habit", "get habits")
+	}
+	owners := make(map[int]int, len(habits))
+	for _, habit := range habits {
+		owners[habit.ID] = habit.UserID
+	}
+	tracks := make([]models.HabitTrack, 0, len(all))
+	for _, track := range all {
+		if visibleTo(owners[track.HabitID], userID) {
+			tracks = append(tracks, track)
+		}
+	}
+
+	versions := make(map[int]int, len(tracks))
+	for _, track := range tracks {
+		versions[track.ID] = track.Version
+	}
+	if notModified(c, collectionETag(versions)) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	return c.JSON(fiber.Map{
@@ -46,24 +112,18 @@ func (h *TrackHandler) GetAllTracks(c *fiber.Ctx) error {
 }
 
 func (h *TrackHandler) GetTrackByID(c *fiber.Ctx) error {
-	id, err := strconv.Atoi(c.Params("id"))
+	id, err := parseID(c, "track")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid track ID",
-		})
+		return err
 	}
 
 	track, err := h.storage.GetTrackByID(id)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get track",
-		})
+		return storageError(err, "Track", "get track")
 	}
 
-	if track == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Track not found",
-		})
+	if notModified(c, versionETag(track.Version)) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	return c.JSON(track)
@@ -72,135 +132,131 @@ func (h *TrackHandler) GetTrackByID(c *fiber.Ctx) error {
 func (h *TrackHandler) CreateTrack(c *fiber.Ctx) error {
 	var req CreateTrackRequest
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	if req.HabitID == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Habit ID is required",
-		})
+	if err := validation.Struct(&req); err != nil {
+		return err
 	}
 
-	habit, err := h.storage.GetHabitByID(req.HabitID)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to validate habit",
-		})
-	}
-	if habit == nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Habit not found",
-		})
+	track := req.track()
+	if err := h.checkHabit(c, track.HabitID); err != nil {
+		return err
 	}
-
-	track := &models.HabitTrack{
-		HabitID:   req.HabitID,
-		Date:      req.Date,
-		Completed: req.Completed,
-		Notes:     req.Notes,
-	}
-
 	if err := h.storage.CreateTrack(track); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create track",
-		})
+		return storageError(err, "Track", "create track")
 	}
 
+	c.Set(fiber.HeaderETag, versionETag(track.Version))
 	return c.Status(fiber.StatusCreated).JSON(track)
 }
 
 func (h *TrackHandler) UpdateTrack(c *fiber.Ctx) error {
-	id, err := strconv.Atoi(c.Params("id"))
+	id, err := parseID(c, "track")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid track ID",
-		})
+		return err
 	}
 
 	var req UpdateTrackRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	if req.HabitID == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Habit ID is required",
-		})
+	if err := validation.Struct(&req); err != nil {
+		return err
 	}
 
-	habit, err := h.storage.GetHabitByID(req.HabitID)
+	version, err := ifMatchVersion(c, h.storage, "track", id)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to validate habit",
-		})
+		return err
 	}
-	if habit == nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Habit not found",
-		})
+
+	updatedTrack, err := h.update(c, id, version, func(track *models.HabitTrack) error {
+		req.apply(track)
+		return nil
+	})
+	if err != nil {
+		return storageError(err, "Track", "update track")
 	}
 
-	existingTrack, err := h.storage.GetTrackByID(id)
+	c.Set(fiber.HeaderETag, versionETag(updatedTrack.Version))
+	return c.JSON(updatedTrack)
+}
+
+func (h *TrackHandler) PatchTrack(c *fiber.Ctx) error {
+	id, err := parseID(c, "track")
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get track",
-		})
+		return err
 	}
 
-	if existingTrack == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Track not found",
-		})
+	patch, err := mergePatchBody(c)
+	if err != nil {
+		return err
 	}
 
-	updatedTrack := &models.HabitTrack{
-		ID:        id,
-		HabitID:   req.HabitID,
-		Date:      req.Date,
-		Completed: req.Completed,
-		Notes:     req.Notes,
+	version, err := ifMatchVersion(c, h.storage, "track", id)
+	if err != nil {
+		return err
 	}
 
-	if err := h.storage.UpdateTrack(id, updatedTrack); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update track",
-		})
+	updatedTrack, err := h.update(c, id, version, patchTrack(patch))
+	if err != nil {
+		return storageError(err, "Track", "update track")
 	}
 
+	c.Set(fiber.HeaderETag, versionETag(updatedTrack.Version))
 	return c.JSON(updatedTrack)
 }
 
 func (h *TrackHandler) DeleteTrack(c *fiber.Ctx) error {
-	id, err := strconv.Atoi(c.Params("id"))
+	id, err := parseID(c, "track")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid track ID",
-		})
+		return err
 	}
 
-	track, err := h.storage.GetTrackByID(id)
+	version, err := ifMatchVersion(c, h.storage, "track", id)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get track",
-		})
+		return err
 	}
 
-	if track == nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Track not found",
-		})
+	if _, err := h.storage.DeleteTrack(id, version); err != nil {
+		return storageError(err, "Track", "delete track")
 	}
 
-	if err := h.storage.DeleteTrack(id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to delete track",
-		})
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// checkHabit не даёт отмечать чужую привычку: для пользователя её нет.
+func (h *TrackHandler) checkHabit(c *fiber.Ctx, habitID int) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	if habit, err := h.storage.GetHabitByID(habitID); err == nil && !visibleTo(habit.UserID, userID) {
+		return apperror.NotFound("Habit not found")
 	}
+	return nil
+}
 
-	return c.Status(fiber.StatusNoContent).Send(nil)
+// update изменяет отметку и в той же операции проверяет, что её не перенесли на чужую привычку.
+func (h *TrackHandler) update(c *fiber.Ctx, id, version int, update func(track *models.HabitTrack) error) (*models.HabitTrack, error) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated *models.HabitTrack
+	err = h.storage.Batch(func(tx *storage.Tx) error {
+		track, err := tx.UpdateTrack(id, version, update)
+		if err != nil {
+			return err
+		}
+		if habit := tx.Habit(track.HabitID); habit != nil && !visibleTo(habit.UserID, userID) {
+			return apperror.NotFound("Habit not found")
+		}
+		updated = track
+		return nil
+	})
+	return updated, err
 }
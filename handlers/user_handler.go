@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/models"
+	"habit-tracker-api/storage"
+	"habit-tracker-api/validation"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderUserID — заголовок с ID текущего пользователя. Полноценной аутентификации пока нет.
+const HeaderUserID = "X-User-ID"
+
+const DefaultLocale = "ru"
+
+type UserHandler struct {
+	storage *storage.JSONStorage
+}
+
+func NewUserHandler(storage *storage.JSONStorage) *UserHandler {
+	return &UserHandler{storage: storage}
+}
+
+type CreateUserRequest struct {
+	Name   string `json:"name" validate:"required,min=1,max=100"`
+	Email  string `json:"email" validate:"omitempty,email,max=254"`
+	Locale string `json:"locale" validate:"omitempty,oneof=ru en"`
+}
+
+// UserResponse — пользователь без токена ленты. Токен показывается только при создании и замене.
+type UserResponse struct {
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	Email          string    `json:"email"`
+	Locale         string    `json:"locale"`
+	TelegramLinked bool      `json:"telegram_linked"`
+	CreatedAt      time.Time `json:"created_at"`
+	Version        int       `json:"version"`
+}
+
+type FeedTokenResponse struct {
+	UserResponse
+	FeedToken string `json:"feed_token"`
+	FeedURL   string `json:"feed_url"`
+}
+
+func userResponse(user models.User) UserResponse {
+	return UserResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Locale:    user.Locale,
+		CreatedAt: user.CreatedAt,
+		Version:   user.Version,
+
+		TelegramLinked: user.TelegramChatID != 0,
+	}
+}
+
+func feedTokenResponse(c *fiber.Ctx, user models.User) FeedTokenResponse {
+	return FeedTokenResponse{
+		UserResponse: userResponse(user),
+		FeedToken:    user.FeedToken,
+		FeedURL:      c.BaseURL() + "/api/v1/calendar.ics?token=" + user.FeedToken,
+	}
+}
+
+// telegramLinkTTL — сколько действует код привязки Telegram.
+const telegramLinkTTL = 15 * time.Minute
+
+type TelegramLinkResponse struct {
+	Code      string    `json:"code"`
+	Command   string    `json:"command"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// newTelegramLinkCode — короткий код, который удобно набрать в чате: 8 символов base32 без 0/1/O/I.
+func newTelegramLinkCode() (string, error) {
+	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = alphabet[int(b)%len(alphabet)]
+	}
+	return string(buf), nil
+}
+
+func newFeedToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// requestLocale — язык из параметра lang, иначе язык пользователя.
+func requestLocale(c *fiber.Ctx, store *storage.JSONStorage, userID int) string {
+	if locale := c.Query("lang"); locale != "" {
+		return locale
+	}
+	if user, err := store.GetUserByID(userID); err == nil && user.Locale != "" {
+		return user.Locale
+	}
+	return DefaultLocale
+}
+
+// currentUserID возвращает ID из заголовка X-User-ID, 0 — если заголовка нет.
+func currentUserID(c *fiber.Ctx) (int, error) {
+	header := strings.TrimSpace(c.Get(HeaderUserID))
+	if header == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.Atoi(header)
+	if err != nil || id <= 0 {
+		return 0, apperror.BadRequest(apperror.CodeInvalidUser, "Invalid "+HeaderUserID+" header")
+	}
+	return id, nil
+}
+
+// requireUser пропускает только запросы от самого пользователя id: токены и привязки
+// дают доступ к его данным, поэтому чужие выпускать нельзя.
+func requireUser(c *fiber.Ctx, id int) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	if userID != id {
+		return apperror.Forbidden(HeaderUserID + " does not match the user")
+	}
+	return nil
+}
+
+func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	users, err := h.storage.GetAllUsers()
+	if err != nil {
+		return storageError(err, "User", "get users")
+	}
+
+	// Пользователь из X-User-ID видит только себя
+	response := make([]UserResponse, 0, len(users))
+	versions := make(map[int]int, len(users))
+	for _, user := range users {
+		if userID != 0 && user.ID != userID {
+			continue
+		}
+		response = append(response, userResponse(user))
+		versions[user.ID] = user.Version
+	}
+	if notModified(c, collectionETag(versions)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return c.JSON(fiber.Map{
+		"users": response,
+		"count": len(response),
+	})
+}
+
+func (h *UserHandler) GetUserByID(c *fiber.Ctx) error {
+	id, err := parseID(c, "user")
+	if err != nil {
+		return err
+	}
+
+	user, err := h.storage.GetUserByID(id)
+	if err != nil {
+		return storageError(err, "User", "get user")
+	}
+
+	if notModified(c, versionETag(user.Version)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return c.JSON(userResponse(*user))
+}
+
+func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
+	var req CreateUserRequest
+
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	token, err := newFeedToken()
+	if err != nil {
+		return apperror.Internal(err, "Failed to generate feed token")
+	}
+
+	user := &models.User{
+		Name:      req.Name,
+		Email:     req.Email,
+		Locale:    req.Locale,
+		FeedToken: token,
+		CreatedAt: time.Now(),
+	}
+	if user.Locale == "" {
+		user.Locale = DefaultLocale
+	}
+
+	if err := h.storage.CreateUser(user); err != nil {
+		return storageError(err, "User", "create user")
+	}
+
+	c.Set(fiber.HeaderETag, versionETag(user.Version))
+	return c.Status(fiber.StatusCreated).JSON(feedTokenResponse(c, *user))
+}
+
+// RotateFeedToken выдаёт новый токен календарной ленты, старая ссылка перестаёт работать.
+func (h *UserHandler) RotateFeedToken(c *fiber.Ctx) error {
+	id, err := parseID(c, "user")
+	if err != nil {
+		return err
+	}
+	if err := requireUser(c, id); err != nil {
+		return err
+	}
+
+	version, err := ifMatchVersion(c, h.storage, "user", id)
+	if err != nil {
+		return err
+	}
+
+	token, err := newFeedToken()
+	if err != nil {
+		return apperror.Internal(err, "Failed to generate feed token")
+	}
+
+	user, err := h.storage.UpdateUser(id, version, func(user *models.User) error {
+		user.FeedToken = token
+		return nil
+	})
+	if err != nil {
+		return storageError(err, "User", "rotate feed token")
+	}
+
+	c.Set(fiber.HeaderETag, versionETag(user.Version))
+	return c.JSON(feedTokenResponse(c, *user))
+}
+
+// CreateTelegramLink выдаёт одноразовый код: отправленный боту командой /link, он привязывает чат к пользователю.
+func (h *UserHandler) CreateTelegramLink(c *fiber.Ctx) error {
+	id, err := parseID(c, "user")
+	if err != nil {
+		return err
+	}
+	if err := requireUser(c, id); err != nil {
+		return err
+	}
+
+	code, err := newTelegramLinkCode()
+	if err != nil {
+		return apperror.Internal(err, "Failed to generate link code")
+	}
+	expiresAt := time.Now().Add(telegramLinkTTL)
+
+	_, err = h.storage.UpdateUser(id, storage.AnyVersion, func(user *models.User) error {
+		user.TelegramLinkCode = code
+		user.TelegramLinkExpiry = &expiresAt
+		return nil
+	})
+	if err != nil {
+		return storageError(err, "User", "create telegram link")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(TelegramLinkResponse{
+		Code:      code,
+		Command:   "/link " + code,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// DeleteTelegramLink отвязывает чат Telegram от пользователя.
+func (h *UserHandler) DeleteTelegramLink(c *fiber.Ctx) error {
+	id, err := parseID(c, "user")
+	if err != nil {
+		return err
+	}
+	if err := requireUser(c, id); err != nil {
+		return err
+	}
+
+	_, err = h.storage.UpdateUser(id, storage.AnyVersion, func(user *models.User) error {
+		user.TelegramChatID = 0
+		user.TelegramLinkCode = ""
+		user.TelegramLinkExpiry = nil
+		return nil
+	})
+	if err != nil {
+		return storageError(err, "User", "delete telegram link")
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
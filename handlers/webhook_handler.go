@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/models"
+	"habit-tracker-api/storage"
+	"habit-tracker-api/validation"
+	"habit-tracker-api/webhooks"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebhookHandler struct {
+	storage    *storage.JSONStorage
+	dispatcher *webhooks.Dispatcher
+}
+
+func NewWebhookHandler(storage *storage.JSONStorage, dispatcher *webhooks.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{storage: storage, dispatcher: dispatcher}
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,http_url,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,dive,webhookevent"`
+	Secret string   `json:"secret" validate:"omitempty,min=16,max=256"`
+	Active *bool    `json:"active"`
+}
+
+type UpdateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,http_url,max=2048"`
+	Events []string `json:"events" validate:"required,min=1,dive,webhookevent"`
+	Active bool     `json:"active"`
+}
+
+// WebhookResponse — подписка без секрета. Секрет показывается только при создании.
+type WebhookResponse struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id,omitempty"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int       `json:"version"`
+}
+
+func webhookResponse(webhook models.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:        webhook.ID,
+		UserID:    webhook.UserID,
+		URL:       webhook.URL,
+		Events:    webhook.Events,
+		Active:    webhook.Active,
+		CreatedAt: webhook.CreatedAt,
+		Version:   webhook.Version,
+	}
+}
+
+func updateWebhookRequestFrom(webhook models.Webhook) UpdateWebhookRequest {
+	return UpdateWebhookRequest{
+		URL:    webhook.URL,
+		Events: webhook.Events,
+		Active: webhook.Active,
+	}
+}
+
+func (r UpdateWebhookRequest) apply(webhook *models.Webhook) {
+	webhook.URL = r.URL
+	webhook.Events = r.Events
+	webhook.Active = r.Active
+}
+
+func patchWebhook(patch []byte) func(webhook *models.Webhook) error {
+	return func(webhook *models.Webhook) error {
+		req, err := applyMergePatch(updateWebhookRequestFrom(*webhook), patch)
+		if err != nil {
+			return err
+		}
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+		req.apply(webhook)
+		return nil
+	}
+}
+
+func (h *WebhookHandler) GetAllWebhooks(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	webhooks, err := h.storage.GetAllWebhooks()
+	if err != nil {
+		return storageError(err, "Webhook", "get webhooks")
+	}
+
+	response := make([]WebhookResponse, 0, len(webhooks))
+	versions := make(map[int]int, len(webhooks))
+	for _, webhook := range webhooks {
+		if !webhookVisibleTo(webhook.UserID, userID) {
+			continue
+		}
+		response = append(response, webhookResponse(webhook))
+		versions[webhook.ID] = webhook.Version
+	}
+	if notModified(c, collectionETag(versions)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return c.JSON(fiber.Map{
+		"webhooks": response,
+		"count":    len(response),
+	})
+}
+
+func (h *WebhookHandler) GetWebhookByID(c *fiber.Ctx) error {
+	id, err := parseID(c, "webhook")
+	if err != nil {
+		return err
+	}
+
+	webhook, err := h.storage.GetWebhookByID(id)
+	if err != nil {
+		return storageError(err, "Webhook", "get webhook")
+	}
+
+	if notModified(c, versionETag(webhook.Version)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	return c.JSON(webhookResponse(*webhook))
+}
+
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req CreateWebhookRequest
+
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = webhooks.NewSecret(); err != nil {
+			return apperror.Internal(err, "Failed to generate webhook secret")
+		}
+	}
+
+	webhook := &models.Webhook{
+		UserID:    userID,
+		URL:       req.URL,
+		Events:    req.Events,
+		Secret:    secret,
+		Active:    req.Active == nil || *req.Active,
+		CreatedAt: time.Now(),
+	}
+	if err := h.storage.CreateWebhook(webhook); err != nil {
+		return storageError(err, "Webhook", "create webhook")
+	}
+
+	c.Set(fiber.HeaderETag, versionETag(webhook.Version))
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"webhook": webhookResponse(*webhook),
+		"secret":  webhook.Secret,
+	})
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	id, err := parseID(c, "webhook")
+	if err != nil {
+		return err
+	}
+
+	var req UpdateWebhookRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	if err := validation.Struct(&req); err != nil {
+		return err
+	}
+
+	version, err := ifMatchVersion(c, h.storage, "webhook", id)
+	if err != nil {
+		return err
+	}
+
+	webhook, err := h.storage.UpdateWebhook(id, version, func(webhook *models.Webhook) error {
+		req.apply(webhook)
+		return nil
+	})
+	if err != nil {
+		return storageError(err, "Webhook", "update webhook")
+	}
+
+	c.Set(fiber.HeaderETag, versionETag(webhook.Version))
+	return c.JSON(webhookResponse(*webhook))
+}
+
+func (h *WebhookHandler) PatchWebhook(c *fiber.Ctx) error {
+	id, err := parseID(c, "webhook")
+	if err != nil {
+		return err
+	}
+
+	patch, err := mergePatchBody(c)
+	if err != nil {
+		return err
+	}
+
+	version, err := ifMatchVersion(c, h.storage, "webhook", id)
+	if err != nil {
+		return err
+	}
+
+	webhook, err := h.storage.UpdateWebhook(id, version, patchWebhook(patch))
+	if err != nil {
+		return storageError(err, "Webhook", "update webhook")
+	}
+
+	c.Set(fiber.HeaderETag, versionETag(webhook.Version))
+	return c.JSON(webhookResponse(*webhook))
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	id, err := parseID(c, "webhook")
+	if err != nil {
+		return err
+	}
+
+	version, err := ifMatchVersion(c, h.storage, "webhook", id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.storage.DeleteWebhook(id, version); err != nil {
+		return storageError(err, "Webhook", "delete webhook")
+	}
+
+	h.dispatcher.Forget(id)
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// GetDeliveries возвращает журнал последних попыток доставки, новые первыми.
+func (h *WebhookHandler) GetDeliveries(c *fiber.Ctx) error {
+	id, err := parseID(c, "webhook")
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.storage.GetWebhookByID(id); err != nil {
+		return storageError(err, "Webhook", "get webhook")
+	}
+
+	deliveries := h.dispatcher.Log().List(id)
+	return c.JSON(fiber.Map{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// Ping отправляет подписке проверочное событие, даже если она выключена.
+func (h *WebhookHandler) Ping(c *fiber.Ctx) error {
+	id, err := parseID(c, "webhook")
+	if err != nil {
+		return err
+	}
+
+	webhook, err := h.storage.GetWebhookByID(id)
+	if err != nil {
+		return storageError(err, "Webhook", "get webhook")
+	}
+
+	h.dispatcher.Send(*webhook, webhooks.Event{
+		Type: webhooks.Ping,
+		Data: fiber.Map{"webhook_id": webhook.ID},
+	})
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Ping event queued",
+		"id":      webhook.ID,
+	})
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"habit-tracker-api/webhooks"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWebhookEvents(t *testing.T) {
+	store := newTestStorage(t)
+	h := NewWebhookHandler(store, webhooks.NewDispatcher(store, webhooks.Options{}))
+	app := newTestApp()
+	app.Post("/webhooks", h.CreateWebhook)
+	app.Put("/webhooks/:id", h.UpdateWebhook)
+
+	resp := call(t, app, http.MethodPost, "/webhooks", 0, map[string]interface{}{
+		"url":    "https://example.com/hook",
+		"events": []string{webhooks.HabitReopened},
+	})
+	if resp.status != http.StatusCreated {
+		t.Fatalf("create with %s: %d %s", webhooks.HabitReopened, resp.status, resp.body)
+	}
+	var created struct {
+		Webhook WebhookResponse `json:"webhook"`
+	}
+	resp.decode(t, &created)
+
+	resp = call(t, app, http.MethodPut, "/webhooks/"+strconv.Itoa(created.Webhook.ID), 0, map[string]interface{}{
+		"url":    "https://example.com/hook",
+		"events": []string{webhooks.GoalReopened, webhooks.AllEvents},
+		"active": true,
+	})
+	if resp.status != http.StatusOK {
+		t.Fatalf("update with %s: %d %s", webhooks.GoalReopened, resp.status, resp.body)
+	}
+
+	// Каждое событие из webhooks.Events принимается, неизвестное — нет
+	resp = call(t, app, http.MethodPost, "/webhooks", 0, map[string]interface{}{
+		"url":    "https://example.com/hook",
+		"events": webhooks.Events,
+	})
+	if resp.status != http.StatusCreated {
+		t.Errorf("create with all events: %d %s", resp.status, resp.body)
+	}
+	resp = call(t, app, http.MethodPost, "/webhooks", 0, map[string]interface{}{
+		"url":    "https://example.com/hook",
+		"events": []string{"habit.archived"},
+	})
+	if resp.status != http.StatusBadRequest || !strings.Contains(string(resp.body), webhooks.HabitReopened) {
+		t.Errorf("create with unknown event: %d %s", resp.status, resp.body)
+	}
+}
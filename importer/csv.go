@@ -0,0 +1,142 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func init() {
+	Register("csv", csvParser{})
+}
+
+// csvParser — универсальный CSV: одна строка на отметку, столбцы задаются через ColumnMapping.
+type csvParser struct{}
+
+var truthyValues = map[string]bool{
+	"1": true, "true": true, "yes": true, "y": true, "x": true, "+": true,
+	"✓": true, "✔": true, "done": true, "да": true, "д": true, "выполнено": true,
+}
+
+func (csvParser) Parse(data []byte, opts Options) (*Dataset, error) {
+	columns := opts.Columns
+	if columns.Habit == "" {
+		columns.Habit = "habit"
+	}
+	if columns.Date == "" {
+		columns.Date = "date"
+	}
+
+	reader := newCSVReader(data)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	index := headerIndex(header)
+
+	column := func(name string, required bool) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		i, ok := index[strings.ToLower(name)]
+		if !ok {
+			if required {
+				return -1, fmt.Errorf("column %q not found", name)
+			}
+			return -1, nil
+		}
+		return i, nil
+	}
+
+	habitCol, err := column(columns.Habit, true)
+	if err != nil {
+		return nil, err
+	}
+	dateCol, err := column(columns.Date, true)
+	if err != nil {
+		return nil, err
+	}
+	completedCol, _ := column(columns.Completed, false)
+	notesCol, _ := column(columns.Notes, false)
+	categoryCol, _ := column(columns.Category, false)
+	frequencyCol, _ := column(columns.Frequency, false)
+
+	ds := NewDataset()
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			ds.AddError("", line, "%v", err)
+			continue
+		}
+
+		name := cell(row, habitCol)
+		if name == "" {
+			ds.AddError("", line, "habit name is empty")
+			continue
+		}
+		date, err := parseDate(cell(row, dateCol), opts.DateLayout)
+		if err != nil {
+			ds.AddError("", line, "%v", err)
+			continue
+		}
+
+		habit := ds.Habit(name)
+		if habit.Category == "" {
+			habit.Category = cell(row, categoryCol)
+			if habit.Category == "" {
+				habit.Category = opts.DefaultCategory
+			}
+		}
+		if habit.Frequency == "" {
+			habit.Frequency = cell(row, frequencyCol)
+		}
+
+		completed := true
+		if completedCol >= 0 {
+			completed = truthyValues[strings.ToLower(cell(row, completedCol))]
+		}
+		habit.Tracks = append(habit.Tracks, TrackRecord{
+			Date:      date,
+			Completed: completed,
+			Notes:     cell(row, notesCol),
+		})
+	}
+
+	return ds, nil
+}
+
+func newCSVReader(data []byte) *csv.Reader {
+	// Excel и многие приложения добавляют BOM в начало UTF-8 файла
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// Поддерживаем и CSV с точкой с запятой, который выгружает русская версия Excel
+	if firstLine, _, _ := strings.Cut(string(data), "\n"); strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		reader.Comma = ';'
+	}
+	return reader
+}
+
+func headerIndex(header []string) map[string]int {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	return index
+}
+
+func cell(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
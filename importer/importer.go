@@ -0,0 +1,314 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"habit-tracker-api/models"
+	"habit-tracker-api/validation"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	DefaultCategory  = "другое"
+	DefaultFrequency = "ежедневно"
+	// DefaultMaxFileSize — предел распакованного размера файла из архива
+	DefaultMaxFileSize = 32 << 20
+)
+
+// ErrFileTooLarge — файл в архиве больше Options.MaxFileSize.
+var ErrFileTooLarge = errors.New("file too large")
+
+// Options — настройки разбора файла. Не все парсеры используют все поля.
+type Options struct {
+	DefaultCategory string
+	DateLayout      string
+	Columns         ColumnMapping
+	// MaxFileSize — сколько байт можно распаковать из одного файла архива, 0 — DefaultMaxFileSize
+	MaxFileSize int64
+}
+
+func (o Options) maxFileSize() int64 {
+	if o.MaxFileSize > 0 {
+		return o.MaxFileSize
+	}
+	return DefaultMaxFileSize
+}
+
+// ColumnMapping задаёт имена столбцов для универсального CSV.
+type ColumnMapping struct {
+	Habit     string
+	Date      string
+	Completed string
+	Notes     string
+	Category  string
+	Frequency string
+}
+
+// Parser читает выгрузку другого приложения в общий Dataset.
+type Parser interface {
+	Parse(data []byte, opts Options) (*Dataset, error)
+}
+
+var parsers = map[string]Parser{}
+
+// Register добавляет парсер формата. Вызывается из init() файлов с парсерами.
+func Register(format string, parser Parser) {
+	parsers[format] = parser
+}
+
+func Lookup(format string) (Parser, bool) {
+	parser, ok := parsers[format]
+	return parser, ok
+}
+
+func Formats() []string {
+	formats := make([]string, 0, len(parsers))
+	for format := range parsers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Dataset — привычки с историей выполнения, прочитанные из файла.
+type Dataset struct {
+	Habits []*HabitRecord
+	Errors []RowError
+
+	byName map[string]*HabitRecord
+}
+
+type HabitRecord struct {
+	Name        string
+	Description string
+	Category    string
+	Frequency   string
+	Tracks      []TrackRecord
+}
+
+type TrackRecord struct {
+	Date      time.Time
+	Completed bool
+	Notes     string
+}
+
+// RowError — строка файла, которую не удалось импортировать.
+type RowError struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func NewDataset() *Dataset {
+	return &Dataset{byName: make(map[string]*HabitRecord)}
+}
+
+// Habit возвращает запись привычки по имени, создавая её при первом обращении.
+func (d *Dataset) Habit(name string) *HabitRecord {
+	key := nameKey(name)
+	if record, ok := d.byName[key]; ok {
+		return record
+	}
+
+	record := &HabitRecord{Name: strings.TrimSpace(name)}
+	d.byName[key] = record
+	d.Habits = append(d.Habits, record)
+	return record
+}
+
+func (d *Dataset) AddError(file string, line int, format string, args ...interface{}) {
+	d.Errors = append(d.Errors, RowError{
+		File:    file,
+		Line:    line,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func dayKey(habitID int, date time.Time) string {
+	return fmt.Sprintf("%d/%s", habitID, date.Format("2006-01-02"))
+}
+
+// Store — операции хранилища, нужные импорту. Реализуется storage.Tx.
+type Store interface {
+	GetAllHabits() ([]models.Habit, error)
+	GetAllTracks() ([]models.HabitTrack, error)
+	CreateHabit(habit *models.Habit) error
+	CreateTrack(track *models.HabitTrack) error
+}
+
+const (
+	ActionCreate = "create"
+	ActionMatch  = "match"
+)
+
+type Report struct {
+	Format        string        `json:"format"`
+	DryRun        bool          `json:"dry_run"`
+	HabitsCreated int           `json:"habits_created"`
+	HabitsMatched int           `json:"habits_matched"`
+	TracksCreated int           `json:"tracks_created"`
+	TracksSkipped int           `json:"tracks_skipped"`
+	Habits        []HabitReport `json:"habits"`
+	Errors        []RowError    `json:"errors"`
+}
+
+type HabitReport struct {
+	ID            int    `json:"id,omitempty"`
+	Name          string `json:"name"`
+	Action        string `json:"action"`
+	TracksCreated int    `json:"tracks_created"`
+	TracksSkipped int    `json:"tracks_skipped"`
+}
+
+// Import сопоставляет привычки по имени с существующими привычками пользователя и создаёт
+// недостающие привычки и отметки. Отметка за день, который уже есть у привычки, пропускается.
+// В режиме dryRun хранилище не меняется, отчёт показывает, что было бы создано.
+func Import(store Store, ds *Dataset, userID int, dryRun bool) (*Report, error) {
+	report := &Report{
+		DryRun: dryRun,
+		Habits: []HabitReport{},
+		Errors: append([]RowError{}, ds.Errors...),
+	}
+
+	habits, err := store.GetAllHabits()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(habits, func(i, j int) bool { return habits[i].ID < habits[j].ID })
+	existing := make(map[string]models.Habit, len(habits))
+	for _, habit := range habits {
+		// Привычки без владельца общие для всех пользователей
+		if habit.UserID != 0 && habit.UserID != userID {
+			continue
+		}
+		if _, ok := existing[nameKey(habit.Name)]; !ok {
+			existing[nameKey(habit.Name)] = habit
+		}
+	}
+
+	tracks, err := store.GetAllTracks()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(tracks))
+	for _, track := range tracks {
+		seen[dayKey(track.HabitID, track.Date)] = true
+	}
+
+	now := time.Now()
+	year, month, day := now.Date()
+	endOfToday := time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())
+
+	for _, record := range ds.Habits {
+		habitReport := HabitReport{Name: record.Name}
+
+		if record.Name == "" || utf8.RuneCountInString(record.Name) > 100 {
+			report.Errors = append(report.Errors, RowError{
+				Message: fmt.Sprintf("habit %q: name must be 1 to 100 characters long", record.Name),
+			})
+			continue
+		}
+
+		habit, found := existing[nameKey(record.Name)]
+		if found {
+			habitReport.Action = ActionMatch
+			habitReport.ID = habit.ID
+			report.HabitsMatched++
+		} else {
+			habit = models.Habit{
+				UserID:      userID,
+				Name:        record.Name,
+				Description: record.Description,
+				Category:    record.Category,
+				Frequency:   record.Frequency,
+				CreatedAt:   now,
+			}
+			if !validation.IsKnownCategory(habit.Category) {
+				habit.Category = DefaultCategory
+			}
+			if habit.Frequency == "" {
+				habit.Frequency = DefaultFrequency
+			}
+			if !dryRun {
+				if err := store.CreateHabit(&habit); err != nil {
+					return nil, err
+				}
+			}
+			existing[nameKey(habit.Name)] = habit
+			habitReport.Action = ActionCreate
+			habitReport.ID = habit.ID
+			report.HabitsCreated++
+		}
+
+		for _, trackRecord := range record.Tracks {
+			if !trackRecord.Date.Before(endOfToday) {
+				report.Errors = append(report.Errors, RowError{
+					Message: fmt.Sprintf("habit %q: date %s is in the future", record.Name, trackRecord.Date.Format("2006-01-02")),
+				})
+				continue
+			}
+
+			// В пробном режиме у новой привычки нет ID, отметки сверяем только внутри файла
+			key := dayKey(habit.ID, trackRecord.Date)
+			if habit.ID == 0 {
+				key = "new:" + nameKey(habit.Name) + "/" + trackRecord.Date.Format("2006-01-02")
+			}
+			if seen[key] {
+				habitReport.TracksSkipped++
+				continue
+			}
+			seen[key] = true
+
+			if !dryRun {
+				track := &models.HabitTrack{
+					HabitID:   habit.ID,
+					Date:      trackRecord.Date,
+					Completed: trackRecord.Completed,
+					Notes:     trackRecord.Notes,
+				}
+				if err := store.CreateTrack(track); err != nil {
+					return nil, err
+				}
+			}
+			habitReport.TracksCreated++
+		}
+
+		report.TracksCreated += habitReport.TracksCreated
+		report.TracksSkipped += habitReport.TracksSkipped
+		report.Habits = append(report.Habits, habitReport)
+	}
+
+	return report, nil
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"02.01.2006",
+	"01/02/2006",
+}
+
+// parseDate разбирает дату по заданному формату или по одному из распространённых.
+// Даты без часового пояса считаются локальными.
+func parseDate(value, layout string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if layout != "" {
+		return time.ParseInLocation(layout, value, time.Local)
+	}
+
+	for _, candidate := range dateLayouts {
+		if date, err := time.ParseInLocation(candidate, value, time.Local); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
@@ -0,0 +1,49 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"habit-tracker-api/export"
+)
+
+func init() {
+	Register("json", jsonParser{})
+}
+
+// jsonParser читает собственную JSON-выгрузку (GET /api/v1/export?format=json).
+type jsonParser struct{}
+
+func (jsonParser) Parse(data []byte, opts Options) (*Dataset, error) {
+	var doc export.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode export: %w", err)
+	}
+	if doc.SchemaVersion < 1 || doc.SchemaVersion > export.SchemaVersion {
+		return nil, fmt.Errorf("unsupported schema_version %d, expected up to %d", doc.SchemaVersion, export.SchemaVersion)
+	}
+
+	ds := NewDataset()
+	byID := make(map[int]*HabitRecord, len(doc.Habits))
+	for _, habit := range doc.Habits {
+		record := ds.Habit(habit.Name)
+		record.Description = habit.Description
+		record.Category = habit.Category
+		record.Frequency = habit.Frequency
+		byID[habit.ID] = record
+	}
+
+	for i, track := range doc.Tracks {
+		record, ok := byID[track.HabitID]
+		if !ok {
+			ds.AddError("tracks", i+1, "track %d references unknown habit %d", track.ID, track.HabitID)
+			continue
+		}
+		record.Tracks = append(record.Tracks, TrackRecord{
+			Date:      track.Date,
+			Completed: track.Completed,
+			Notes:     track.Notes,
+		})
+	}
+
+	return ds, nil
+}
@@ -0,0 +1,235 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register("loop", loopParser{})
+}
+
+// loopParser читает выгрузку Loop Habit Tracker: zip-архив с Habits.csv и Checkmarks.csv
+// или отдельный Checkmarks.csv (столбец Date и по столбцу на каждую привычку).
+type loopParser struct{}
+
+// Значения отметок в Loop: 2 — выполнено вручную, 1 — засчитано автоматически по частоте,
+// 0 — не выполнено, -1 — нет данных, 3 — пропуск.
+const loopYesManual = 2
+
+func (loopParser) Parse(data []byte, opts Options) (*Dataset, error) {
+	ds := NewDataset()
+
+	if !bytes.HasPrefix(data, []byte("PK")) {
+		return ds, parseLoopCheckmarks(ds, "Checkmarks.csv", data, nil, opts)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open zip: %w", err)
+	}
+
+	files := make(map[string][]byte)
+	for _, f := range archive.File {
+		// Нужны только два файла в корне архива, в подпапках лежат те же данные по каждой привычке
+		name := strings.ToLower(path.Clean(f.Name))
+		if name != "checkmarks.csv" && name != "habits.csv" {
+			continue
+		}
+		content, err := readZipFile(f, opts.maxFileSize())
+		if err != nil {
+			return nil, err
+		}
+		files[name] = content
+	}
+
+	checkmarks, ok := files["checkmarks.csv"]
+	if !ok {
+		return nil, errors.New("Checkmarks.csv not found in archive")
+	}
+
+	numeric := make(map[string]bool)
+	if habitsCSV, ok := files["habits.csv"]; ok {
+		numeric = parseLoopHabits(ds, habitsCSV, opts)
+	}
+
+	return ds, parseLoopCheckmarks(ds, "Checkmarks.csv", checkmarks, numeric, opts)
+}
+
+// readZipFile распаковывает не больше limit байт: размер в заголовке архива может врать.
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", f.Name, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s: %w: limit is %d bytes", f.Name, ErrFileTooLarge, limit)
+	}
+	return data, nil
+}
+
+// parseLoopHabits заполняет описания и частоту привычек и возвращает множество числовых привычек.
+func parseLoopHabits(ds *Dataset, data []byte, opts Options) map[string]bool {
+	numeric := make(map[string]bool)
+
+	reader := newCSVReader(data)
+	header, err := reader.Read()
+	if err != nil {
+		ds.AddError("Habits.csv", 1, "read header: %v", err)
+		return numeric
+	}
+	index := headerIndex(header)
+	col := func(name string) int {
+		if i, ok := index[strings.ToLower(name)]; ok {
+			return i
+		}
+		return -1
+	}
+
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			ds.AddError("Habits.csv", line, "%v", err)
+			continue
+		}
+
+		name := cell(row, col("Name"))
+		if name == "" {
+			continue
+		}
+
+		habit := ds.Habit(name)
+		habit.Description = cell(row, col("Description"))
+		if habit.Description == "" {
+			habit.Description = cell(row, col("Question"))
+		}
+		habit.Category = opts.DefaultCategory
+		habit.Frequency = loopFrequency(cell(row, col("NumRepetitions")), cell(row, col("Interval")))
+
+		if cell(row, col("Type")) == "1" {
+			numeric[nameKey(name)] = true
+		}
+	}
+
+	return numeric
+}
+
+func loopFrequency(repetitions, interval string) string {
+	reps, err1 := strconv.Atoi(repetitions)
+	days, err2 := strconv.Atoi(interval)
+	if err1 != nil || err2 != nil || reps <= 0 || days <= 0 {
+		return DefaultFrequency
+	}
+
+	switch {
+	case reps == 1 && days == 1:
+		return "ежедневно"
+	case reps == 1 && days == 7:
+		return "еженедельно"
+	case reps == 1 && days == 30:
+		return "ежемесячно"
+	case days == 7:
+		return fmt.Sprintf("%d раз в неделю", reps)
+	default:
+		return fmt.Sprintf("%d раз в %d дн.", reps, days)
+	}
+}
+
+func parseLoopCheckmarks(ds *Dataset, file string, data []byte, numeric map[string]bool, opts Options) error {
+	reader := newCSVReader(data)
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("read %s header: %w", file, err)
+	}
+	if len(header) < 2 || !strings.EqualFold(strings.TrimSpace(header[0]), "date") {
+		return fmt.Errorf("%s: first column must be Date", file)
+	}
+
+	habits := make([]*HabitRecord, len(header))
+	for i := 1; i < len(header); i++ {
+		name := strings.TrimSpace(header[i])
+		if name == "" {
+			continue
+		}
+		habits[i] = ds.Habit(name)
+		if habits[i].Category == "" {
+			habits[i].Category = opts.DefaultCategory
+		}
+	}
+
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			ds.AddError(file, line, "%v", err)
+			continue
+		}
+
+		date, err := parseDate(cell(row, 0), opts.DateLayout)
+		if err != nil {
+			ds.AddError(file, line, "%v", err)
+			continue
+		}
+
+		for i := 1; i < len(row) && i < len(habits); i++ {
+			habit := habits[i]
+			value := cell(row, i)
+			if habit == nil || value == "" {
+				continue
+			}
+
+			track, ok := loopTrack(value, numeric[nameKey(habit.Name)])
+			if !ok {
+				continue
+			}
+			track.Date = date
+			habit.Tracks = append(habit.Tracks, track)
+		}
+	}
+}
+
+// loopTrack переводит значение ячейки в отметку. Новые версии Loop пишут названия вместо чисел.
+func loopTrack(value string, numeric bool) (TrackRecord, bool) {
+	switch strings.ToUpper(value) {
+	case "YES_MANUAL", "YES":
+		return TrackRecord{Completed: true}, true
+	case "YES_AUTO", "NO", "UNKNOWN", "SKIP":
+		return TrackRecord{}, false
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return TrackRecord{}, false
+	}
+
+	if numeric {
+		// Числовые привычки Loop хранит умноженными на 1000
+		if number <= 0 {
+			return TrackRecord{}, false
+		}
+		amount := strconv.FormatFloat(number/1000, 'f', -1, 64)
+		return TrackRecord{Completed: true, Notes: "Loop: " + amount}, true
+	}
+
+	if int(number) == loopYesManual {
+		return TrackRecord{Completed: true}, true
+	}
+	return TrackRecord{}, false
+}
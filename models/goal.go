@@ -3,12 +3,59 @@ package models
 import "time"
 
 type Goal struct {
-	ID          int       `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	TargetDate  time.Time `json:"target_date"`
-	CreatedAt   time.Time `json:"created_at"`
-	Completed   bool      `json:"completed"`
-	CompletedAt time.Time `json:"completed_at"`
-	HabitIDs    []int     `json:"habit_ids"`
+	ID          int         `json:"id"`
+	UserID      int         `json:"user_id,omitempty"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	TargetDate  time.Time   `json:"target_date" validate:"notbeforefield=CreatedAt"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Completed   bool        `json:"completed"`
+	CompletedAt time.Time   `json:"completed_at"`
+	HabitIDs    []int       `json:"habit_ids"`
+	Milestones  []Milestone `json:"milestones,omitempty"`
+	// Progress — процент выполнения: по значению у числовой цели, иначе по вехам; пуст, если считать не по чему
+	Progress *float64 `json:"progress,omitempty"`
+	// AutoComplete — выполнить цель, когда выполнены все вехи
+	AutoComplete bool `json:"auto_complete"`
+
+	// Числовая цель: от StartValue к TargetValue, значение меняется записями журнала
+	TargetValue  *float64        `json:"target_value,omitempty"`
+	StartValue   float64         `json:"start_value,omitempty"`
+	Unit         string          `json:"unit,omitempty"`
+	CurrentValue *float64        `json:"current_value,omitempty"`
+	Entries      []ProgressEntry `json:"progress_entries,omitempty"`
+	// ProjectedDate — когда значение достигнет цели при нынешнем темпе (линейная регрессия по журналу)
+	ProjectedDate *time.Time `json:"projected_completion_date,omitempty"`
+
+	// Recurrence — период повторяющейся цели (weekly, monthly, quarterly, yearly): после
+	// выполнения или срока создаётся следующая цель серии
+	Recurrence string `json:"recurrence,omitempty"`
+	// PreviousID и NextID связывают соседние цели серии
+	PreviousID int `json:"previous_goal_id,omitempty"`
+	NextID     int `json:"next_goal_id,omitempty"`
+
+	// History — когда цель выполнялась и открывалась снова
+	History []StatusChange `json:"history,omitempty"`
+	Version int            `json:"version"`
+}
+
+// ProgressEntry — запись журнала числовой цели: изменение (Delta) или новое значение (Value).
+type ProgressEntry struct {
+	ID    int       `json:"id"`
+	Date  time.Time `json:"date"`
+	Delta *float64  `json:"delta,omitempty"`
+	Value *float64  `json:"value,omitempty"`
+	Note  string    `json:"note,omitempty"`
+}
+
+// Milestone — промежуточный шаг цели. Веха с TargetValue выполняется,
+// когда Value достигает цели, и до этого учитывается в прогрессе долей Value/TargetValue.
+type Milestone struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	TargetValue *float64   `json:"target_value,omitempty"`
+	Value       float64    `json:"value,omitempty"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
 }
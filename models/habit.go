@@ -4,10 +4,30 @@ import "time"
 
 type Habit struct {
 	ID          int       `json:"id"`
+	UserID      int       `json:"user_id,omitempty"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	Category    string    `json:"category"`
 	Frequency   string    `json:"frequency"`
+	Reminder    *Reminder `json:"reminder,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	Completed   bool      `json:"completed"`
+
+	// Target — норма за раз (8 стаканов, 10000 шагов), Unit — её единица
+	Target *float64 `json:"target,omitempty"`
+	Unit   string   `json:"unit,omitempty"`
+	// History — когда привычку отмечали выполненной и открывали снова
+	History []StatusChange `json:"history,omitempty"`
+	Version int            `json:"version"`
+}
+
+// Reminder — настройки напоминаний привычки. Время задаётся как "15:04"
+// в часовом поясе Timezone (по умолчанию — часовой пояс сервера).
+type Reminder struct {
+	Enabled    bool     `json:"enabled"`
+	Times      []string `json:"times"`
+	Weekdays   []int    `json:"weekdays,omitempty"` // 1 — понедельник, 7 — воскресенье; пусто — каждый день
+	QuietStart string   `json:"quiet_start,omitempty"`
+	QuietEnd   string   `json:"quiet_end,omitempty"`
+	Timezone   string   `json:"timezone,omitempty"`
 }
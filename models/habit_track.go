@@ -8,4 +8,5 @@ type HabitTrack struct {
 	Date      time.Time `json:"date"`
 	Completed bool      `json:"completed"`
 	Notes     string    `json:"notes"`
+	Version   int       `json:"version"`
 }
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+const (
+	StatusCompleted = "completed"
+	StatusReopened  = "reopened"
+)
+
+// StatusChange — запись истории выполнения привычки или цели.
+type StatusChange struct {
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type User struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Locale    string    `json:"locale"`
+	FeedToken string    `json:"feed_token"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int       `json:"version"`
+
+	// Привязка чата Telegram: код из API отправляется боту командой /link
+	TelegramChatID     int64      `json:"telegram_chat_id,omitempty"`
+	TelegramLinkCode   string     `json:"telegram_link_code,omitempty"`
+	TelegramLinkExpiry *time.Time `json:"telegram_link_expiry,omitempty"`
+}
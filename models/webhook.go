@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type Webhook struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id,omitempty"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int       `json:"version"`
+}
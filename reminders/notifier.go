@@ -0,0 +1,52 @@
+package reminders
+
+import (
+	"context"
+	"errors"
+	"habit-tracker-api/models"
+	"log"
+	"time"
+)
+
+// Notification — напоминание о привычке. User пуст для привычек без владельца.
+type Notification struct {
+	Habit models.Habit
+	User  *models.User
+	At    time.Time
+}
+
+// Notifier доставляет напоминания: в лог, на почту, в мессенджер.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+type NotifierFunc func(ctx context.Context, n Notification) error
+
+func (f NotifierFunc) Notify(ctx context.Context, n Notification) error {
+	return f(ctx, n)
+}
+
+// LogNotifier пишет напоминания в лог — используется, пока не настроена доставка.
+func LogNotifier(logger *log.Logger) Notifier {
+	return NotifierFunc(func(ctx context.Context, n Notification) error {
+		userID := 0
+		if n.User != nil {
+			userID = n.User.ID
+		}
+		logger.Printf("reminder: habit %d %q for user %d at %s", n.Habit.ID, n.Habit.Name, userID, n.At.Format("2006-01-02 15:04 MST"))
+		return nil
+	})
+}
+
+// Multi отправляет напоминание через все notifiers: ошибка одного не мешает остальным.
+func Multi(notifiers ...Notifier) Notifier {
+	return NotifierFunc(func(ctx context.Context, n Notification) error {
+		var errs []error
+		for _, notifier := range notifiers {
+			if err := notifier.Notify(ctx, n); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
@@ -0,0 +1,64 @@
+package reminders
+
+import (
+	"habit-tracker-api/calendar"
+	"habit-tracker-api/models"
+	"time"
+)
+
+// Period — отрезок, за который привычку нужно выполнить Required раз.
+type Period struct {
+	Start    time.Time
+	End      time.Time
+	Required int
+}
+
+// PeriodOf определяет период привычки по её частоте. Частота разбирается тем же
+// правилом, что и в календаре; нераспознанная частота считается ежедневной.
+func PeriodOf(frequency string, at time.Time) Period {
+	day := startOfDay(at)
+	rule, ok := calendar.ParseFrequency(frequency)
+	if !ok {
+		return Period{Start: day, End: day.AddDate(0, 0, 1), Required: 1}
+	}
+
+	switch rule.Freq {
+	case "WEEKLY":
+		if rule.Interval > 1 {
+			// «Каждые N недель» — скользящее окно: выполнение за последние N недель
+			return Period{Start: day.AddDate(0, 0, 1-7*rule.Interval), End: day.AddDate(0, 0, 1), Required: 1}
+		}
+		monday := day.AddDate(0, 0, 1-isoWeekday(day))
+		return Period{Start: monday, End: monday.AddDate(0, 0, 7), Required: max(len(rule.ByDay), 1)}
+	case "MONTHLY":
+		first := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return Period{Start: first, End: first.AddDate(0, 1, 0), Required: 1}
+	default:
+		return Period{Start: day.AddDate(0, 0, 1-rule.Interval), End: day.AddDate(0, 0, 1), Required: 1}
+	}
+}
+
+// Done — привычка уже выполнена в периоде, куда попадает at. Привычку, отмеченную
+// сегодня, повторно не напоминаем, даже если недельная норма ещё не набрана.
+func Done(habit models.Habit, tracks []models.HabitTrack, at time.Time) bool {
+	if habit.Completed {
+		return true
+	}
+
+	period := PeriodOf(habit.Frequency, at)
+	today := startOfDay(at)
+	count := 0
+	for _, track := range tracks {
+		if track.HabitID != habit.ID || !track.Completed {
+			continue
+		}
+		date := track.Date.In(at.Location())
+		if startOfDay(date).Equal(today) {
+			return true
+		}
+		if !date.Before(period.Start) && date.Before(period.End) {
+			count++
+		}
+	}
+	return count >= period.Required
+}
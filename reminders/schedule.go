@@ -0,0 +1,100 @@
+package reminders
+
+import (
+	"habit-tracker-api/models"
+	"sort"
+	"time"
+)
+
+// maxLookahead ограничивает поиск следующего напоминания: дальше недели
+// расписание повторяется.
+const maxLookahead = 8
+
+// parseClock возвращает смещение от начала суток для времени "15:04".
+func parseClock(value string) (time.Duration, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+}
+
+// isoWeekday — день недели от 1 (понедельник) до 7 (воскресенье).
+func isoWeekday(t time.Time) int {
+	if day := int(t.Weekday()); day != 0 {
+		return day
+	}
+	return 7
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func activeOn(reminder *models.Reminder, day time.Time) bool {
+	if len(reminder.Weekdays) == 0 {
+		return true
+	}
+	weekday := isoWeekday(day)
+	for _, d := range reminder.Weekdays {
+		if d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// inQuietHours — время суток попадает в тихие часы. Интервал может переходить
+// через полночь (22:00–08:00).
+func inQuietHours(reminder *models.Reminder, offset time.Duration) bool {
+	start, ok1 := parseClock(reminder.QuietStart)
+	end, ok2 := parseClock(reminder.QuietEnd)
+	if !ok1 || !ok2 || start == end {
+		return false
+	}
+	if start < end {
+		return offset >= start && offset < end
+	}
+	return offset >= start || offset < end
+}
+
+// Occurrences возвращает моменты напоминаний в интервале (from, to] в часовом
+// поясе loc, без дней недели вне расписания и без тихих часов.
+func Occurrences(reminder *models.Reminder, loc *time.Location, from, to time.Time) []time.Time {
+	if reminder == nil || !reminder.Enabled || !to.After(from) {
+		return nil
+	}
+
+	var result []time.Time
+	for day := startOfDay(from.In(loc)); !day.After(to); day = day.AddDate(0, 0, 1) {
+		if !activeOn(reminder, day) {
+			continue
+		}
+		for _, value := range reminder.Times {
+			offset, ok := parseClock(value)
+			if !ok || inQuietHours(reminder, offset) {
+				continue
+			}
+			// Часы и минуты передаются в time.Date, а не прибавляются к полуночи:
+			// в дни перехода на летнее время сутки не 24 часа
+			at := time.Date(day.Year(), day.Month(), day.Day(), int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, loc)
+			if at.After(from) && !at.After(to) {
+				result = append(result, at)
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Before(result[j])
+	})
+	return result
+}
+
+// Next — ближайшее напоминание после after.
+func Next(reminder *models.Reminder, loc *time.Location, after time.Time) (time.Time, bool) {
+	occurrences := Occurrences(reminder, loc, after, after.AddDate(0, 0, maxLookahead))
+	if len(occurrences) == 0 {
+		return time.Time{}, false
+	}
+	return occurrences[0], true
+}
@@ -0,0 +1,224 @@
+package reminders
+
+import (
+	"context"
+	"habit-tracker-api/events"
+	"habit-tracker-api/models"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Store — данные для напоминаний. Реализуется storage.JSONStorage.
+type Store interface {
+	GetAllHabits() ([]models.Habit, error)
+	GetAllTracks() ([]models.HabitTrack, error)
+	GetUserByID(id int) (*models.User, error)
+}
+
+type Options struct {
+	Clock Clock
+	// Location — часовой пояс напоминаний без своего Timezone. По умолчанию time.Local.
+	Location *time.Location
+	// MaxWait — как долго планировщик спит без перечитывания привычек: напоминания,
+	// изменённые без событий шины, подхватываются не позже, чем через MaxWait.
+	MaxWait time.Duration
+	Logger  *log.Logger
+}
+
+func (o *Options) withDefaults() {
+	if o.Clock == nil {
+		o.Clock = SystemClock
+	}
+	if o.Location == nil {
+		o.Location = time.Local
+	}
+	if o.MaxWait <= 0 {
+		o.MaxWait = time.Minute
+	}
+	if o.Logger == nil {
+		o.Logger = log.Default()
+	}
+}
+
+// Scheduler отправляет напоминания, время которых наступило с прошлой проверки.
+// Напоминания, пропущенные пока сервер не работал, не досылаются.
+type Scheduler struct {
+	store    Store
+	notifier Notifier
+	opts     Options
+
+	mu        sync.Mutex
+	last      time.Time
+	locations map[string]*time.Location
+	wake      chan struct{}
+
+	// Ближайшее напоминание, вычисленное в checked. Wake помечает его устаревшим
+	next    time.Time
+	checked time.Time
+	stale   atomic.Bool
+}
+
+func NewScheduler(store Store, notifier Notifier, opts Options) *Scheduler {
+	opts.withDefaults()
+	return &Scheduler{
+		store:     store,
+		notifier:  notifier,
+		opts:      opts,
+		last:      opts.Clock.Now(),
+		locations: make(map[string]*time.Location),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Subscribe будит планировщик при изменении привычек, чтобы новое время
+// напоминания учитывалось сразу, а не через MaxWait.
+func (s *Scheduler) Subscribe(bus *events.Bus) (unsubscribe func()) {
+	return bus.Subscribe("reminders", func(event events.Event) {
+		switch event.(type) {
+		case events.HabitCreated, events.HabitUpdated:
+			s.Wake()
+		}
+	})
+}
+
+// Wake заставляет Run заново вычислить ближайшее напоминание. Несколько вызовов
+// подряд приводят к одному пересчёту.
+func (s *Scheduler) Wake() {
+	s.stale.Store(true)
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run проверяет напоминания до отмены ctx, просыпаясь к ближайшему из них.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		wait := s.opts.MaxWait
+		if next, ok := s.Next(); ok {
+			if until := next.Sub(s.opts.Clock.Now()); until < wait {
+				wait = until
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-s.opts.Clock.After(wait):
+			s.Tick(ctx)
+		}
+	}
+}
+
+// Tick отправляет напоминания, наступившие с прошлого вызова, и возвращает их число.
+// На одну привычку за вызов уходит не больше одного напоминания.
+func (s *Scheduler) Tick(ctx context.Context) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.opts.Clock.Now()
+	from := s.last
+	if !now.After(from) {
+		return 0
+	}
+	s.last = now
+
+	habits, err := s.store.GetAllHabits()
+	if err != nil {
+		s.opts.Logger.Printf("reminders: failed to load habits: %v", err)
+		return 0
+	}
+
+	var due []Notification
+	for _, habit := range habits {
+		if habit.Reminder == nil || !habit.Reminder.Enabled || habit.Completed {
+			continue
+		}
+		occurrences := Occurrences(habit.Reminder, s.location(habit.Reminder), from, now)
+		if len(occurrences) == 0 {
+			continue
+		}
+		due = append(due, Notification{Habit: habit, At: occurrences[len(occurrences)-1]})
+	}
+	if len(due) == 0 {
+		return 0
+	}
+
+	tracks, err := s.store.GetAllTracks()
+	if err != nil {
+		s.opts.Logger.Printf("reminders: failed to load tracks: %v", err)
+		return 0
+	}
+
+	sent := 0
+	for _, n := range due {
+		if Done(n.Habit, tracks, now.In(n.At.Location())) {
+			continue
+		}
+		if n.Habit.UserID != 0 {
+			if user, err := s.store.GetUserByID(n.Habit.UserID); err == nil {
+				n.User = user
+			}
+		}
+		if err := s.notifier.Notify(ctx, n); err != nil {
+			s.opts.Logger.Printf("reminders: habit %d: %v", n.Habit.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+// Next — ближайшее напоминание среди всех привычек. Оно запоминается и пересчитывается,
+// только когда наступило, после Wake или спустя MaxWait.
+func (s *Scheduler) Next() (time.Time, bool) {
+	s.mu.Lock()
+	now := s.opts.Clock.Now()
+	cached := !s.stale.Load() && now.Sub(s.checked) < s.opts.MaxWait &&
+		(s.next.IsZero() || s.next.After(s.last))
+	next, last := s.next, s.last
+	s.mu.Unlock()
+	if cached {
+		return next, !next.IsZero()
+	}
+
+	// Wake во время чтения привычек снова пометит результат устаревшим
+	s.stale.Store(false)
+	habits, err := s.store.GetAllHabits()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next = time.Time{}
+	for _, habit := range habits {
+		if habit.Reminder == nil || !habit.Reminder.Enabled || habit.Completed {
+			continue
+		}
+		at, ok := Next(habit.Reminder, s.location(habit.Reminder), last)
+		if ok && (next.IsZero() || at.Before(next)) {
+			next = at
+		}
+	}
+	s.next, s.checked = next, now
+	return next, !next.IsZero()
+}
+
+func (s *Scheduler) location(reminder *models.Reminder) *time.Location {
+	if reminder.Timezone == "" {
+		return s.opts.Location
+	}
+	if loc, ok := s.locations[reminder.Timezone]; ok {
+		return loc
+	}
+	loc, err := time.LoadLocation(reminder.Timezone)
+	if err != nil {
+		loc = s.opts.Location
+	}
+	s.locations[reminder.Timezone] = loc
+	return loc
+}
@@ -0,0 +1,208 @@
+package reminders
+
+import (
+	"context"
+	"habit-tracker-api/models"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStore — Store поверх срезов в памяти. loads считает чтения привычек.
+type memoryStore struct {
+	habits []models.Habit
+	tracks []models.HabitTrack
+	loads  int
+}
+
+func (s *memoryStore) GetAllHabits() ([]models.Habit, error) {
+	s.loads++
+	return s.habits, nil
+}
+
+func (s *memoryStore) GetAllTracks() ([]models.HabitTrack, error) { return s.tracks, nil }
+func (s *memoryStore) GetUserByID(id int) (*models.User, error) {
+	return &models.User{ID: id}, nil
+}
+
+// inbox запоминает отправленные напоминания.
+type inbox struct {
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func (i *inbox) Notify(ctx context.Context, n Notification) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.sent = append(i.sent, n)
+	return nil
+}
+
+func (i *inbox) received() []Notification {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return append([]Notification(nil), i.sent...)
+}
+
+type fixture struct {
+	clock *FakeClock
+	inbox *inbox
+}
+
+// run запускает Run планировщика на FakeClock и ждёт, пока он уснёт.
+func run(t *testing.T, store Store, loc *time.Location, now time.Time) *fixture {
+	t.Helper()
+	f := &fixture{clock: NewFakeClock(now), inbox: &inbox{}}
+	scheduler := NewScheduler(store, f.inbox, Options{
+		Clock:    f.clock,
+		Location: loc,
+		Logger:   log.New(io.Discard, "", 0),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	f.clock.BlockUntil(1)
+	return f
+}
+
+// advance сдвигает время и ждёт, пока планировщик обработает его и снова уснёт.
+func (f *fixture) advance(d time.Duration) {
+	f.clock.Advance(d)
+	f.clock.BlockUntil(1)
+}
+
+func (f *fixture) expect(t *testing.T, want ...time.Time) {
+	t.Helper()
+	got := f.inbox.received()
+	if len(got) != len(want) {
+		t.Fatalf("sent %d reminders, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !got[i].At.Equal(want[i]) {
+			t.Errorf("reminder %d at %s, want %s", i, got[i].At, want[i])
+		}
+	}
+}
+
+func habitWith(reminder models.Reminder) models.Habit {
+	reminder.Enabled = true
+	return models.Habit{ID: 1, Name: "Зарядка", Frequency: "daily", Reminder: &reminder}
+}
+
+func TestScheduledTime(t *testing.T) {
+	store := &memoryStore{habits: []models.Habit{habitWith(models.Reminder{Times: []string{"09:00"}})}}
+	f := run(t, store, time.UTC, time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC))
+
+	f.advance(59 * time.Minute)
+	f.expect(t)
+
+	f.advance(time.Minute)
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	f.expect(t, at)
+
+	// Одно и то же напоминание не уходит дважды
+	f.advance(time.Hour)
+	f.expect(t, at)
+
+	// Привычку отметили — на следующий день не напоминаем
+	store.tracks = []models.HabitTrack{{HabitID: 1, Date: time.Date(2026, 6, 2, 8, 0, 0, 0, time.UTC), Completed: true}}
+	f.advance(24 * time.Hour)
+	f.expect(t, at)
+}
+
+func TestDaylightSavingTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	store := &memoryStore{habits: []models.Habit{habitWith(models.Reminder{Times: []string{"09:00"}})}}
+	// 29 марта 2026 Берлин переходит на летнее время: до 09:00 следующего дня 22 часа, а не 23
+	f := run(t, store, berlin, time.Date(2026, 3, 28, 10, 0, 0, 0, berlin))
+
+	f.advance(21*time.Hour + 59*time.Minute)
+	f.expect(t)
+
+	f.advance(time.Minute)
+	f.expect(t, time.Date(2026, 3, 29, 9, 0, 0, 0, berlin))
+	if got := f.inbox.received()[0].At.In(berlin); got.Hour() != 9 {
+		t.Errorf("reminder at %s, want 09:00 local time", got)
+	}
+}
+
+func TestPausedPeriod(t *testing.T) {
+	store := &memoryStore{habits: []models.Habit{habitWith(models.Reminder{
+		Times:      []string{"07:00", "12:00", "23:00"},
+		QuietStart: "22:00",
+		QuietEnd:   "08:00",
+	})}}
+	f := run(t, store, time.UTC, time.Date(2026, 6, 1, 6, 0, 0, 0, time.UTC))
+
+	// В тихие часы напоминаний нет
+	f.advance(2 * time.Hour)
+	f.expect(t)
+
+	f.advance(4 * time.Hour)
+	first := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	f.expect(t, first)
+
+	// Часы ушли на три дня вперёд, пока планировщик не работал: пропущенные
+	// напоминания не досылаются, уходит только последнее
+	f.clock.Set(time.Date(2026, 6, 4, 13, 0, 0, 0, time.UTC))
+	f.clock.BlockUntil(1)
+	f.expect(t, first, time.Date(2026, 6, 4, 12, 0, 0, 0, time.UTC))
+}
+
+func TestNextIsCached(t *testing.T) {
+	disabled := habitWith(models.Reminder{Times: []string{"08:30"}})
+	disabled.ID, disabled.Reminder.Enabled = 2, false
+	store := &memoryStore{habits: []models.Habit{habitWith(models.Reminder{Times: []string{"09:00"}}), disabled}}
+	clock := NewFakeClock(time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC))
+	scheduler := NewScheduler(store, &inbox{}, Options{Clock: clock, Location: time.UTC, Logger: log.New(io.Discard, "", 0)})
+
+	next := func() time.Time {
+		t.Helper()
+		at, ok := scheduler.Next()
+		if !ok {
+			t.Fatal("no next reminder")
+		}
+		return at
+	}
+
+	// Выключенное напоминание в 08:30 не учитывается
+	if at := next(); !at.Equal(time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("next reminder at %s, want 09:00", at)
+	}
+	next()
+	if store.loads != 1 {
+		t.Errorf("habits loaded %d times, want 1", store.loads)
+	}
+
+	// Несколько изменений подряд — один пересчёт
+	store.habits[0].Reminder = &models.Reminder{Enabled: true, Times: []string{"10:00"}}
+	scheduler.Wake()
+	scheduler.Wake()
+	if at := next(); !at.Equal(time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("next reminder at %s after Wake, want 10:00", at)
+	}
+	next()
+	if store.loads != 2 {
+		t.Errorf("habits loaded %d times, want 2", store.loads)
+	}
+
+	// Без событий привычки перечитываются через MaxWait
+	clock.Advance(time.Minute)
+	next()
+	if store.loads != 3 {
+		t.Errorf("habits loaded %d times, want 3", store.loads)
+	}
+}
@@ -0,0 +1,374 @@
+package reports
+
+import (
+	"fmt"
+	"habit-tracker-api/models"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Состояние клетки сетки
+const (
+	CellOpen     = iota // отметки нет — клетку можно заполнить от руки
+	CellDone            // в этот день есть выполненная отметка
+	CellInactive        // привычки ещё не было
+)
+
+type ChartDay struct {
+	Date    time.Time
+	Weekend bool
+}
+
+type ChartRow struct {
+	HabitID int
+	Name    string
+	Cells   []int
+	Done    int
+}
+
+const (
+	GoalDue       = "due"
+	GoalCompleted = "completed"
+	GoalOverdue   = "overdue"
+)
+
+type ChartDeadline struct {
+	Number int
+	GoalID int
+	Title  string
+	Date   time.Time
+	Status string
+}
+
+// Chart — сетка «привычки × дни месяца» для печати.
+type Chart struct {
+	Period      Period
+	GeneratedAt time.Time
+	Days        []ChartDay
+	// Today — индекс сегодняшнего дня в Days или -1
+	Today     int
+	Rows      []ChartRow
+	Deadlines []ChartDeadline
+}
+
+// BuildChart строит сетку за месяц: выполненные дни привычек и сроки целей.
+func BuildChart(period Period, data Data, now time.Time) *Chart {
+	loc := period.Start.Location()
+	now = now.In(loc)
+
+	chart := &Chart{Period: period, GeneratedAt: now, Today: -1}
+	for day := period.Start; day.Before(period.End); day = day.AddDate(0, 0, 1) {
+		if day.Equal(startOfDay(now)) {
+			chart.Today = len(chart.Days)
+		}
+		chart.Days = append(chart.Days, ChartDay{
+			Date:    day,
+			Weekend: day.Weekday() == time.Saturday || day.Weekday() == time.Sunday,
+		})
+	}
+
+	done := make(map[int]map[int]bool)
+	for _, track := range data.Tracks {
+		date := track.Date.In(loc)
+		if !track.Completed || !period.Contains(date) {
+			continue
+		}
+		if done[track.HabitID] == nil {
+			done[track.HabitID] = make(map[int]bool)
+		}
+		done[track.HabitID][date.Day()-1] = true
+	}
+
+	habits := append([]models.Habit(nil), data.Habits...)
+	sort.Slice(habits, func(i, j int) bool { return habits[i].ID < habits[j].ID })
+
+	for _, habit := range habits {
+		if !habit.CreatedAt.Before(period.End) {
+			continue
+		}
+		created := startOfDay(habit.CreatedAt.In(loc))
+		row := ChartRow{HabitID: habit.ID, Name: habit.Name, Cells: make([]int, len(chart.Days))}
+		for i, day := range chart.Days {
+			switch {
+			case done[habit.ID][i]:
+				row.Cells[i] = CellDone
+				row.Done++
+			case day.Date.Before(created):
+				row.Cells[i] = CellInactive
+			}
+		}
+		chart.Rows = append(chart.Rows, row)
+	}
+
+	goals := append([]models.Goal(nil), data.Goals...)
+	sort.Slice(goals, func(i, j int) bool {
+		if !goals[i].TargetDate.Equal(goals[j].TargetDate) {
+			return goals[i].TargetDate.Before(goals[j].TargetDate)
+		}
+		return goals[i].ID < goals[j].ID
+	})
+
+	for _, goal := range goals {
+		target := goal.TargetDate.In(loc)
+		if !period.Contains(target) {
+			continue
+		}
+		status := GoalDue
+		switch {
+		case goal.Completed:
+			status = GoalCompleted
+		case target.Before(now):
+			status = GoalOverdue
+		}
+		chart.Deadlines = append(chart.Deadlines, ChartDeadline{
+			Number: len(chart.Deadlines) + 1,
+			GoalID: goal.ID,
+			Title:  goal.Title,
+			Date:   target,
+			Status: status,
+		})
+	}
+
+	return chart
+}
+
+// Размеры страницы и сетки в миллиметрах: A4 альбомной ориентации
+const (
+	pageWidth    = 297.0
+	pageHeight   = 210.0
+	margin       = 10.0
+	nameWidth    = 62.0
+	totalWidth   = 12.0
+	headerHeight = 9.0
+	rowHeight    = 6.0
+	lineHeight   = 4.5
+	// ptToMM переводит кегль шрифта в миллиметры
+	ptToMM = 25.4 / 72
+)
+
+type color struct{ r, g, b int }
+
+var (
+	none        = color{-1, -1, -1}
+	black       = color{33, 33, 33}
+	white       = color{255, 255, 255}
+	muted       = color{117, 117, 117}
+	gridColor   = color{176, 176, 176}
+	weekendFill = color{243, 243, 243}
+	todayFill   = color{255, 243, 196}
+	inactive    = color{220, 220, 220}
+	doneFill    = color{67, 160, 71}
+	overdueFill = color{229, 57, 53}
+	dueFill     = color{251, 140, 0}
+)
+
+func (c color) hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.r, c.g, c.b)
+}
+
+type font struct {
+	size  float64
+	bold  bool
+	color color
+	// anchor — выравнивание относительно x: start, middle или end
+	anchor string
+}
+
+// canvas — поверхность рисования. Сетка рисуется одним кодом в PDF и SVG.
+type canvas interface {
+	rect(x, y, w, h float64, fill, stroke color)
+	text(x, y float64, s string, f font)
+	// clip укорачивает строку, чтобы она уместилась в width
+	clip(s string, f font, width float64) string
+	addPage()
+}
+
+var monthNames = map[string][]string{
+	"ru": {"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь", "Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь"},
+	"en": {"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+}
+
+// weekdayNames начинаются с воскресенья, как time.Weekday
+var weekdayNames = map[string][]string{
+	"ru": {"вс", "пн", "вт", "ср", "чт", "пт", "сб"},
+	"en": {"Su", "Mo", "Tu", "We", "Th", "Fr", "Sa"},
+}
+
+func chartLocale(locale string) string {
+	if _, ok := labels[locale]; ok {
+		return locale
+	}
+	return defaultLocale
+}
+
+func chartTitle(chart *Chart, locale string) string {
+	l := labels[locale]
+	month := chart.Period.Start
+	return fmt.Sprintf("%s — %s %d", l["chart_title"], monthNames[locale][month.Month()-1], month.Year())
+}
+
+// drawChart рисует сетку. На постраничной поверхности строки, не поместившиеся
+// на лист, переносятся на следующий вместе с шапкой. Возвращает высоту нарисованного.
+func drawChart(cv canvas, chart *Chart, locale string, paged bool) float64 {
+	l := labels[locale]
+	dayWidth := (pageWidth - 2*margin - nameWidth - totalWidth) / float64(len(chart.Days))
+	gridX := margin + nameWidth
+	totalX := gridX + dayWidth*float64(len(chart.Days))
+
+	cv.text(margin, margin+6, chartTitle(chart, locale), font{size: 16, bold: true, color: black})
+	cv.text(pageWidth-margin, margin+6, chart.GeneratedAt.Format(l["date_layout"]), font{size: 7, color: muted, anchor: "end"})
+	y := margin + 11
+
+	header := func() {
+		cv.rect(margin, y, nameWidth, headerHeight, none, gridColor)
+		cv.text(margin+1.5, y+headerHeight-2.5, l["habit"], font{size: 8, bold: true, color: black})
+		for i, day := range chart.Days {
+			fill := white
+			if day.Weekend {
+				fill = weekendFill
+			}
+			if i == chart.Today {
+				fill = todayFill
+			}
+			x := gridX + dayWidth*float64(i)
+			cv.rect(x, y, dayWidth, headerHeight, fill, gridColor)
+			cv.text(x+dayWidth/2, y+4, strconv.Itoa(day.Date.Day()), font{size: 7, bold: true, color: black, anchor: "middle"})
+			cv.text(x+dayWidth/2, y+7.5, weekdayNames[locale][day.Date.Weekday()], font{size: 5.5, color: muted, anchor: "middle"})
+		}
+		cv.rect(totalX, y, totalWidth, headerHeight, none, gridColor)
+		cv.text(totalX+totalWidth/2, y+headerHeight-2.5, l["chart_total"], font{size: 6.5, bold: true, color: black, anchor: "middle"})
+		y += headerHeight
+	}
+
+	// fit начинает новый лист, если блок высотой h не помещается на текущий
+	fit := func(h float64, withHeader bool) {
+		if !paged || y+h <= pageHeight-margin {
+			return
+		}
+		cv.addPage()
+		y = margin
+		if withHeader {
+			header()
+		}
+	}
+
+	header()
+
+	for _, row := range chart.Rows {
+		fit(rowHeight, true)
+		cv.rect(margin, y, nameWidth, rowHeight, none, gridColor)
+		cv.text(margin+1.5, y+rowHeight-1.9, cv.clip(row.Name, font{size: 8}, nameWidth-3), font{size: 8, color: black})
+		for i, cell := range row.Cells {
+			x := gridX + dayWidth*float64(i)
+			fill := white
+			switch {
+			case cell == CellInactive:
+				fill = inactive
+			case chart.Days[i].Weekend:
+				fill = weekendFill
+			}
+			cv.rect(x, y, dayWidth, rowHeight, fill, gridColor)
+			if cell == CellDone {
+				cv.rect(x+0.9, y+0.9, dayWidth-1.8, rowHeight-1.8, doneFill, none)
+			}
+		}
+		cv.rect(totalX, y, totalWidth, rowHeight, none, gridColor)
+		cv.text(totalX+totalWidth/2, y+rowHeight-1.9, strconv.Itoa(row.Done), font{size: 8, color: black, anchor: "middle"})
+		y += rowHeight
+	}
+
+	// Строка сроков целей: номер цели в клетке её дня
+	byDay := make(map[int][]ChartDeadline)
+	for _, deadline := range chart.Deadlines {
+		byDay[deadline.Date.Day()-1] = append(byDay[deadline.Date.Day()-1], deadline)
+	}
+	fit(rowHeight, true)
+	cv.rect(margin, y, nameWidth, rowHeight, none, gridColor)
+	cv.text(margin+1.5, y+rowHeight-1.9, l["chart_deadlines"], font{size: 8, bold: true, color: black})
+	for i, day := range chart.Days {
+		x := gridX + dayWidth*float64(i)
+		fill := white
+		if day.Weekend {
+			fill = weekendFill
+		}
+		cv.rect(x, y, dayWidth, rowHeight, fill, gridColor)
+		if deadlines := byDay[i]; len(deadlines) > 0 {
+			mark := strconv.Itoa(deadlines[0].Number)
+			if len(deadlines) > 1 {
+				mark += "+"
+			}
+			cv.text(x+dayWidth/2, y+rowHeight-1.9, mark, font{size: 7, bold: true, color: statusColor(deadlines[0].Status), anchor: "middle"})
+		}
+	}
+	cv.rect(totalX, y, totalWidth, rowHeight, none, gridColor)
+	y += rowHeight + 4
+
+	fit(lineHeight, false)
+	x := margin
+	legend := []struct {
+		fill  color
+		label string
+	}{
+		{doneFill, l["chart_done"]},
+		{inactive, l["chart_inactive"]},
+		{weekendFill, l["chart_weekend"]},
+	}
+	for _, item := range legend {
+		cv.rect(x, y, 3, 3, item.fill, gridColor)
+		cv.text(x+4.5, y+2.6, item.label, font{size: 7, color: muted})
+		x += 40
+	}
+	y += lineHeight + 2
+
+	if len(chart.Deadlines) == 0 {
+		return y
+	}
+
+	fit(lineHeight*2, false)
+	cv.text(margin, y+3, l["goals"], font{size: 9, bold: true, color: black})
+	y += lineHeight + 1
+
+	// Сроки целей — в две колонки
+	columnWidth := (pageWidth - 2*margin) / 2
+	for i := 0; i < len(chart.Deadlines); i += 2 {
+		fit(lineHeight, false)
+		for j := i; j < i+2 && j < len(chart.Deadlines); j++ {
+			deadline := chart.Deadlines[j]
+			x := margin + columnWidth*float64(j-i)
+			status := ""
+			if deadline.Status != GoalDue {
+				status = " — " + l["chart_goal_"+deadline.Status]
+			}
+			prefix := fmt.Sprintf("%d. %s  ", deadline.Number, deadline.Date.Format(l["date_layout"]))
+			line := cv.clip(prefix+deadline.Title+status, font{size: 8}, columnWidth-6)
+			cv.rect(x, y+0.6, 2.4, 2.4, statusColor(deadline.Status), none)
+			cv.text(x+4, y+3, line, font{size: 8, color: black})
+		}
+		y += lineHeight
+	}
+	return y
+}
+
+func statusColor(status string) color {
+	switch status {
+	case GoalCompleted:
+		return doneFill
+	case GoalOverdue:
+		return overdueFill
+	default:
+		return dueFill
+	}
+}
+
+// truncate укорачивает строку, пока width не скажет, что она помещается в max.
+func truncate(s string, max float64, width func(string) float64) string {
+	if width(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && width(string(runes)+"…") > max {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
@@ -0,0 +1,84 @@
+package reports
+
+import (
+	_ "embed"
+	"io"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const chartFont = "dejavu"
+
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	fontRegular []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	fontBold []byte
+)
+
+type pdfCanvas struct {
+	pdf *fpdf.Fpdf
+}
+
+func (c *pdfCanvas) rect(x, y, w, h float64, fill, stroke color) {
+	style := ""
+	if fill != none {
+		c.pdf.SetFillColor(fill.r, fill.g, fill.b)
+		style += "F"
+	}
+	if stroke != none {
+		c.pdf.SetDrawColor(stroke.r, stroke.g, stroke.b)
+		style += "D"
+	}
+	if style != "" {
+		c.pdf.Rect(x, y, w, h, style)
+	}
+}
+
+func (c *pdfCanvas) text(x, y float64, s string, f font) {
+	c.setFont(f)
+	c.pdf.SetTextColor(f.color.r, f.color.g, f.color.b)
+	switch f.anchor {
+	case "middle":
+		x -= c.pdf.GetStringWidth(s) / 2
+	case "end":
+		x -= c.pdf.GetStringWidth(s)
+	}
+	c.pdf.Text(x, y, s)
+}
+
+func (c *pdfCanvas) clip(s string, f font, width float64) string {
+	c.setFont(f)
+	return truncate(s, width, c.pdf.GetStringWidth)
+}
+
+func (c *pdfCanvas) setFont(f font) {
+	style := ""
+	if f.bold {
+		style = "B"
+	}
+	c.pdf.SetFont(chartFont, style, f.size)
+}
+
+func (c *pdfCanvas) addPage() {
+	c.pdf.AddPage()
+}
+
+// WriteChartPDF выводит сетку на листах A4 альбомной ориентации.
+func WriteChartPDF(w io.Writer, chart *Chart, locale string) error {
+	locale = chartLocale(locale)
+
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetMargins(margin, margin, margin)
+	pdf.AddUTF8FontFromBytes(chartFont, "", fontRegular)
+	pdf.AddUTF8FontFromBytes(chartFont, "B", fontBold)
+	pdf.SetTitle(chartTitle(chart, locale), true)
+	pdf.SetCreator("Habit Tracker API", true)
+	pdf.SetCreationDate(chart.GeneratedAt)
+	pdf.SetLineWidth(0.2)
+	pdf.AddPage()
+
+	drawChart(&pdfCanvas{pdf: pdf}, chart, locale, true)
+	return pdf.Output(w)
+}
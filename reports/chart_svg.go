@@ -0,0 +1,74 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf8"
+)
+
+type svgCanvas struct {
+	buf bytes.Buffer
+}
+
+func (c *svgCanvas) rect(x, y, w, h float64, fill, stroke color) {
+	fmt.Fprintf(&c.buf, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f"`, x, y, w, h)
+	if fill != none {
+		fmt.Fprintf(&c.buf, ` fill="%s"`, fill.hex())
+	} else {
+		c.buf.WriteString(` fill="none"`)
+	}
+	if stroke != none {
+		fmt.Fprintf(&c.buf, ` stroke="%s" stroke-width="0.2"`, stroke.hex())
+	}
+	c.buf.WriteString("/>\n")
+}
+
+func (c *svgCanvas) text(x, y float64, s string, f font) {
+	fmt.Fprintf(&c.buf, `<text x="%.2f" y="%.2f" font-size="%.2f" fill="%s"`, x, y, f.size*ptToMM, f.color.hex())
+	if f.bold {
+		c.buf.WriteString(` font-weight="bold"`)
+	}
+	if f.anchor != "" && f.anchor != "start" {
+		fmt.Fprintf(&c.buf, ` text-anchor="%s"`, f.anchor)
+	}
+	c.buf.WriteString(">")
+	xml.EscapeText(&c.buf, []byte(s))
+	c.buf.WriteString("</text>\n")
+}
+
+// clip оценивает ширину по средней ширине символа: метрик шрифта у SVG нет
+func (c *svgCanvas) clip(s string, f font, width float64) string {
+	return truncate(s, width, func(s string) float64 {
+		return float64(utf8.RuneCountInString(s)) * f.size * ptToMM * 0.55
+	})
+}
+
+// addPage не нужен: SVG — один лист, высота которого растёт вместе с сеткой
+func (c *svgCanvas) addPage() {}
+
+// WriteChartSVG выводит сетку одним изображением шириной A4 в миллиметрах.
+func WriteChartSVG(w io.Writer, chart *Chart, locale string) error {
+	locale = chartLocale(locale)
+
+	var cv svgCanvas
+	height := math.Ceil(drawChart(&cv, chart, locale, false) + margin)
+
+	_, err := fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="%.0fmm" height="%.0fmm" viewBox="0 0 %.0f %.0f" font-family="'DejaVu Sans Condensed', 'Arial Narrow', sans-serif">
+<title>`, pageWidth, height, pageWidth, height)
+	if err != nil {
+		return err
+	}
+	xml.EscapeText(w, []byte(chartTitle(chart, locale)))
+	if _, err := io.WriteString(w, "</title>\n<rect width=\"100%\" height=\"100%\" fill=\"#ffffff\"/>\n"); err != nil {
+		return err
+	}
+	if _, err := cv.buf.WriteTo(w); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "</svg>\n")
+	return err
+}
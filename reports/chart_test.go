@@ -0,0 +1,95 @@
+package reports
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"habit-tracker-api/models"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+const cyrillicHabit = "Зарядка"
+
+func testChart() *Chart {
+	period := Month(2026, time.June, time.UTC)
+	created := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	data := Data{
+		Habits: []models.Habit{{ID: 1, Name: cyrillicHabit, Frequency: "ежедневно", CreatedAt: created}},
+		Goals:  []models.Goal{{ID: 1, Title: "Марафон", TargetDate: time.Date(2026, 6, 20, 0, 0, 0, 0, time.UTC)}},
+		Tracks: []models.HabitTrack{{HabitID: 1, Date: time.Date(2026, 6, 3, 8, 0, 0, 0, time.UTC), Completed: true}},
+	}
+	return BuildChart(period, data, time.Date(2026, 6, 10, 12, 0, 0, 0, time.UTC))
+}
+
+// pdfStreams распаковывает сжатые потоки PDF: текст страниц лежит в них.
+func pdfStreams(t *testing.T, pdf []byte) []byte {
+	t.Helper()
+	var content []byte
+	for {
+		start := bytes.Index(pdf, []byte("stream\n"))
+		if start < 0 {
+			return content
+		}
+		pdf = pdf[start+len("stream\n"):]
+		end := bytes.Index(pdf, []byte("endstream"))
+		if end < 0 {
+			t.Fatal("unterminated PDF stream")
+		}
+		if r, err := zlib.NewReader(bytes.NewReader(pdf[:end])); err == nil {
+			data, _ := io.ReadAll(r)
+			content = append(content, data...)
+		}
+		pdf = pdf[end+len("endstream"):]
+	}
+}
+
+func TestChartPDFCyrillic(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteChartPDF(&out, testChart(), "ru"); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(out.Bytes(), []byte("%PDF-")) {
+		t.Fatalf("output is not a PDF: %q", out.Bytes()[:min(out.Len(), 16)])
+	}
+	if !bytes.Contains(out.Bytes(), []byte("/FontFile2")) {
+		t.Error("TrueType font is not embedded")
+	}
+
+	// Текст UTF-8 шрифтом пишется в UTF-16BE
+	var name []byte
+	for _, r := range utf16.Encode([]rune(cyrillicHabit)) {
+		name = append(name, byte(r>>8), byte(r))
+	}
+	if !bytes.Contains(pdfStreams(t, out.Bytes()), name) {
+		t.Errorf("PDF has no habit name %q", cyrillicHabit)
+	}
+}
+
+func TestChartSVGCyrillic(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteChartSVG(&out, testChart(), "ru"); err != nil {
+		t.Fatal(err)
+	}
+
+	var texts []string
+	decoder := xml.NewDecoder(bytes.NewReader(out.Bytes()))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %v", err)
+		}
+		if data, ok := token.(xml.CharData); ok {
+			texts = append(texts, string(data))
+		}
+	}
+	if text := strings.Join(texts, "\n"); !strings.Contains(text, cyrillicHabit) {
+		t.Errorf("SVG has no habit name %q:\n%s", cyrillicHabit, text)
+	}
+}
@@ -0,0 +1,104 @@
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	Weekly  = "weekly"
+	Monthly = "monthly"
+)
+
+var ErrInvalidPeriod = errors.New("invalid period")
+
+// Period — отчётный период [Start, End).
+type Period struct {
+	Kind  string    `json:"kind"`
+	Label string    `json:"label"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Week — ISO-неделя: с понедельника, первая неделя года содержит 4 января.
+func Week(year, week int, loc *time.Location) Period {
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	start := startOfWeek(jan4).AddDate(0, 0, 7*(week-1))
+	return Period{
+		Kind:  Weekly,
+		Label: fmt.Sprintf("%04d-W%02d", year, week),
+		Start: start,
+		End:   start.AddDate(0, 0, 7),
+	}
+}
+
+func Month(year int, month time.Month, loc *time.Location) Period {
+	start := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	return Period{
+		Kind:  Monthly,
+		Label: start.Format("2006-01"),
+		Start: start,
+		End:   start.AddDate(0, 1, 0),
+	}
+}
+
+// ParseWeek разбирает неделю в формате ISO 8601: "2026-W42".
+func ParseWeek(value string, loc *time.Location) (Period, error) {
+	var year, week int
+	if n, err := fmt.Sscanf(value, "%4d-W%2d", &year, &week); err != nil || n != 2 || len(value) != 8 {
+		return Period{}, fmt.Errorf("%w: week must look like 2026-W42", ErrInvalidPeriod)
+	}
+
+	period := Week(year, week, loc)
+	if y, w := period.Start.ISOWeek(); week < 1 || y != year || w != week {
+		return Period{}, fmt.Errorf("%w: %d has no week %d", ErrInvalidPeriod, year, week)
+	}
+	return period, nil
+}
+
+// ParseMonth разбирает месяц в формате "2026-10".
+func ParseMonth(value string, loc *time.Location) (Period, error) {
+	start, err := time.ParseInLocation("2006-01", value, loc)
+	if err != nil {
+		return Period{}, fmt.Errorf("%w: month must look like 2026-10", ErrInvalidPeriod)
+	}
+	return Month(start.Year(), start.Month(), loc), nil
+}
+
+func CurrentWeek(now time.Time) Period {
+	year, week := now.ISOWeek()
+	return Week(year, week, now.Location())
+}
+
+func CurrentMonth(now time.Time) Period {
+	return Month(now.Year(), now.Month(), now.Location())
+}
+
+// Previous — предыдущий период того же вида.
+func (p Period) Previous() Period {
+	if p.Kind == Monthly {
+		prev := p.Start.AddDate(0, -1, 0)
+		return Month(prev.Year(), prev.Month(), prev.Location())
+	}
+	year, week := p.Start.AddDate(0, 0, -7).ISOWeek()
+	return Week(year, week, p.Start.Location())
+}
+
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func startOfWeek(t time.Time) time.Time {
+	day := startOfDay(t)
+	weekday := int(day.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	return day.AddDate(0, 0, 1-weekday)
+}
@@ -0,0 +1,169 @@
+package reports
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	texttemplate "text/template"
+	"time"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+var ContentTypes = map[string]string{
+	FormatMarkdown: "text/markdown; charset=utf-8",
+	FormatHTML:     "text/html; charset=utf-8",
+}
+
+const defaultLocale = "ru"
+
+var labels = map[string]map[string]string{
+	"ru": {
+		"weekly":               "Итоги недели",
+		"monthly":              "Итоги месяца",
+		"summary":              "Сводка",
+		"completion":           "Выполнено по расписанию",
+		"previous":             "Прошлый период",
+		"goals_completed":      "Целей выполнено",
+		"streaks":              "Серии",
+		"streaks_gained":       "выросло",
+		"streaks_lost":         "прервано",
+		"habits":               "Привычки",
+		"habit":                "Привычка",
+		"done":                 "Выполнено",
+		"rate":                 "%",
+		"change":               "Изменение",
+		"streak":               "Серия",
+		"categories":           "Категории",
+		"category":             "Категория",
+		"best":                 "Лучшая категория",
+		"worst":                "Худшая категория",
+		"goals":                "Цели",
+		"goals_done":           "Выполнены",
+		"goals_overdue":        "Просрочены",
+		"goals_due":            "Срок в этом периоде",
+		"none":                 "нет",
+		"statistics":           "Общая статистика",
+		"overall_progress":     "Общий прогресс",
+		"unit_day":             "дн.",
+		"unit_week":            "нед.",
+		"unit_month":           "мес.",
+		"date_layout":          "02.01.2006",
+		"chart_title":          "Привычки",
+		"chart_total":          "Итого",
+		"chart_deadlines":      "Сроки целей",
+		"chart_done":           "выполнено",
+		"chart_inactive":       "привычки ещё не было",
+		"chart_weekend":        "выходной",
+		"chart_goal_completed": "выполнена",
+		"chart_goal_overdue":   "просрочена",
+	},
+	"en": {
+		"weekly":               "Weekly review",
+		"monthly":              "Monthly review",
+		"summary":              "Summary",
+		"completion":           "Done on schedule",
+		"previous":             "Previous period",
+		"goals_completed":      "Goals completed",
+		"streaks":              "Streaks",
+		"streaks_gained":       "gained",
+		"streaks_lost":         "lost",
+		"habits":               "Habits",
+		"habit":                "Habit",
+		"done":                 "Done",
+		"rate":                 "%",
+		"change":               "Change",
+		"streak":               "Streak",
+		"categories":           "Categories",
+		"category":             "Category",
+		"best":                 "Best category",
+		"worst":                "Worst category",
+		"goals":                "Goals",
+		"goals_done":           "Completed",
+		"goals_overdue":        "Overdue",
+		"goals_due":            "Due this period",
+		"none":                 "none",
+		"statistics":           "Overall statistics",
+		"overall_progress":     "Overall progress",
+		"unit_day":             "d",
+		"unit_week":            "wk",
+		"unit_month":           "mo",
+		"date_layout":          "Jan 2, 2006",
+		"chart_title":          "Habits",
+		"chart_total":          "Total",
+		"chart_deadlines":      "Goal deadlines",
+		"chart_done":           "done",
+		"chart_inactive":       "habit not started yet",
+		"chart_weekend":        "weekend",
+		"chart_goal_completed": "completed",
+		"chart_goal_overdue":   "overdue",
+	},
+}
+
+//go:embed templates/*
+var templateFS embed.FS
+
+type view struct {
+	*Report
+	L map[string]string
+}
+
+func funcs(l map[string]string) map[string]interface{} {
+	return map[string]interface{}{
+		"rate": func(rate *float64) string {
+			if rate == nil {
+				return "—"
+			}
+			return fmt.Sprintf("%.0f%%", *rate)
+		},
+		"change": func(diff *float64) string {
+			if diff == nil {
+				return "—"
+			}
+			return fmt.Sprintf("%+.1f", *diff)
+		},
+		"percent": func(value float64) string {
+			return fmt.Sprintf("%.0f%%", value)
+		},
+		"date": func(t time.Time) string {
+			return t.Format(l["date_layout"])
+		},
+		"lastDay": func(p Period) time.Time {
+			return p.End.AddDate(0, 0, -1)
+		},
+		"streak": func(s Streak) string {
+			return fmt.Sprintf("%d → %d %s", s.Start, s.End, l["unit_"+s.Unit])
+		},
+	}
+}
+
+// Render выводит отчёт в Markdown или HTML на языке locale (ru или en).
+func Render(w io.Writer, report *Report, format, locale string) error {
+	l, ok := labels[locale]
+	if !ok {
+		l = labels[defaultLocale]
+	}
+	data := view{Report: report, L: l}
+
+	switch format {
+	case FormatMarkdown:
+		tmpl, err := texttemplate.New("report.md").Funcs(funcs(l)).ParseFS(templateFS, "templates/report.md")
+		if err != nil {
+			return err
+		}
+		return tmpl.Execute(w, data)
+	case FormatHTML:
+		tmpl, err := htmltemplate.New("report.html").Funcs(funcs(l)).ParseFS(templateFS, "templates/report.html")
+		if err != nil {
+			return err
+		}
+		return tmpl.Execute(w, data)
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+}
@@ -0,0 +1,297 @@
+package reports
+
+import (
+	"habit-tracker-api/models"
+	"habit-tracker-api/storage"
+	"math"
+	"sort"
+	"time"
+)
+
+// Data — записи, по которым строится отчёт.
+type Data struct {
+	Habits []models.Habit
+	Goals  []models.Goal
+	Tracks []models.HabitTrack
+}
+
+// Completion — выполнение относительно расписания. Rate пуст, если за период ничего не ожидалось.
+type Completion struct {
+	Expected  int      `json:"expected"`
+	Completed int      `json:"completed"`
+	Rate      *float64 `json:"rate"`
+}
+
+type Streak struct {
+	Unit   string `json:"unit"`
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	Change int    `json:"change"`
+}
+
+type HabitReport struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Category  string `json:"category"`
+	Frequency string `json:"frequency"`
+	Completion
+	Previous Completion `json:"previous"`
+	// Change — изменение Rate к прошлому периоду в процентных пунктах
+	Change *float64 `json:"change"`
+	Streak Streak   `json:"streak"`
+}
+
+type GoalItem struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	TargetDate  time.Time  `json:"target_date"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+type GoalsReport struct {
+	Completed []GoalItem `json:"completed"`
+	Overdue   []GoalItem `json:"overdue"`
+	Due       []GoalItem `json:"due"`
+}
+
+type CategoryReport struct {
+	Category string `json:"category"`
+	Completion
+}
+
+type Summary struct {
+	Completion
+	Previous               Completion `json:"previous"`
+	Change                 *float64   `json:"change"`
+	GoalsCompleted         int        `json:"goals_completed"`
+	PreviousGoalsCompleted int        `json:"previous_goals_completed"`
+	StreaksGained          int        `json:"streaks_gained"`
+	StreaksLost            int        `json:"streaks_lost"`
+}
+
+type Report struct {
+	Period         Period              `json:"period"`
+	PreviousPeriod Period              `json:"previous_period"`
+	GeneratedAt    time.Time           `json:"generated_at"`
+	Summary        Summary             `json:"summary"`
+	Habits         []HabitReport       `json:"habits"`
+	Goals          GoalsReport         `json:"goals"`
+	Categories     []CategoryReport    `json:"categories"`
+	BestCategory   *CategoryReport     `json:"best_category"`
+	WorstCategory  *CategoryReport     `json:"worst_category"`
+	Statistics     *storage.Statistics `json:"statistics"`
+}
+
+var streakUnits = map[string]string{
+	"WEEKLY":  "week",
+	"MONTHLY": "month",
+}
+
+// Build строит отчёт за период на момент now. Текущий период оценивается
+// только по уже прошедшим дням.
+func Build(period Period, data Data, now time.Time) *Report {
+	loc := period.Start.Location()
+	now = now.In(loc)
+	previous := period.Previous()
+
+	report := &Report{
+		Period:         period,
+		PreviousPeriod: previous,
+		GeneratedAt:    now,
+		Habits:         []HabitReport{},
+		Goals:          GoalsReport{Completed: []GoalItem{}, Overdue: []GoalItem{}, Due: []GoalItem{}},
+		Categories:     []CategoryReport{},
+		Statistics:     storage.ComputeStatistics(data.Habits, data.Goals, data.Tracks, now),
+	}
+
+	// Дни с выполненными отметками по привычкам: несколько отметок за день считаются одной
+	doneDays := make(map[int]map[int64]time.Time)
+	for _, track := range data.Tracks {
+		if !track.Completed {
+			continue
+		}
+		day := startOfDay(track.Date.In(loc))
+		if doneDays[track.HabitID] == nil {
+			doneDays[track.HabitID] = make(map[int64]time.Time)
+		}
+		doneDays[track.HabitID][day.Unix()] = day
+	}
+
+	categories := make(map[string]*CategoryReport)
+	var total, totalPrevious Completion
+
+	habits := append([]models.Habit(nil), data.Habits...)
+	sort.Slice(habits, func(i, j int) bool { return habits[i].ID < habits[j].ID })
+
+	for _, habit := range habits {
+		if !habit.CreatedAt.Before(period.End) {
+			continue
+		}
+		sched := scheduleOf(habit.Frequency)
+		days := doneDays[habit.ID]
+
+		item := HabitReport{
+			ID:         habit.ID,
+			Name:       habit.Name,
+			Category:   habit.Category,
+			Frequency:  habit.Frequency,
+			Completion: completion(sched, habit, days, period, now),
+			Previous:   completion(sched, habit, days, previous, now),
+		}
+		item.Change = change(item.Rate, item.Previous.Rate)
+
+		units := make(map[int64]int)
+		for _, day := range days {
+			units[sched.unit(day).Unix()]++
+		}
+		end := minTime(period.End, startOfDay(now).AddDate(0, 0, 1))
+		item.Streak = Streak{
+			Unit:  streakUnit(sched),
+			Start: sched.streak(units, period.Start.Add(-time.Nanosecond), false),
+			End:   sched.streak(units, end.Add(-time.Nanosecond), period.End.After(now)),
+		}
+		item.Streak.Change = item.Streak.End - item.Streak.Start
+		if item.Streak.Change > 0 {
+			report.Summary.StreaksGained++
+		} else if item.Streak.Start > 0 && item.Streak.End < item.Streak.Start {
+			report.Summary.StreaksLost++
+		}
+
+		report.Habits = append(report.Habits, item)
+		add(&total, item.Completion)
+		add(&totalPrevious, item.Previous)
+
+		category := categories[habit.Category]
+		if category == nil {
+			category = &CategoryReport{Category: habit.Category}
+			categories[habit.Category] = category
+		}
+		add(&category.Completion, item.Completion)
+	}
+
+	report.Summary.Completion = withRate(total)
+	report.Summary.Previous = withRate(totalPrevious)
+	report.Summary.Change = change(report.Summary.Rate, report.Summary.Previous.Rate)
+
+	for _, category := range categories {
+		category.Completion = withRate(category.Completion)
+		report.Categories = append(report.Categories, *category)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		a, b := report.Categories[i], report.Categories[j]
+		if rateOf(a.Rate) != rateOf(b.Rate) {
+			return rateOf(a.Rate) > rateOf(b.Rate)
+		}
+		return a.Category < b.Category
+	})
+	var rated []CategoryReport
+	for _, category := range report.Categories {
+		if category.Rate != nil {
+			rated = append(rated, category)
+		}
+	}
+	if len(rated) > 0 {
+		report.BestCategory = &rated[0]
+	}
+	if len(rated) > 1 {
+		report.WorstCategory = &rated[len(rated)-1]
+	}
+
+	for _, goal := range data.Goals {
+		item := GoalItem{ID: goal.ID, Title: goal.Title, TargetDate: goal.TargetDate}
+		if goal.Completed {
+			item.CompletedAt = &goal.CompletedAt
+			if period.Contains(goal.CompletedAt) {
+				report.Goals.Completed = append(report.Goals.Completed, item)
+			}
+			if previous.Contains(goal.CompletedAt) {
+				report.Summary.PreviousGoalsCompleted++
+			}
+			continue
+		}
+		// Просроченные в периоде — срок прошёл внутри периода; остальные сроки периода ещё впереди
+		if period.Contains(goal.TargetDate) {
+			if goal.TargetDate.Before(now) {
+				report.Goals.Overdue = append(report.Goals.Overdue, item)
+			} else {
+				report.Goals.Due = append(report.Goals.Due, item)
+			}
+		}
+	}
+	report.Summary.GoalsCompleted = len(report.Goals.Completed)
+	for _, list := range [][]GoalItem{report.Goals.Completed, report.Goals.Overdue, report.Goals.Due} {
+		sort.Slice(list, func(i, j int) bool { return list[i].TargetDate.Before(list[j].TargetDate) })
+	}
+
+	return report
+}
+
+// completion считает ожидаемые и выполненные дни привычки в периоде. Дни до создания
+// привычки и ещё не наступившие дни не ожидаются.
+func completion(sched schedule, habit models.Habit, days map[int64]time.Time, period Period, now time.Time) Completion {
+	created := startOfDay(habit.CreatedAt.In(period.Start.Location()))
+	from := maxTime(period.Start, created)
+	to := minTime(period.End, startOfDay(now).AddDate(0, 0, 1))
+
+	result := Completion{Expected: sched.expected(from, to, created)}
+	for _, day := range days {
+		if period.Contains(day) {
+			result.Completed++
+		}
+	}
+	return withRate(result)
+}
+
+func withRate(c Completion) Completion {
+	c.Rate = nil
+	if c.Expected > 0 {
+		rate := float64(c.Completed) / float64(c.Expected) * 100
+		if rate > 100 {
+			rate = 100
+		}
+		rate = math.Round(rate*10) / 10
+		c.Rate = &rate
+	}
+	return c
+}
+
+func add(total *Completion, c Completion) {
+	total.Expected += c.Expected
+	total.Completed += c.Completed
+}
+
+func change(current, previous *float64) *float64 {
+	if current == nil || previous == nil {
+		return nil
+	}
+	diff := math.Round((*current-*previous)*10) / 10
+	return &diff
+}
+
+func rateOf(rate *float64) float64 {
+	if rate == nil {
+		return -1
+	}
+	return *rate
+}
+
+func streakUnit(sched schedule) string {
+	if unit, ok := streakUnits[sched.rule.Freq]; ok {
+		return unit
+	}
+	return "day"
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
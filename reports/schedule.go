@@ -0,0 +1,83 @@
+package reports
+
+import (
+	"habit-tracker-api/calendar"
+	"time"
+)
+
+// schedule — расписание привычки по её частоте: сколько выполнений ожидается
+// и из каких единиц (день, неделя, месяц) складывается серия.
+type schedule struct {
+	rule calendar.Rule
+}
+
+func scheduleOf(frequency string) schedule {
+	return schedule{rule: calendar.RuleOf(frequency)}
+}
+
+// expected — сколько выполнений ожидается в интервале дней [from, to).
+// origin — день создания привычки, от него отсчитываются интервалы «каждые N дней».
+func (s schedule) expected(from, to, origin time.Time) int {
+	return s.rule.Expected(from, to, origin)
+}
+
+// unit — начало единицы серии, в которую попадает t.
+func (s schedule) unit(t time.Time) time.Time {
+	switch s.rule.Freq {
+	case "WEEKLY":
+		return startOfWeek(t)
+	case "MONTHLY":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return startOfDay(t)
+	}
+}
+
+func (s schedule) previous(unit time.Time) time.Time {
+	switch s.rule.Freq {
+	case "WEEKLY":
+		return unit.AddDate(0, 0, -7)
+	case "MONTHLY":
+		return unit.AddDate(0, -1, 0)
+	default:
+		return unit.AddDate(0, 0, -1)
+	}
+}
+
+// required — сколько выполнений нужно в единице, чтобы серия продолжилась.
+func (s schedule) required() int {
+	if s.rule.Freq == "WEEKLY" && s.rule.Interval == 1 {
+		return max(len(s.rule.ByDay), 1)
+	}
+	return 1
+}
+
+// streak — длина серии, заканчивающейся в единице с моментом at; done — число выполнений
+// по началу единицы (Unix). Если единица ещё не закончилась (inProgress) и пока
+// не выполнена, серия считается с предыдущей. Для «каждые N дней» серия — дни
+// выполнения с перерывами не больше N дней.
+func (s schedule) streak(done map[int64]int, at time.Time, inProgress bool) int {
+	unit := s.unit(at)
+	if inProgress && done[unit.Unix()] < s.required() {
+		unit = s.previous(unit)
+	}
+
+	gap := 1
+	if s.rule.Freq == "DAILY" {
+		gap = s.rule.Interval
+	}
+
+	streak := 0
+	for misses := 0; misses < gap; unit = s.previous(unit) {
+		if done[unit.Unix()] >= s.required() {
+			streak++
+			misses = 0
+			continue
+		}
+		if streak == 0 && gap == 1 {
+			break
+		}
+		misses++
+	}
+	return streak
+}
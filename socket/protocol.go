@@ -0,0 +1,58 @@
+package socket
+
+import (
+	"encoding/json"
+	"habit-tracker-api/apperror"
+)
+
+// Сообщения клиента
+const (
+	TypeSubscribe = "subscribe"
+	TypeComplete  = "complete"
+	TypePing      = "ping"
+)
+
+// Сообщения сервера
+const (
+	TypeWelcome    = "welcome"
+	TypeSubscribed = "subscribed"
+	TypeChange     = "change"
+	TypeStatistics = "statistics"
+	TypeResync     = "resync"
+	TypeResult     = "result"
+	TypeError      = "error"
+	TypePong       = "pong"
+)
+
+// ClientMessage — команда клиента. Поле id возвращается в ответе, чтобы клиент сопоставил его с запросом.
+type ClientMessage struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+
+	// subscribe
+	UserID      int      `json:"user_id,omitempty"`
+	Entities    []string `json:"entities,omitempty"`
+	LastEventID int64    `json:"last_event_id,omitempty"`
+
+	// complete
+	Entity   string `json:"entity,omitempty"`
+	EntityID int    `json:"entity_id,omitempty"`
+	Version  int    `json:"version,omitempty"`
+}
+
+// ServerMessage — сообщение сервера: ответ на команду или уведомление об изменении.
+type ServerMessage struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+
+	EventID  int64           `json:"event_id,omitempty"`
+	Event    string          `json:"event,omitempty"`
+	Entity   string          `json:"entity,omitempty"`
+	EntityID int             `json:"entity_id,omitempty"`
+	Version  int             `json:"version,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	Error    *apperror.Error `json:"error,omitempty"`
+}
+
+// Entities — сущности, на изменения которых можно подписаться.
+var Entities = []string{"habit", "goal", "track", "statistics"}
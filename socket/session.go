@@ -0,0 +1,301 @@
+package socket
+
+import (
+	"encoding/json"
+	"errors"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/sse"
+	"strings"
+	"time"
+
+	"github.com/fasthttp/websocket"
+)
+
+const (
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 9 / 10
+	// maxMessageSize — ограничение размера команды клиента
+	maxMessageSize = 64 << 10
+	// outboxSize — сколько ответов на команды может ждать отправки. Когда очередь заполнена,
+	// чтение следующих команд приостанавливается, пока клиент не примет ответы.
+	outboxSize = 16
+)
+
+// CloseTooSlow — код закрытия для клиента, который не успевает читать уведомления (1013 Try Again Later).
+// Клиент должен переподключиться и подписаться с last_event_id.
+const CloseTooSlow = websocket.CloseTryAgainLater
+
+// Executor выполняет команду complete от имени пользователя и возвращает изменённую запись.
+type Executor func(userID int, msg ClientMessage) (interface{}, error)
+
+type subscription struct {
+	id          string
+	userID      int
+	entities    map[string]bool
+	lastEventID int64
+}
+
+// Session обслуживает одно соединение: читает команды и пишет ответы и уведомления.
+// Все записи в соединение идут из одной горутины.
+type Session struct {
+	conn    *websocket.Conn
+	hub     *sse.Hub
+	execute Executor
+	userID  int
+
+	outbox chan ServerMessage
+	subs   chan subscription
+	done   chan struct{}
+}
+
+func NewSession(conn *websocket.Conn, hub *sse.Hub, execute Executor, userID int) *Session {
+	return &Session{
+		conn:    conn,
+		hub:     hub,
+		execute: execute,
+		userID:  userID,
+		outbox:  make(chan ServerMessage, outboxSize),
+		subs:    make(chan subscription, 1),
+		done:    make(chan struct{}),
+	}
+}
+
+// Serve работает до закрытия соединения.
+func (s *Session) Serve() {
+	go s.read()
+	s.write()
+}
+
+func (s *Session) read() {
+	defer close(s.done)
+
+	s.conn.SetReadLimit(maxMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	userID := s.userID
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		s.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		var msg ClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.reply(ServerMessage{Type: TypeError, Error: apperror.BadRequest(apperror.CodeInvalidBody, "Invalid message")})
+			continue
+		}
+
+		switch msg.Type {
+		case TypePing:
+			s.reply(ServerMessage{Type: TypePong, ID: msg.ID})
+
+		case TypeSubscribe:
+			sub, err := newSubscription(msg, userID)
+			if err != nil {
+				s.reply(ServerMessage{Type: TypeError, ID: msg.ID, Error: err})
+				continue
+			}
+			userID = sub.userID
+			select {
+			case s.subs <- sub:
+			case <-s.done:
+				return
+			}
+
+		case TypeComplete:
+			data, err := s.execute(userID, msg)
+			if err != nil {
+				s.reply(ServerMessage{Type: TypeError, ID: msg.ID, Error: problem(err)})
+				continue
+			}
+			payload, _ := json.Marshal(data)
+			entityID, version := entityVersion(payload)
+			s.reply(ServerMessage{
+				Type:     TypeResult,
+				ID:       msg.ID,
+				Entity:   msg.Entity,
+				EntityID: entityID,
+				Version:  version,
+				Data:     payload,
+			})
+
+		default:
+			s.reply(ServerMessage{Type: TypeError, ID: msg.ID,
+				Error: apperror.BadRequest(apperror.CodeBadRequest, "Unknown message type "+msg.Type)})
+		}
+	}
+}
+
+// reply ставит ответ в очередь. Если очередь полна, чтение ждёт: это и есть обратное давление на клиента.
+func (s *Session) reply(msg ServerMessage) {
+	select {
+	case s.outbox <- msg:
+	case <-s.done:
+	}
+}
+
+func (s *Session) write() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		s.conn.Close()
+	}()
+
+	if err := s.send(ServerMessage{Type: TypeWelcome}); err != nil {
+		return
+	}
+
+	var client *sse.Client
+	var current subscription
+	defer func() {
+		if client != nil {
+			s.hub.Disconnect(client)
+		}
+	}()
+
+	for {
+		var messages <-chan sse.Message
+		if client != nil {
+			messages = client.Messages()
+		}
+
+		select {
+		case <-s.done:
+			return
+
+		case msg := <-s.outbox:
+			if err := s.send(msg); err != nil {
+				return
+			}
+
+		case sub := <-s.subs:
+			if client != nil {
+				s.hub.Disconnect(client)
+			}
+			var replay []sse.Message
+			client, replay = s.hub.Connect(sub.userID, sub.lastEventID)
+			current = sub
+
+			if err := s.send(ServerMessage{Type: TypeSubscribed, ID: sub.id}); err != nil {
+				return
+			}
+			for _, msg := range replay {
+				if err := s.notify(current, msg); err != nil {
+					return
+				}
+			}
+
+		case msg, ok := <-messages:
+			if !ok {
+				// Хаб отключил клиента из-за переполнения буфера
+				s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+				s.conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(CloseTooSlow, "client is too slow, resubscribe with last_event_id"))
+				client = nil
+				return
+			}
+			if err := s.notify(current, msg); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := s.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// notify переводит сообщение хаба в уведомление с версией записи.
+func (s *Session) notify(sub subscription, msg sse.Message) error {
+	switch msg.Event {
+	case sse.EventResync:
+		return s.send(ServerMessage{Type: TypeResync, Data: msg.Data})
+	case sse.EventStatistics:
+		if !sub.entities["statistics"] {
+			return nil
+		}
+		return s.send(ServerMessage{Type: TypeStatistics, EventID: msg.ID, Data: msg.Data})
+	}
+
+	entity, _, _ := strings.Cut(msg.Event, ".")
+	if !sub.entities[entity] {
+		return nil
+	}
+
+	entityID, version := entityVersion(msg.Data)
+	return s.send(ServerMessage{
+		Type:     TypeChange,
+		EventID:  msg.ID,
+		Event:    msg.Event,
+		Entity:   entity,
+		EntityID: entityID,
+		Version:  version,
+		Data:     msg.Data,
+	})
+}
+
+func (s *Session) send(msg ServerMessage) error {
+	s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return s.conn.WriteJSON(msg)
+}
+
+func newSubscription(msg ClientMessage, userID int) (subscription, *apperror.Error) {
+	if msg.UserID != 0 {
+		// Пользователь из заголовка при подключении не меняется
+		if userID != 0 && msg.UserID != userID {
+			return subscription{}, apperror.BadRequest(apperror.CodeInvalidUser, "user_id does not match X-User-ID")
+		}
+		userID = msg.UserID
+	}
+
+	entities := msg.Entities
+	if len(entities) == 0 {
+		entities = []string{"habit", "goal", "track"}
+	}
+
+	sub := subscription{id: msg.ID, userID: userID, entities: make(map[string]bool), lastEventID: msg.LastEventID}
+	for _, entity := range entities {
+		if !knownEntity(entity) {
+			return subscription{}, apperror.Validation(apperror.FieldError{
+				Field:   "entities",
+				Code:    "oneof",
+				Message: "entities must be one of: " + strings.Join(Entities, ", "),
+			})
+		}
+		sub.entities[entity] = true
+	}
+	return sub, nil
+}
+
+func knownEntity(entity string) bool {
+	for _, known := range Entities {
+		if entity == known {
+			return true
+		}
+	}
+	return false
+}
+
+func entityVersion(data []byte) (int, int) {
+	var record struct {
+		ID      int `json:"id"`
+		Version int `json:"version"`
+	}
+	json.Unmarshal(data, &record)
+	return record.ID, record.Version
+}
+
+func problem(err error) *apperror.Error {
+	var apiErr *apperror.Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return apperror.Internal(err, "Failed to execute command")
+}
@@ -0,0 +1,213 @@
+package socket
+
+import (
+	"errors"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/sse"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+)
+
+// call — команда, которую сессия передала исполнителю.
+type call struct {
+	userID int
+	msg    ClientMessage
+}
+
+type executor struct {
+	mu     sync.Mutex
+	calls  []call
+	result interface{}
+	err    error
+}
+
+func (e *executor) execute(userID int, msg ClientMessage) (interface{}, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls = append(e.calls, call{userID: userID, msg: msg})
+	return e.result, e.err
+}
+
+func (e *executor) lastCall(t *testing.T) call {
+	t.Helper()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.calls) == 0 {
+		t.Fatal("executor was not called")
+	}
+	return e.calls[len(e.calls)-1]
+}
+
+type client struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+// connect запускает сессию за тестовым HTTP-сервером и подключается к ней.
+// userID — пользователь, которого задал бы заголовок X-User-ID.
+func connect(t *testing.T, hub *sse.Hub, exec *executor, userID int) *client {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		NewSession(conn, hub, exec.execute, userID).Serve()
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	c := &client{t: t, conn: conn}
+	if msg := c.read(); msg.Type != TypeWelcome {
+		t.Fatalf("first message = %+v, want welcome", msg)
+	}
+	return c
+}
+
+func (c *client) send(msg ClientMessage) ServerMessage {
+	c.t.Helper()
+	if err := c.conn.WriteJSON(msg); err != nil {
+		c.t.Fatal(err)
+	}
+	return c.read()
+}
+
+func (c *client) read() ServerMessage {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg ServerMessage
+	if err := c.conn.ReadJSON(&msg); err != nil {
+		c.t.Fatal(err)
+	}
+	return msg
+}
+
+func expectError(t *testing.T, msg ServerMessage, id string, status int, code string) {
+	t.Helper()
+	if msg.Type != TypeError || msg.ID != id || msg.Error == nil {
+		t.Fatalf("got %+v, want error for %q", msg, id)
+	}
+	if msg.Error.Status != status || msg.Error.Code != code {
+		t.Errorf("error = %d %s, want %d %s", msg.Error.Status, msg.Error.Code, status, code)
+	}
+}
+
+func TestPing(t *testing.T) {
+	c := connect(t, sse.NewHub(10, nil), &executor{}, 0)
+
+	if msg := c.send(ClientMessage{Type: TypePing, ID: "p1"}); msg.Type != TypePong || msg.ID != "p1" {
+		t.Errorf("got %+v, want pong p1", msg)
+	}
+}
+
+func TestInvalidMessages(t *testing.T) {
+	c := connect(t, sse.NewHub(10, nil), &executor{}, 0)
+
+	if err := c.conn.WriteMessage(websocket.TextMessage, []byte("{not json")); err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, c.read(), "", http.StatusBadRequest, apperror.CodeInvalidBody)
+
+	expectError(t, c.send(ClientMessage{Type: "delete", ID: "d1"}), "d1", http.StatusBadRequest, apperror.CodeBadRequest)
+
+	// После ошибок сессия продолжает принимать команды
+	if msg := c.send(ClientMessage{Type: TypePing, ID: "p1"}); msg.Type != TypePong {
+		t.Errorf("got %+v after errors, want pong", msg)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	exec := &executor{result: map[string]interface{}{"id": 4, "version": 3, "name": "Бег"}}
+	c := connect(t, sse.NewHub(10, nil), exec, 2)
+
+	msg := c.send(ClientMessage{Type: TypeComplete, ID: "c1", Entity: "habit", EntityID: 4, Version: 2})
+	if msg.Type != TypeResult || msg.ID != "c1" || msg.Entity != "habit" || msg.EntityID != 4 || msg.Version != 3 {
+		t.Errorf("got %+v, want result for habit 4 version 3", msg)
+	}
+	if !strings.Contains(string(msg.Data), `"name":"Бег"`) {
+		t.Errorf("data = %s", msg.Data)
+	}
+
+	got := exec.lastCall(t)
+	if got.userID != 2 || got.msg.Entity != "habit" || got.msg.EntityID != 4 || got.msg.Version != 2 {
+		t.Errorf("executor called with %+v", got)
+	}
+}
+
+func TestCompleteErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"problem", apperror.New(http.StatusPreconditionFailed, apperror.CodePreconditionFailed, "Habit has been modified"),
+			http.StatusPreconditionFailed, apperror.CodePreconditionFailed},
+		{"not found", apperror.NotFound("Habit not found"), http.StatusNotFound, apperror.CodeNotFound},
+		{"internal", errors.New("disk full"), http.StatusInternalServerError, apperror.CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := connect(t, sse.NewHub(10, nil), &executor{err: tt.err}, 0)
+			msg := c.send(ClientMessage{Type: TypeComplete, ID: "c1", Entity: "habit", EntityID: 4})
+			expectError(t, msg, "c1", tt.status, tt.code)
+			if tt.code == apperror.CodeInternal && strings.Contains(msg.Error.Detail, "disk full") {
+				t.Errorf("internal error detail leaks the cause: %q", msg.Error.Detail)
+			}
+		})
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	hub := sse.NewHub(10, nil)
+	exec := &executor{result: map[string]int{"id": 1, "version": 2}}
+	c := connect(t, hub, exec, 0)
+
+	msg := c.send(ClientMessage{Type: TypeSubscribe, ID: "s1", UserID: 7, Entities: []string{"habit"}})
+	if msg.Type != TypeSubscribed || msg.ID != "s1" {
+		t.Fatalf("got %+v, want subscribed s1", msg)
+	}
+
+	// Команды после подписки выполняются от имени её пользователя
+	c.send(ClientMessage{Type: TypeComplete, ID: "c1", Entity: "goal", EntityID: 1})
+	if got := exec.lastCall(t); got.userID != 7 {
+		t.Errorf("command executed for user %d, want 7", got.userID)
+	}
+
+	// Приходят только изменения выбранных сущностей и видимых пользователю записей
+	hub.Publish("goal.completed", 7, map[string]int{"id": 1, "version": 2})
+	hub.Publish("habit.created", 8, map[string]int{"id": 2, "version": 1})
+	hub.Publish("habit.completed", 7, map[string]int{"id": 3, "version": 5})
+
+	msg = c.read()
+	if msg.Type != TypeChange || msg.Event != "habit.completed" || msg.Entity != "habit" ||
+		msg.EntityID != 3 || msg.Version != 5 || msg.EventID != 3 {
+		t.Errorf("got %+v, want change of habit 3", msg)
+	}
+}
+
+func TestSubscribeErrors(t *testing.T) {
+	c := connect(t, sse.NewHub(10, nil), &executor{}, 2)
+
+	expectError(t, c.send(ClientMessage{Type: TypeSubscribe, ID: "s1", UserID: 3}),
+		"s1", http.StatusBadRequest, apperror.CodeInvalidUser)
+	expectError(t, c.send(ClientMessage{Type: TypeSubscribe, ID: "s2", Entities: []string{"user"}}),
+		"s2", http.StatusBadRequest, apperror.CodeValidation)
+
+	msg := c.send(ClientMessage{Type: TypeSubscribe, ID: "s3", UserID: 2})
+	if msg.Type != TypeSubscribed || msg.ID != "s3" {
+		t.Errorf("got %+v, want subscribed s3", msg)
+	}
+}
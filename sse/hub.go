@@ -0,0 +1,259 @@
+package sse
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"habit-tracker-api/events"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// EventStatistics — снимок статистики пользователя после изменений
+	EventStatistics = "statistics"
+	// EventResync — клиент отстал больше, чем хранит буфер, и должен перечитать данные
+	EventResync = "resync"
+)
+
+// clientBuffer — сколько сообщений может ждать отправки одному клиенту.
+// Клиент, который не успевает читать, отключается и переподключается с Last-Event-ID.
+const clientBuffer = 64
+
+// statisticsInterval — снимок статистики рассылается не чаще: пачка изменений даёт
+// один снимок на пользователя, а не по снимку на каждое событие.
+const statisticsInterval = time.Second
+
+// Message — одно событие потока.
+type Message struct {
+	ID     int64
+	Event  string
+	Data   []byte
+	UserID int
+}
+
+// WriteTo пишет сообщение в формате text/event-stream.
+func (m Message) WriteTo(w *bufio.Writer) error {
+	if m.ID > 0 {
+		fmt.Fprintf(w, "id: %d\n", m.ID)
+	}
+	fmt.Fprintf(w, "event: %s\n", m.Event)
+	for _, line := range strings.Split(string(m.Data), "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	w.WriteByte('\n')
+	return w.Flush()
+}
+
+// Visible — видит ли сообщение клиент пользователя userID. Клиент без пользователя видит всё,
+// пользователь — свои записи и общие.
+func (m Message) Visible(userID int) bool {
+	return userID == 0 || m.UserID == 0 || m.UserID == userID
+}
+
+// Snapshot возвращает текущую статистику пользователя userID для сообщения statistics,
+// для клиента без пользователя (0) — по всем записям.
+type Snapshot func(userID int) (interface{}, error)
+
+// Hub раздаёт события шины подключённым клиентам и хранит последние сообщения
+// в кольцевом буфере для переподключения с Last-Event-ID.
+type Hub struct {
+	mu       sync.Mutex
+	nextID   int64
+	buffer   []Message
+	size     int
+	clients  map[*Client]struct{}
+	snapshot Snapshot
+	// stale — статистика устарела и её нужно разослать заново
+	stale    chan struct{}
+	interval time.Duration
+}
+
+func NewHub(size int, snapshot Snapshot) *Hub {
+	return &Hub{
+		nextID:   1,
+		size:     size,
+		clients:  make(map[*Client]struct{}),
+		snapshot: snapshot,
+		stale:    make(chan struct{}, 1),
+		interval: statisticsInterval,
+	}
+}
+
+// Subscribe подписывает хаб на события хранилища.
+func (h *Hub) Subscribe(bus *events.Bus) (unsubscribe func()) {
+	stop := make(chan struct{})
+	if h.snapshot != nil {
+		go h.refreshStatistics(stop)
+	}
+
+	unsubscribeBus := bus.Subscribe("sse", func(event events.Event) {
+		h.Publish(event.Name(), event.UserID(), event.Payload())
+		h.markStale()
+	})
+	return func() {
+		unsubscribeBus()
+		close(stop)
+	}
+}
+
+func (h *Hub) markStale() {
+	select {
+	case h.stale <- struct{}{}:
+	default:
+	}
+}
+
+// refreshStatistics рассылает статистику после изменений, но не чаще interval:
+// изменения, пришедшие за это время, попадают в следующий снимок.
+func (h *Hub) refreshStatistics(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-h.stale:
+		}
+
+		h.sendStatistics()
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(h.interval):
+		}
+	}
+}
+
+// sendStatistics считает статистику каждого подключённого пользователя и отправляет её
+// только его клиентам. Снимок не попадает в буфер и не получает ID: после
+// переподключения клиент получает свежий снимок, а не старые.
+func (h *Hub) sendStatistics() {
+	h.mu.Lock()
+	users := make(map[int]bool)
+	for client := range h.clients {
+		users[client.userID] = true
+	}
+	h.mu.Unlock()
+
+	for userID := range users {
+		stats, err := h.snapshot(userID)
+		if err != nil {
+			log.Printf("sse: failed to get statistics for user %d: %v", userID, err)
+			continue
+		}
+		data, err := json.Marshal(stats)
+		if err != nil {
+			log.Printf("sse: failed to encode statistics: %v", err)
+			continue
+		}
+
+		msg := Message{Event: EventStatistics, Data: data, UserID: userID}
+		h.mu.Lock()
+		for client := range h.clients {
+			if client.userID == userID {
+				h.send(client, msg)
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+// Publish присваивает сообщению следующий ID, сохраняет его в буфер и рассылает клиентам.
+func (h *Hub) Publish(event string, userID int, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("sse: failed to encode %s: %v", event, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	msg := Message{ID: h.nextID, Event: event, Data: data, UserID: userID}
+	h.nextID++
+
+	h.buffer = append(h.buffer, msg)
+	if len(h.buffer) > h.size {
+		h.buffer = h.buffer[len(h.buffer)-h.size:]
+	}
+
+	for client := range h.clients {
+		if msg.Visible(client.userID) {
+			h.send(client, msg)
+		}
+	}
+}
+
+func (h *Hub) send(client *Client, msg Message) {
+	select {
+	case client.messages <- msg:
+	default:
+		// Клиент не успевает читать: отключаем, он продолжит с Last-Event-ID
+		h.drop(client)
+	}
+}
+
+// Client — подключение к потоку событий.
+type Client struct {
+	userID   int
+	messages chan Message
+}
+
+// Messages закрывается, когда хаб отключает клиента.
+func (c *Client) Messages() <-chan Message {
+	return c.messages
+}
+
+// Connect регистрирует клиента и возвращает сообщения после lastID для повторной отправки.
+// Если часть сообщений после lastID уже вытеснена из буфера, первым идёт сообщение resync.
+func (h *Hub) Connect(userID int, lastID int64) (*Client, []Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	client := &Client{userID: userID, messages: make(chan Message, clientBuffer)}
+	h.clients[client] = struct{}{}
+	// Новый клиент получает текущую статистику, не дожидаясь изменений
+	h.markStale()
+
+	if lastID <= 0 {
+		return client, nil
+	}
+
+	var replay []Message
+	if lastID >= h.nextID {
+		// ID из будущего — сервер перезапускался, буфер начат заново
+		replay = append(replay, Message{Event: EventResync, Data: []byte(`{"reason":"unknown_event_id"}`)})
+	} else if len(h.buffer) > 0 && h.buffer[0].ID > lastID+1 {
+		replay = append(replay, Message{Event: EventResync, Data: []byte(`{"reason":"buffer_overflow"}`)})
+	}
+	for _, msg := range h.buffer {
+		if msg.ID > lastID && msg.Visible(userID) {
+			replay = append(replay, msg)
+		}
+	}
+	return client, replay
+}
+
+func (h *Hub) Disconnect(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.drop(client)
+}
+
+func (h *Hub) drop(client *Client) {
+	if _, ok := h.clients[client]; ok {
+		delete(h.clients, client)
+		close(client.messages)
+	}
+}
+
+// Clients — число подключённых клиентов.
+func (h *Hub) Clients() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.clients)
+}
@@ -0,0 +1,152 @@
+package sse
+
+import (
+	"encoding/json"
+	"habit-tracker-api/events"
+	"habit-tracker-api/models"
+	"sync"
+	"testing"
+	"time"
+)
+
+// counter — Snapshot, который возвращает userID и считает вызовы по пользователям.
+type counter struct {
+	mu    sync.Mutex
+	calls map[int]int
+}
+
+func (c *counter) snapshot(userID int) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls[userID]++
+	return map[string]int{"user_id": userID}, nil
+}
+
+func (c *counter) count(userID int) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[userID]
+}
+
+func newTestHub(t *testing.T, interval time.Duration) (*Hub, *events.Bus, *counter) {
+	stats := &counter{calls: make(map[int]int)}
+	hub := NewHub(100, stats.snapshot)
+	hub.interval = interval
+
+	bus := events.NewBus()
+	unsubscribe := hub.Subscribe(bus)
+	t.Cleanup(func() {
+		bus.Close()
+		unsubscribe()
+	})
+	return hub, bus, stats
+}
+
+// collect читает сообщения клиента в течение d.
+func collect(client *Client, d time.Duration) []Message {
+	var messages []Message
+	timeout := time.After(d)
+	for {
+		select {
+		case msg, ok := <-client.Messages():
+			if !ok {
+				return messages
+			}
+			messages = append(messages, msg)
+		case <-timeout:
+			return messages
+		}
+	}
+}
+
+func statisticsOf(t *testing.T, messages []Message) []int {
+	t.Helper()
+	var users []int
+	for _, msg := range messages {
+		if msg.Event != EventStatistics {
+			continue
+		}
+		if msg.ID != 0 {
+			t.Errorf("statistics message has id %d", msg.ID)
+		}
+		var data struct {
+			UserID int `json:"user_id"`
+		}
+		if err := json.Unmarshal(msg.Data, &data); err != nil {
+			t.Fatal(err)
+		}
+		users = append(users, data.UserID)
+	}
+	return users
+}
+
+func TestStatisticsPerUser(t *testing.T) {
+	hub, bus, _ := newTestHub(t, 10*time.Millisecond)
+
+	alice, _ := hub.Connect(1, 0)
+	bob, _ := hub.Connect(2, 0)
+	admin, _ := hub.Connect(0, 0)
+	bus.Publish(events.HabitCompleted{Habit: models.Habit{ID: 1, UserID: 1}})
+
+	for _, tt := range []struct {
+		name   string
+		client *Client
+		userID int
+	}{
+		{"alice", alice, 1},
+		{"bob", bob, 2},
+		{"admin", admin, 0},
+	} {
+		users := statisticsOf(t, collect(tt.client, 100*time.Millisecond))
+		if len(users) == 0 {
+			t.Errorf("%s got no statistics", tt.name)
+		}
+		for _, userID := range users {
+			if userID != tt.userID {
+				t.Errorf("%s got statistics of user %d", tt.name, userID)
+			}
+		}
+	}
+}
+
+func TestStatisticsNotBuffered(t *testing.T) {
+	hub, bus, _ := newTestHub(t, 10*time.Millisecond)
+
+	client, _ := hub.Connect(1, 0)
+	bus.Publish(events.HabitCreated{Habit: models.Habit{ID: 1}})
+	bus.Flush()
+	time.Sleep(50 * time.Millisecond)
+	hub.Disconnect(client)
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if len(hub.buffer) != 1 || hub.buffer[0].Event != "habit.created" {
+		t.Errorf("buffer = %+v, want only habit.created", hub.buffer)
+	}
+}
+
+func TestStatisticsCoalesced(t *testing.T) {
+	hub, bus, stats := newTestHub(t, 200*time.Millisecond)
+
+	client, _ := hub.Connect(1, 0)
+	time.Sleep(50 * time.Millisecond)
+	connected := stats.count(1)
+	if connected != 1 {
+		t.Fatalf("snapshot on connect called %d times, want 1", connected)
+	}
+
+	var batch []events.Event
+	for i := 1; i <= 20; i++ {
+		batch = append(batch, events.TrackCreated{Track: models.HabitTrack{ID: i}})
+	}
+	bus.Publish(batch...)
+	bus.Publish(batch...)
+
+	messages := collect(client, 500*time.Millisecond)
+	if n := len(statisticsOf(t, messages)); n < 2 || n > 3 {
+		t.Errorf("got %d statistics messages for 40 events, want 2 or 3", n)
+	}
+	if n := stats.count(1); n > 3 {
+		t.Errorf("snapshot called %d times", n)
+	}
+}
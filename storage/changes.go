@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"fmt"
+	"habit-tracker-api/models"
+	"sort"
+	"time"
+)
+
+// tombstoneTTL — сколько хранятся записи об удалении. Клиент, который не синхронизировался
+// дольше, получает полный снимок данных.
+const tombstoneTTL = 90 * 24 * time.Hour
+
+// ChangeInfo — когда запись менялась последний раз.
+type ChangeInfo struct {
+	Revision   int64     `json:"revision"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// Tombstone — запись об удалении для синхронизации.
+type Tombstone struct {
+	Entity    string    `json:"entity"`
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id,omitempty"`
+	Version   int       `json:"version"`
+	Revision  int64     `json:"revision"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// ChangeSet — изменения после ревизии since.
+type ChangeSet struct {
+	Revision int64
+	// Reset — since старше хранимых записей об удалении или новее хранилища,
+	// поэтому в наборе все записи, а клиент должен заменить свои данные целиком
+	Reset   bool
+	Habits  []models.Habit
+	Goals   []models.Goal
+	Tracks  []models.HabitTrack
+	Deleted []Tombstone
+	// Owners — владельцы привычек, к которым относятся отметки набора, прочитанные
+	// под той же блокировкой
+	Owners map[int]int
+}
+
+func changeKey(entity string, id int) string {
+	return fmt.Sprintf("%s:%d", entity, id)
+}
+
+// ChangesSince возвращает записи, изменённые после ревизии since, и удаления после неё.
+// since = 0 — полный снимок.
+func (s *JSONStorage) ChangesSince(since int64) (*ChangeSet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := &ChangeSet{Revision: s.Revision, Owners: make(map[int]int)}
+	if since > s.Revision || (since > 0 && since < s.PrunedRevision) {
+		set.Reset = true
+		since = 0
+	}
+
+	changed := func(entity string, id int) bool {
+		return since == 0 || s.Changes[changeKey(entity, id)].Revision > since
+	}
+
+	for id, habit := range s.Habits {
+		if changed("habit", id) {
+			set.Habits = append(set.Habits, habit)
+		}
+	}
+	for id, goal := range s.Goals {
+		if changed("goal", id) {
+			set.Goals = append(set.Goals, goal)
+		}
+	}
+	for id, track := range s.HabitTracks {
+		habit, exists := s.Habits[track.HabitID]
+		// Отметка удалённой привычки клиенту не нужна: привычка придёт в удалённых
+		if !exists || !changed("track", id) {
+			continue
+		}
+		set.Tracks = append(set.Tracks, track)
+		set.Owners[track.HabitID] = habit.UserID
+	}
+	if since > 0 {
+		for _, tombstone := range s.Tombstones {
+			if tombstone.Revision > since {
+				set.Deleted = append(set.Deleted, tombstone)
+			}
+		}
+	}
+
+	sort.Slice(set.Habits, func(i, j int) bool { return set.Habits[i].ID < set.Habits[j].ID })
+	sort.Slice(set.Goals, func(i, j int) bool { return set.Goals[i].ID < set.Goals[j].ID })
+	sort.Slice(set.Tracks, func(i, j int) bool { return set.Tracks[i].ID < set.Tracks[j].ID })
+	sort.Slice(set.Deleted, func(i, j int) bool { return set.Deleted[i].Revision < set.Deleted[j].Revision })
+
+	return set, nil
+}
+
+// touch отмечает изменение записи новой ревизией.
+func (tx *Tx) touch(entity string, id int) {
+	tx.s.Revision++
+	tx.s.Changes[changeKey(entity, id)] = ChangeInfo{Revision: tx.s.Revision, ModifiedAt: time.Now()}
+	delete(tx.s.Tombstones, changeKey(entity, id))
+}
+
+// bury заменяет сведения об изменении записи записью об удалении.
+func (tx *Tx) bury(entity string, id, userID, version int) {
+	now := time.Now()
+	tx.s.Revision++
+	key := changeKey(entity, id)
+	delete(tx.s.Changes, key)
+	tx.s.Tombstones[key] = Tombstone{
+		Entity:    entity,
+		ID:        id,
+		UserID:    userID,
+		Version:   version,
+		Revision:  tx.s.Revision,
+		DeletedAt: now,
+	}
+
+	for key, tombstone := range tx.s.Tombstones {
+		if now.Sub(tombstone.DeletedAt) > tombstoneTTL {
+			// Клиент с ревизией меньше удалённой записи мог её не видеть
+			if tombstone.Revision > tx.s.PrunedRevision {
+				tx.s.PrunedRevision = tombstone.Revision
+			}
+			delete(tx.s.Tombstones, key)
+		}
+	}
+}
+
+// LastChange возвращает версию записи и время её последнего изменения.
+// Для записей, созданных до появления журнала изменений, время нулевое.
+func (tx *Tx) LastChange(entity string, id int) (version int, modifiedAt time.Time, exists bool) {
+	switch entity {
+	case "habit":
+		habit, ok := tx.s.Habits[id]
+		version, exists = habit.Version, ok
+	case "goal":
+		goal, ok := tx.s.Goals[id]
+		version, exists = goal.Version, ok
+	case "track":
+		track, ok := tx.s.HabitTracks[id]
+		version, exists = track.Version, ok
+	}
+	return version, tx.s.Changes[changeKey(entity, id)].ModifiedAt, exists
+}
+
+// Tombstone возвращает запись об удалении, если запись удалена.
+func (tx *Tx) Tombstone(entity string, id int) (Tombstone, bool) {
+	tombstone, ok := tx.s.Tombstones[changeKey(entity, id)]
+	return tombstone, ok
+}
+
+// Revision — текущая ревизия внутри транзакции.
+func (tx *Tx) Revision() int64 {
+	return tx.s.Revision
+}
+
+func (tx *Tx) Habit(id int) *models.Habit {
+	if habit, ok := tx.s.Habits[id]; ok {
+		return &habit
+	}
+	return nil
+}
+
+func (tx *Tx) Goal(id int) *models.Goal {
+	if goal, ok := tx.s.Goals[id]; ok {
+		return &goal
+	}
+	return nil
+}
+
+func (tx *Tx) Track(id int) *models.HabitTrack {
+	if track, ok := tx.s.HabitTracks[id]; ok {
+		return &track
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"habit-tracker-api/models"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestStorage(t *testing.T) *JSONStorage {
+	t.Helper()
+	s, err := NewJSONStorage(filepath.Join(t.TempDir(), "habits.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestChangesSinceOwners(t *testing.T) {
+	s := newTestStorage(t)
+	if err := s.CreateUser(&models.User{Name: "Анна"}); err != nil {
+		t.Fatal(err)
+	}
+
+	own := models.Habit{UserID: 1, Name: "Бег", CreatedAt: time.Now()}
+	shared := models.Habit{Name: "Вода", CreatedAt: time.Now()}
+	deleted := models.Habit{UserID: 1, Name: "Чтение", CreatedAt: time.Now()}
+	for _, habit := range []*models.Habit{&own, &shared, &deleted} {
+		if err := s.CreateHabit(habit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, habit := range []models.Habit{own, shared, deleted} {
+		if err := s.CreateTrack(&models.HabitTrack{HabitID: habit.ID, Date: time.Now(), Completed: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before, err := s.ChangesSince(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DeleteHabit(deleted.ID, AnyVersion); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, since := range []int64{0, before.Revision - 1} {
+		set, err := s.ChangesSince(since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, track := range set.Tracks {
+			if track.HabitID == deleted.ID {
+				t.Errorf("since %d: got track %d of deleted habit", since, track.ID)
+			}
+			owner, ok := set.Owners[track.HabitID]
+			if !ok {
+				t.Errorf("since %d: no owner for habit %d", since, track.HabitID)
+			}
+			want := map[int]int{own.ID: 1, shared.ID: 0}[track.HabitID]
+			if owner != want {
+				t.Errorf("since %d: owner of habit %d = %d, want %d", since, track.HabitID, owner, want)
+			}
+		}
+	}
+
+	set, err := s.ChangesSince(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Tracks) != 2 {
+		t.Errorf("got %d tracks, want 2", len(set.Tracks))
+	}
+}
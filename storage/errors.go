@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrAlreadyCompleted = errors.New("already completed")
+	ErrNotCompleted     = errors.New("not completed")
+	ErrConflict         = errors.New("conflict")
+	ErrVersionMismatch  = errors.New("version mismatch")
+)
+
+// Вложенная запись не найдена у существующей цели. Совпадают и с ErrNotFound.
+var (
+	ErrMilestoneNotFound = fmt.Errorf("milestone %w", ErrNotFound)
+	ErrEntryNotFound     = fmt.Errorf("progress entry %w", ErrNotFound)
+)
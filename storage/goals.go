@@ -0,0 +1,261 @@
+package storage
+
+import (
+	"fmt"
+	"habit-tracker-api/forecast"
+	"habit-tracker-api/models"
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// refreshGoal пересчитывает производные поля цели: состояние вех, текущее значение,
+// прогресс и прогноз.
+func refreshGoal(goal *models.Goal, now time.Time) {
+	goal.Progress = nil
+	refreshMilestones(goal, now)
+	refreshValue(goal)
+}
+
+// achieved — цель достигнута: числовое значение дошло до цели или, с AutoComplete,
+// выполнены все вехи. Смотрит на поля, уже пересчитанные refreshGoal.
+func achieved(goal *models.Goal) bool {
+	if goal.TargetValue != nil && goal.CurrentValue != nil && reached(*goal.CurrentValue, goal.StartValue, *goal.TargetValue) {
+		return true
+	}
+	if !goal.AutoComplete || len(goal.Milestones) == 0 {
+		return false
+	}
+	for _, milestone := range goal.Milestones {
+		if !milestone.Completed {
+			return false
+		}
+	}
+	return true
+}
+
+// reached — значение дошло до цели с учётом направления: цель может и убывать, вес 90 → 80 кг.
+func reached(value, start, target float64) bool {
+	return (value-target)*direction(start, target) >= 0
+}
+
+// direction — знак движения к цели: +1, если значение должно расти.
+func direction(start, target float64) float64 {
+	return math.Copysign(1, target-start)
+}
+
+// completeGoal отмечает цель выполненной и записывает это в историю.
+func completeGoal(goal *models.Goal, now time.Time) {
+	goal.Completed = true
+	goal.CompletedAt = now
+	goal.History = recordStatus(goal.History, models.StatusCompleted, now)
+}
+
+// maxHistory — сколько последних изменений статуса хранится у записи
+const maxHistory = 100
+
+func recordStatus(history []models.StatusChange, status string, at time.Time) []models.StatusChange {
+	history = append(history, models.StatusChange{Status: status, At: at})
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	return history
+}
+
+// refreshMilestones отмечает вехи, достигшие числовой цели, и считает прогресс как
+// среднюю долю выполнения вех.
+func refreshMilestones(goal *models.Goal, now time.Time) {
+	if len(goal.Milestones) == 0 {
+		goal.Milestones = nil
+		return
+	}
+
+	var sum float64
+	for i := range goal.Milestones {
+		milestone := &goal.Milestones[i]
+		if milestone.TargetValue != nil && milestone.Value >= *milestone.TargetValue {
+			milestone.Completed = true
+		}
+
+		switch {
+		case !milestone.Completed:
+			milestone.CompletedAt = nil
+		case milestone.CompletedAt == nil:
+			at := now
+			milestone.CompletedAt = &at
+		}
+
+		switch {
+		case milestone.Completed:
+			sum++
+		case milestone.TargetValue != nil:
+			sum += math.Max(0, milestone.Value / *milestone.TargetValue)
+		}
+	}
+
+	goal.Progress = percent(sum / float64(len(goal.Milestones)))
+}
+
+// refreshValue считает текущее значение числовой цели по журналу, прогресс от StartValue
+// к TargetValue и прогноз, пока цель не достигнута.
+func refreshValue(goal *models.Goal) {
+	goal.CurrentValue = nil
+	goal.ProjectedDate = nil
+	if len(goal.Entries) == 0 {
+		goal.Entries = nil
+	}
+	if goal.TargetValue == nil {
+		return
+	}
+
+	// Сортируется копия: журнал может делить массив с записью в хранилище или с телом запроса
+	entries := slices.Clone(goal.Entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+	goal.Entries = entries
+
+	// Значение после каждой записи — точки для прогноза; первая точка — начальное значение
+	value := goal.StartValue
+	var points []point
+	if !goal.CreatedAt.IsZero() && (len(goal.Entries) == 0 || !goal.Entries[0].Date.Before(goal.CreatedAt)) {
+		points = append(points, point{goal.CreatedAt, value})
+	}
+	for _, entry := range goal.Entries {
+		switch {
+		case entry.Value != nil:
+			value = *entry.Value
+		case entry.Delta != nil:
+			value += *entry.Delta
+		}
+		points = append(points, point{entry.Date, value})
+	}
+
+	target, start := *goal.TargetValue, goal.StartValue
+	goal.CurrentValue = &value
+	goal.Progress = percent(math.Min(1, math.Max(0, (value-start)/(target-start))))
+
+	if !reached(value, start, target) {
+		goal.ProjectedDate = project(points, target, direction(start, target))
+	}
+}
+
+type point struct {
+	at    time.Time
+	value float64
+}
+
+// minProjectionSpan — по точкам, собранным быстрее, темп не определить
+const minProjectionSpan = 24 * time.Hour
+
+// maxProjection — дальше прогноз не строится: при таком темпе цель не достижима на практике
+const maxProjection = 100 * 365 * 24 * time.Hour
+
+// project находит методом наименьших квадратов прямую value(t) по точкам журнала и
+// момент, когда она пересечёт target. Пусто, если точек мало, они укладываются в сутки или значение не движется к цели.
+func project(points []point, target, direction float64) *time.Time {
+	if len(points) < 2 || points[len(points)-1].at.Sub(points[0].at) < minProjectionSpan {
+		return nil
+	}
+
+	origin := points[0].at
+	var sumX, sumY, sumXX, sumXY float64
+	for _, p := range points {
+		x := p.at.Sub(origin).Hours() / 24
+		sumX += x
+		sumY += p.value
+		sumXX += x * x
+		sumXY += x * p.value
+	}
+
+	n := float64(len(points))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return nil
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	if slope*direction <= 0 {
+		return nil
+	}
+	intercept := (sumY - slope*sumX) / n
+
+	days := (target - intercept) / slope
+	if days*24 > maxProjection.Hours() {
+		return nil
+	}
+	projected := origin.Add(time.Duration(days * 24 * float64(time.Hour)))
+	return &projected
+}
+
+// percent переводит долю в проценты с точностью до десятых.
+func percent(fraction float64) *float64 {
+	value := math.Round(fraction*1000) / 10
+	return &value
+}
+
+// GoalForecast оценивает цель на момент now по её связанным привычкам и их отметкам.
+func (s *JSONStorage) GoalForecast(goal models.Goal, now time.Time) *forecast.Forecast {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var habits []models.Habit
+	for _, id := range goal.HabitIDs {
+		if habit, ok := s.Habits[id]; ok {
+			habits = append(habits, habit)
+		}
+	}
+	var tracks []models.HabitTrack
+	if len(habits) > 0 {
+		for _, track := range s.HabitTracks {
+			tracks = append(tracks, track)
+		}
+	}
+
+	return forecast.Goal(goal, habits, tracks, now)
+}
+
+// CreateProgressEntry добавляет запись в журнал числовой цели.
+func (tx *Tx) CreateProgressEntry(goalID, version int, entry *models.ProgressEntry) (*models.Goal, error) {
+	return tx.UpdateGoal(goalID, version, func(goal *models.Goal) error {
+		if goal.TargetValue == nil {
+			return fmt.Errorf("goal %d has no target value: %w", goalID, ErrConflict)
+		}
+		entry.ID = tx.s.NextEntryID
+		tx.s.NextEntryID++
+		goal.Entries = append(goal.Entries, *entry)
+		return nil
+	})
+}
+
+func (tx *Tx) DeleteProgressEntry(goalID, entryID, version int) (*models.Goal, error) {
+	return tx.UpdateGoal(goalID, version, func(goal *models.Goal) error {
+		for i, entry := range goal.Entries {
+			if entry.ID == entryID {
+				goal.Entries = append(goal.Entries[:i:i], goal.Entries[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("goal %d has no progress entry %d: %w", goalID, entryID, ErrEntryNotFound)
+	})
+}
+
+func (s *JSONStorage) CreateProgressEntry(goalID, version int, entry *models.ProgressEntry) (*models.Goal, error) {
+	var goal *models.Goal
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		goal, err = tx.CreateProgressEntry(goalID, version, entry)
+		return err
+	})
+	return goal, err
+}
+
+func (s *JSONStorage) DeleteProgressEntry(goalID, entryID, version int) (*models.Goal, error) {
+	var goal *models.Goal
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		goal, err = tx.DeleteProgressEntry(goalID, entryID, version)
+		return err
+	})
+	return goal, err
+}
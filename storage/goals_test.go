@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"habit-tracker-api/models"
+	"testing"
+	"time"
+)
+
+func TestProject(t *testing.T) {
+	day := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	at := func(days float64) time.Time {
+		return day.Add(time.Duration(days * 24 * float64(time.Hour)))
+	}
+
+	tests := []struct {
+		name      string
+		points    []point
+		target    float64
+		direction float64
+		want      *time.Time
+	}{
+		{"no points", nil, 10, 1, nil},
+		{"one point", []point{{day, 0}}, 10, 1, nil},
+		{"identical dates", []point{{day, 0}, {day, 2}, {day, 4}}, 10, 1, nil},
+		{"within a day", []point{{day, 0}, {at(0.5), 5}}, 10, 1, nil},
+		{"steady growth", []point{{day, 0}, {at(1), 1}, {at(2), 2}}, 10, 1, ptr(at(10))},
+		{"steady decline", []point{{day, 80}, {at(2), 78}}, 70, -1, ptr(at(10))},
+		{"away from target", []point{{day, 5}, {at(2), 3}}, 10, 1, nil},
+		{"flat", []point{{day, 5}, {at(2), 5}}, 10, 1, nil},
+		{"too slow", []point{{day, 0}, {at(365), 0.001}}, 1000, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := project(tt.points, tt.target, tt.direction)
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("project = %v, want none", got)
+			case tt.want != nil && got == nil:
+				t.Errorf("project = none, want %v", tt.want)
+			case tt.want != nil && got.Sub(*tt.want).Abs() > time.Minute:
+				t.Errorf("project = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestRefreshValueKeepsEntriesOrder(t *testing.T) {
+	created := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	target, one, five := 10.0, 1.0, 5.0
+	entries := []models.ProgressEntry{
+		{ID: 1, Date: created.AddDate(0, 0, 4), Value: &five},
+		{ID: 2, Date: created.AddDate(0, 0, 2), Delta: &one},
+	}
+	goal := models.Goal{CreatedAt: created, TargetValue: &target, Entries: entries}
+
+	refreshValue(&goal)
+
+	if entries[0].ID != 1 || entries[1].ID != 2 {
+		t.Errorf("caller's entries were reordered: %+v", entries)
+	}
+	if goal.Entries[0].ID != 2 || goal.Entries[1].ID != 1 {
+		t.Errorf("goal entries are not sorted by date: %+v", goal.Entries)
+	}
+	if goal.CurrentValue == nil || *goal.CurrentValue != 5 {
+		t.Errorf("current value = %v, want 5", goal.CurrentValue)
+	}
+}
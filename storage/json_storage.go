@@ -1,42 +1,95 @@
 package storage
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"fmt"
+	"habit-tracker-api/events"
+	"habit-tracker-api/forecast"
 	"habit-tracker-api/models"
+	"log"
 	"os"
 	"sync"
 	"time"
 )
 
 type JSONStorage struct {
-	filename    string
-	mu          sync.RWMutex
-	Habits      map[int]models.Habit      `json:"habits"`
-	Goals       map[int]models.Goal       `json:"goals"`
-	HabitTracks map[int]models.HabitTrack `json:"habit_tracks"`
-	NextHabitID int                       `json:"next_habit_id"`
-	NextGoalID  int                       `json:"next_goal_id"`
-	NextTrackID int                       `json:"next_track_id"`
+	filename        string
+	mu              sync.RWMutex
+	Habits          map[int]models.Habit      `json:"habits"`
+	Goals           map[int]models.Goal       `json:"goals"`
+	HabitTracks     map[int]models.HabitTrack `json:"habit_tracks"`
+	Users           map[int]models.User       `json:"users"`
+	Webhooks        map[int]models.Webhook    `json:"webhooks"`
+	NextHabitID     int                       `json:"next_habit_id"`
+	NextGoalID      int                       `json:"next_goal_id"`
+	NextTrackID     int                       `json:"next_track_id"`
+	NextUserID      int                       `json:"next_user_id"`
+	NextHookID      int                       `json:"next_webhook_id"`
+	NextMilestoneID int                       `json:"next_milestone_id"`
+	NextEntryID     int                       `json:"next_progress_entry_id"`
+
+	// Журнал изменений для синхронизации: ревизия растёт при каждом изменении
+	Revision       int64                 `json:"revision"`
+	PrunedRevision int64                 `json:"pruned_revision"`
+	Changes        map[string]ChangeInfo `json:"changes"`
+	Tombstones     map[string]Tombstone  `json:"tombstones"`
+
+	// persisted — последнее сохранённое состояние, к нему откатывается Batch
+	persisted []byte
+	bus       *events.Bus
+	hooks     []namedHook
 }
 
 func NewJSONStorage(filename string) (*JSONStorage, error) {
 	storage := &JSONStorage{
-		filename:    filename,
-		Habits:      make(map[int]models.Habit),
-		Goals:       make(map[int]models.Goal),
-		HabitTracks: make(map[int]models.HabitTrack),
-		NextHabitID: 1,
-		NextGoalID:  1,
-		NextTrackID: 1,
+		filename:        filename,
+		Habits:          make(map[int]models.Habit),
+		Goals:           make(map[int]models.Goal),
+		HabitTracks:     make(map[int]models.HabitTrack),
+		Users:           make(map[int]models.User),
+		Webhooks:        make(map[int]models.Webhook),
+		Changes:         make(map[string]ChangeInfo),
+		Tombstones:      make(map[string]Tombstone),
+		NextHabitID:     1,
+		NextGoalID:      1,
+		NextTrackID:     1,
+		NextUserID:      1,
+		NextHookID:      1,
+		NextMilestoneID: 1,
+		NextEntryID:     1,
 	}
 
 	if err := storage.load(); err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
 
+	persisted, err := json.MarshalIndent(storage, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	storage.persisted = persisted
+
 	return storage, nil
 }
 
+// SetEventBus включает публикацию событий об изменениях. Вызывается до начала работы.
+func (s *JSONStorage) SetEventBus(bus *events.Bus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bus = bus
+}
+
+// OnCommit добавляет хук, который выполняется в каждой транзакции для каждого её
+// события. Вызывается до начала работы.
+func (s *JSONStorage) OnCommit(name string, hook TxHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks = append(s.hooks, namedHook{name: name, hook: hook})
+}
+
 func (s *JSONStorage) load() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -46,106 +99,212 @@ func (s *JSONStorage) load() error {
 		return err
 	}
 
-	return json.Unmarshal(data, s)
-}
-
-func (s *JSONStorage) save() error {
-	data, err := json.MarshalIndent(s, "", "  ")
-	if err != nil {
+	if err := json.Unmarshal(data, s); err != nil {
 		return err
 	}
 
-	return os.WriteFile(s.filename, data, 0644)
+	// Записи, сохранённые до появления версий, считаем первой версией
+	for id, habit := range s.Habits {
+		if habit.Version == 0 {
+			habit.Version = 1
+			s.Habits[id] = habit
+		}
+	}
+	for id, goal := range s.Goals {
+		if goal.Version == 0 {
+			goal.Version = 1
+			s.Goals[id] = goal
+		}
+	}
+	for id, track := range s.HabitTracks {
+		if track.Version == 0 {
+			track.Version = 1
+			s.HabitTracks[id] = track
+		}
+	}
+	for id, user := range s.Users {
+		if user.Version == 0 {
+			user.Version = 1
+			s.Users[id] = user
+		}
+	}
+	for id, webhook := range s.Webhooks {
+		if webhook.Version == 0 {
+			webhook.Version = 1
+			s.Webhooks[id] = webhook
+		}
+	}
+
+	return nil
 }
 
-func (s *JSONStorage) GetAllHabits() ([]models.Habit, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// AnyVersion отключает проверку версии в изменяющих операциях.
+const AnyVersion = 0
 
-	var habits []models.Habit
-	for _, habit := range s.Habits {
-		habits = append(habits, habit)
+func checkVersion(entity string, id, current, expected int) error {
+	if expected != AnyVersion && current != expected {
+		return fmt.Errorf("%s %d has version %d, expected %d: %w", entity, id, current, expected, ErrVersionMismatch)
 	}
-
-	return habits, nil
+	return nil
 }
 
-func (s *JSONStorage) GetHabitByID(id int) (*models.Habit, error) {
+// Version возвращает текущую версию записи entity/id.
+func (s *JSONStorage) Version(entity string, id int) (int, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	habit, exists := s.Habits[id]
-	if !exists {
-		return nil, nil
+	switch entity {
+	case "habit":
+		habit, ok := s.Habits[id]
+		return habit.Version, ok
+	case "goal":
+		goal, ok := s.Goals[id]
+		return goal.Version, ok
+	case "track":
+		track, ok := s.HabitTracks[id]
+		return track.Version, ok
+	case "user":
+		user, ok := s.Users[id]
+		return user.Version, ok
+	case "webhook":
+		webhook, ok := s.Webhooks[id]
+		return webhook.Version, ok
 	}
-
-	return &habit, nil
+	return 0, false
 }
 
-func (s *JSONStorage) CreateHabit(habit *models.Habit) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *JSONStorage) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(s.filename, data, 0644); err != nil {
+		return err
+	}
 
-	habit.ID = s.NextHabitID
-	s.NextHabitID++
-	s.Habits[habit.ID] = *habit
+	s.persisted = data
+	return nil
+}
 
-	return s.save()
+// rollback возвращает состояние к последнему успешному сохранению.
+func (s *JSONStorage) rollback() {
+	s.Habits = make(map[int]models.Habit)
+	s.Goals = make(map[int]models.Goal)
+	s.HabitTracks = make(map[int]models.HabitTrack)
+	s.Users = make(map[int]models.User)
+	s.Webhooks = make(map[int]models.Webhook)
+	s.Changes = make(map[string]ChangeInfo)
+	s.Tombstones = make(map[string]Tombstone)
+
+	if err := json.Unmarshal(s.persisted, s); err != nil {
+		log.Printf("storage: failed to roll back to persisted state: %v", err)
+	}
 }
 
-func (s *JSONStorage) UpdateHabit(id int, habit *models.Habit) error {
+// Batch выполняет все изменения под одной блокировкой и сохраняет файл один раз.
+// Если fn или сохранение завершились ошибкой, все изменения откатываются.
+// События публикуются только после успешного сохранения, в порядке изменений.
+func (s *JSONStorage) Batch(fn func(tx *Tx) error) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.Habits[id]; !exists {
+	tx := &Tx{s: s}
+	err := fn(tx)
+	if err == nil {
+		err = tx.runHooks()
+	}
+	if err != nil {
+		if tx.changed {
+			s.rollback()
+		}
+		return err
+	}
+
+	if !tx.changed {
 		return nil
 	}
 
-	habit.ID = id
-	s.Habits[id] = *habit
+	if err := s.save(); err != nil {
+		s.rollback()
+		return err
+	}
 
-	return s.save()
+	if s.bus != nil {
+		s.bus.Publish(tx.pending...)
+	}
+
+	return nil
 }
 
-func (s *JSONStorage) DeleteHabit(id int) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *JSONStorage) GetAllHabits() ([]models.Habit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	if _, exists := s.Habits[id]; !exists {
-		return nil
+	var habits []models.Habit
+	for _, habit := range s.Habits {
+		habits = append(habits, habit)
 	}
 
-	delete(s.Habits, id)
-	return s.save()
+	return habits, nil
 }
 
-func (s *JSONStorage) CompleteHabit(id int) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *JSONStorage) GetHabitByID(id int) (*models.Habit, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
 	habit, exists := s.Habits[id]
 	if !exists {
-		return nil
+		return nil, fmt.Errorf("habit %d: %w", id, ErrNotFound)
 	}
 
-	if habit.Completed {
-		return nil
-	}
+	return &habit, nil
+}
 
-	habit.Completed = true
-	s.Habits[id] = habit
+func (s *JSONStorage) CreateHabit(habit *models.Habit) error {
+	return s.Batch(func(tx *Tx) error {
+		return tx.CreateHabit(habit)
+	})
+}
 
-	track := models.HabitTrack{
-		ID:        s.NextTrackID,
-		HabitID:   id,
-		Date:      time.Now(),
-		Completed: true,
-		Notes:     "Marked as completed via API",
-	}
-	s.NextTrackID++
-	s.HabitTracks[track.ID] = track
+func (s *JSONStorage) UpdateHabit(id, version int, update func(habit *models.Habit) error) (*models.Habit, error) {
+	var habit *models.Habit
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		habit, err = tx.UpdateHabit(id, version, update)
+		return err
+	})
+	return habit, err
+}
+
+func (s *JSONStorage) DeleteHabit(id, version int) (*models.Habit, error) {
+	var habit *models.Habit
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		habit, err = tx.DeleteHabit(id, version)
+		return err
+	})
+	return habit, err
+}
 
-	return s.save()
+func (s *JSONStorage) CompleteHabit(id, version int) (*models.Habit, error) {
+	var habit *models.Habit
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		habit, err = tx.CompleteHabit(id, version)
+		return err
+	})
+	return habit, err
+}
+
+func (s *JSONStorage) ReopenHabit(id, version int) (*models.Habit, error) {
+	var habit *models.Habit
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		habit, err = tx.ReopenHabit(id, version)
+		return err
+	})
+	return habit, err
 }
 
 func (s *JSONStorage) GetAllGoals() ([]models.Goal, error) {
@@ -166,128 +325,229 @@ func (s *JSONStorage) GetGoalByID(id int) (*models.Goal, error) {
 
 	goal, exists := s.Goals[id]
 	if !exists {
-		return nil, nil
+		return nil, fmt.Errorf("goal %d: %w", id, ErrNotFound)
 	}
 
 	return &goal, nil
 }
 
 func (s *JSONStorage) CreateGoal(goal *models.Goal) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	goal.ID = s.NextGoalID
-	s.NextGoalID++
-	s.Goals[goal.ID] = *goal
-
-	return s.save()
+	return s.Batch(func(tx *Tx) error {
+		return tx.CreateGoal(goal)
+	})
 }
 
-func (s *JSONStorage) UpdateGoal(id int, goal *models.Goal) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *JSONStorage) UpdateGoal(id, version int, update func(goal *models.Goal) error) (*models.Goal, error) {
+	var goal *models.Goal
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		goal, err = tx.UpdateGoal(id, version, update)
+		return err
+	})
+	return goal, err
+}
 
-	if _, exists := s.Goals[id]; !exists {
-		return nil
-	}
+func (s *JSONStorage) DeleteGoal(id, version int) (*models.Goal, error) {
+	var goal *models.Goal
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		goal, err = tx.DeleteGoal(id, version)
+		return err
+	})
+	return goal, err
+}
 
-	goal.ID = id
-	s.Goals[id] = *goal
+func (s *JSONStorage) CompleteGoal(id, version int) (*models.Goal, error) {
+	var goal *models.Goal
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		goal, err = tx.CompleteGoal(id, version)
+		return err
+	})
+	return goal, err
+}
 
-	return s.save()
+func (s *JSONStorage) ReopenGoal(id, version int) (*models.Goal, error) {
+	var goal *models.Goal
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		goal, err = tx.ReopenGoal(id, version)
+		return err
+	})
+	return goal, err
 }
 
-func (s *JSONStorage) DeleteGoal(id int) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *JSONStorage) GetAllTracks() ([]models.HabitTrack, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	if _, exists := s.Goals[id]; !exists {
-		return nil
+	var tracks []models.HabitTrack
+	for _, track := range s.HabitTracks {
+		tracks = append(tracks, track)
 	}
 
-	delete(s.Goals, id)
-	return s.save()
+	return tracks, nil
 }
 
-func (s *JSONStorage) CompleteGoal(id int) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *JSONStorage) GetTrackByID(id int) (*models.HabitTrack, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	goal, exists := s.Goals[id]
+	track, exists := s.HabitTracks[id]
 	if !exists {
-		return nil
+		return nil, fmt.Errorf("track %d: %w", id, ErrNotFound)
 	}
 
-	if goal.Completed {
-		return nil
+	return &track, nil
+}
+
+// TracksPage возвращает до limit записей с ID больше afterID в порядке возрастания ID.
+// Нужен для потоковой выгрузки истории без копирования всех записей сразу.
+func (s *JSONStorage) TracksPage(afterID, limit int) ([]models.HabitTrack, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tracks []models.HabitTrack
+	for id := afterID + 1; id < s.NextTrackID && len(tracks) < limit; id++ {
+		if track, exists := s.HabitTracks[id]; exists {
+			tracks = append(tracks, track)
+		}
 	}
 
-	goal.Completed = true
-	goal.CompletedAt = time.Now()
-	s.Goals[id] = goal
+	return tracks, nil
+}
 
-	return s.save()
+func (s *JSONStorage) CreateTrack(track *models.HabitTrack) error {
+	return s.Batch(func(tx *Tx) error {
+		return tx.CreateTrack(track)
+	})
 }
 
-func (s *JSONStorage) GetAllTracks() ([]models.HabitTrack, error) {
+func (s *JSONStorage) UpdateTrack(id, version int, update func(track *models.HabitTrack) error) (*models.HabitTrack, error) {
+	var track *models.HabitTrack
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		track, err = tx.UpdateTrack(id, version, update)
+		return err
+	})
+	return track, err
+}
+
+func (s *JSONStorage) DeleteTrack(id, version int) (*models.HabitTrack, error) {
+	var track *models.HabitTrack
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		track, err = tx.DeleteTrack(id, version)
+		return err
+	})
+	return track, err
+}
+
+func (s *JSONStorage) GetAllUsers() ([]models.User, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var tracks []models.HabitTrack
-	for _, track := range s.HabitTracks {
-		tracks = append(tracks, track)
+	var users []models.User
+	for _, user := range s.Users {
+		users = append(users, user)
 	}
 
-	return tracks, nil
+	return users, nil
 }
 
-func (s *JSONStorage) GetTrackByID(id int) (*models.HabitTrack, error) {
+func (s *JSONStorage) GetUserByID(id int) (*models.User, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	track, exists := s.HabitTracks[id]
+	user, exists := s.Users[id]
 	if !exists {
-		return nil, nil
+		return nil, fmt.Errorf("user %d: %w", id, ErrNotFound)
 	}
 
-	return &track, nil
+	return &user, nil
 }
 
-func (s *JSONStorage) CreateTrack(track *models.HabitTrack) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// GetUserByFeedToken ищет пользователя по токену календарной ленты.
+// Токены сравниваются за постоянное время, чтобы их нельзя было подобрать по времени ответа.
+func (s *JSONStorage) GetUserByFeedToken(token string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	track.ID = s.NextTrackID
-	s.NextTrackID++
-	s.HabitTracks[track.ID] = *track
+	if token != "" {
+		for _, user := range s.Users {
+			if subtle.ConstantTimeCompare([]byte(user.FeedToken), []byte(token)) == 1 {
+				return &user, nil
+			}
+		}
+	}
 
-	return s.save()
+	return nil, fmt.Errorf("user with feed token: %w", ErrNotFound)
 }
 
-func (s *JSONStorage) UpdateTrack(id int, track *models.HabitTrack) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *JSONStorage) CreateUser(user *models.User) error {
+	return s.Batch(func(tx *Tx) error {
+		return tx.CreateUser(user)
+	})
+}
 
-	if _, exists := s.HabitTracks[id]; !exists {
-		return nil
-	}
+func (s *JSONStorage) UpdateUser(id, version int, update func(user *models.User) error) (*models.User, error) {
+	var user *models.User
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		user, err = tx.UpdateUser(id, version, update)
+		return err
+	})
+	return user, err
+}
+
+func (s *JSONStorage) GetAllWebhooks() ([]models.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	track.ID = id
-	s.HabitTracks[id] = *track
+	var webhooks []models.Webhook
+	for _, webhook := range s.Webhooks {
+		webhooks = append(webhooks, webhook)
+	}
 
-	return s.save()
+	return webhooks, nil
 }
 
-func (s *JSONStorage) DeleteTrack(id int) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *JSONStorage) GetWebhookByID(id int) (*models.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	if _, exists := s.HabitTracks[id]; !exists {
-		return nil
+	webhook, exists := s.Webhooks[id]
+	if !exists {
+		return nil, fmt.Errorf("webhook %d: %w", id, ErrNotFound)
 	}
 
-	delete(s.HabitTracks, id)
-	return s.save()
+	return &webhook, nil
+}
+
+func (s *JSONStorage) CreateWebhook(webhook *models.Webhook) error {
+	return s.Batch(func(tx *Tx) error {
+		return tx.CreateWebhook(webhook)
+	})
+}
+
+func (s *JSONStorage) UpdateWebhook(id, version int, update func(webhook *models.Webhook) error) (*models.Webhook, error) {
+	var webhook *models.Webhook
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		webhook, err = tx.UpdateWebhook(id, version, update)
+		return err
+	})
+	return webhook, err
+}
+
+func (s *JSONStorage) DeleteWebhook(id, version int) (*models.Webhook, error) {
+	var webhook *models.Webhook
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		webhook, err = tx.DeleteWebhook(id, version)
+		return err
+	})
+	return webhook, err
 }
 
 type Statistics struct {
@@ -303,6 +563,7 @@ type Statistics struct {
 	CompletedItems      int                      `json:"completed_items"`
 	OverallProgress     float64                  `json:"overall_progress"`
 	Categories          map[string]CategoryStats `json:"categories"`
+	GoalForecast        forecast.Summary         `json:"goal_forecast"`
 }
 
 type CategoryStats struct {
@@ -312,17 +573,50 @@ type CategoryStats struct {
 }
 
 func (s *JSONStorage) GetStatistics() (*Statistics, error) {
+	return s.statistics(func(ownerID int) bool { return true })
+}
+
+// GetUserStatistics считает статистику по записям пользователя и общим записям без владельца.
+func (s *JSONStorage) GetUserStatistics(userID int) (*Statistics, error) {
+	return s.statistics(func(ownerID int) bool { return ownerID == 0 || ownerID == userID })
+}
+
+func (s *JSONStorage) statistics(visible func(ownerID int) bool) (*Statistics, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	var habits []models.Habit
+	for _, habit := range s.Habits {
+		if visible(habit.UserID) {
+			habits = append(habits, habit)
+		}
+	}
+	var goals []models.Goal
+	for _, goal := range s.Goals {
+		if visible(goal.UserID) {
+			goals = append(goals, goal)
+		}
+	}
+	var tracks []models.HabitTrack
+	for _, track := range s.HabitTracks {
+		if habit, ok := s.Habits[track.HabitID]; !ok || visible(habit.UserID) {
+			tracks = append(tracks, track)
+		}
+	}
+
+	return ComputeStatistics(habits, goals, tracks, time.Now()), nil
+}
+
+// ComputeStatistics считает статистику по переданным записям на момент now.
+func ComputeStatistics(habits []models.Habit, goals []models.Goal, tracks []models.HabitTrack, now time.Time) *Statistics {
 	stats := &Statistics{
 		Categories: make(map[string]CategoryStats),
 	}
 
 	// Считаем привычки
-	totalHabits := len(s.Habits)
+	totalHabits := len(habits)
 	completedHabits := 0
-	for _, habit := range s.Habits {
+	for _, habit := range habits {
 		if habit.Completed {
 			completedHabits++
 		}
@@ -340,16 +634,16 @@ func (s *JSONStorage) GetStatistics() (*Statistics, error) {
 	}
 
 	// Считаем цели
-	totalGoals := len(s.Goals)
+	totalGoals := len(goals)
 	completedGoals := 0
 	overdueGoals := 0
-	now := time.Now()
-	for _, goal := range s.Goals {
+	for _, goal := range goals {
 		if goal.Completed {
 			completedGoals++
 		} else if goal.TargetDate.Before(now) {
 			overdueGoals++
 		}
+		stats.GoalForecast.Add(forecast.Goal(goal, habits, tracks, now))
 	}
 	if totalGoals > 0 {
 		stats.GoalCompletionRate = float64(completedGoals) / float64(totalGoals) * 100
@@ -358,7 +652,7 @@ func (s *JSONStorage) GetStatistics() (*Statistics, error) {
 	// Сегодняшние выполнения
 	today := now.Format("2006-01-02")
 	todayCompleted := 0
-	for _, track := range s.HabitTracks {
+	for _, track := range tracks {
 		if track.Date.Format("2006-01-02") == today && track.Completed {
 			todayCompleted++
 		}
@@ -389,5 +683,5 @@ func (s *JSONStorage) GetStatistics() (*Statistics, error) {
 	stats.TotalItems = totalItems
 	stats.CompletedItems = completedItems
 
-	return stats, nil
+	return stats
 }
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"fmt"
+	"habit-tracker-api/models"
+)
+
+// CreateMilestone присваивает вехе номер и добавляет её к цели.
+func (tx *Tx) CreateMilestone(goalID, version int, milestone *models.Milestone) (*models.Goal, error) {
+	return tx.UpdateGoal(goalID, version, func(goal *models.Goal) error {
+		milestone.ID = tx.s.NextMilestoneID
+		tx.s.NextMilestoneID++
+		goal.Milestones = append(goal.Milestones, *milestone)
+		return nil
+	})
+}
+
+// UpdateMilestone изменяет веху. Версия — версия цели: веха меняется вместе с ней.
+func (tx *Tx) UpdateMilestone(goalID, milestoneID, version int, update func(milestone *models.Milestone) error) (*models.Goal, error) {
+	return tx.UpdateGoal(goalID, version, func(goal *models.Goal) error {
+		i := milestoneIndex(goal, milestoneID)
+		if i < 0 {
+			return fmt.Errorf("goal %d has no milestone %d: %w", goalID, milestoneID, ErrMilestoneNotFound)
+		}
+		milestone := goal.Milestones[i]
+		if err := update(&milestone); err != nil {
+			return err
+		}
+		milestone.ID = milestoneID
+		goal.Milestones[i] = milestone
+		return nil
+	})
+}
+
+func (tx *Tx) DeleteMilestone(goalID, milestoneID, version int) (*models.Goal, error) {
+	return tx.UpdateGoal(goalID, version, func(goal *models.Goal) error {
+		i := milestoneIndex(goal, milestoneID)
+		if i < 0 {
+			return fmt.Errorf("goal %d has no milestone %d: %w", goalID, milestoneID, ErrMilestoneNotFound)
+		}
+		goal.Milestones = append(goal.Milestones[:i:i], goal.Milestones[i+1:]...)
+		return nil
+	})
+}
+
+func milestoneIndex(goal *models.Goal, id int) int {
+	for i, milestone := range goal.Milestones {
+		if milestone.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *JSONStorage) CreateMilestone(goalID, version int, milestone *models.Milestone) (*models.Goal, error) {
+	var goal *models.Goal
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		goal, err = tx.CreateMilestone(goalID, version, milestone)
+		return err
+	})
+	return goal, err
+}
+
+func (s *JSONStorage) UpdateMilestone(goalID, milestoneID, version int, update func(milestone *models.Milestone) error) (*models.Goal, error) {
+	var goal *models.Goal
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		goal, err = tx.UpdateMilestone(goalID, milestoneID, version, update)
+		return err
+	})
+	return goal, err
+}
+
+func (s *JSONStorage) DeleteMilestone(goalID, milestoneID, version int) (*models.Goal, error) {
+	var goal *models.Goal
+	err := s.Batch(func(tx *Tx) error {
+		var err error
+		goal, err = tx.DeleteMilestone(goalID, milestoneID, version)
+		return err
+	})
+	return goal, err
+}
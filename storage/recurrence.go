@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"habit-tracker-api/calendar"
+	"habit-tracker-api/events"
+	"habit-tracker-api/models"
+	"log"
+	"slices"
+	"time"
+)
+
+// renewGoal создаёт следующую цель серии, если у повторяющейся цели её ещё нет, и
+// записывает её номер в NextID. Срок сдвигается на целое число периодов, пока не окажется
+// позже now. Вехи переносятся невыполненными со сдвинутыми сроками, журнал числовой цели
+// начинается заново. Привычки, удалённые с тех пор, не связываются.
+func (tx *Tx) renewGoal(goal *models.Goal, now time.Time) error {
+	if goal.NextID != 0 || !calendar.IsPeriod(goal.Recurrence) {
+		return nil
+	}
+	if err := tx.checkUser(goal.UserID); err != nil {
+		return err
+	}
+
+	periods := 1
+	for !calendar.AddPeriods(goal.TargetDate, goal.Recurrence, periods).After(now) {
+		periods++
+	}
+
+	next := models.Goal{
+		UserID:       goal.UserID,
+		Title:        goal.Title,
+		Description:  goal.Description,
+		TargetDate:   calendar.AddPeriods(goal.TargetDate, goal.Recurrence, periods),
+		CreatedAt:    now,
+		HabitIDs:     []int{},
+		AutoComplete: goal.AutoComplete,
+		TargetValue:  goal.TargetValue,
+		StartValue:   goal.StartValue,
+		Unit:         goal.Unit,
+		Recurrence:   goal.Recurrence,
+		PreviousID:   goal.ID,
+	}
+	for _, id := range goal.HabitIDs {
+		if _, exists := tx.s.Habits[id]; exists {
+			next.HabitIDs = append(next.HabitIDs, id)
+		}
+	}
+	for _, milestone := range goal.Milestones {
+		renewed := models.Milestone{
+			Title:       milestone.Title,
+			TargetValue: milestone.TargetValue,
+		}
+		if milestone.DueDate != nil {
+			due := calendar.AddPeriods(*milestone.DueDate, goal.Recurrence, periods)
+			renewed.DueDate = &due
+		}
+		next.Milestones = append(next.Milestones, renewed)
+	}
+
+	if err := tx.CreateGoal(&next); err != nil {
+		return err
+	}
+	goal.NextID = next.ID
+	return nil
+}
+
+// RenewGoals создаёт следующие цели для повторяющихся целей, которые выполнены или срок
+// которых прошёл, а продолжения у них ещё нет. Возвращает число созданных целей.
+func (s *JSONStorage) RenewGoals(now time.Time) (int, error) {
+	renewed := 0
+	err := s.Batch(func(tx *Tx) error {
+		ids := make([]int, 0, len(tx.s.Goals))
+		for id, goal := range tx.s.Goals {
+			if goal.Recurrence != "" && goal.NextID == 0 && (goal.Completed || goal.TargetDate.Before(now)) {
+				ids = append(ids, id)
+			}
+		}
+		slices.Sort(ids)
+
+		for _, id := range ids {
+			goal := tx.s.Goals[id]
+			// Цель, которую продлить нельзя, не мешает остальным
+			if err := tx.renewGoal(&goal, now); err != nil {
+				log.Printf("storage: failed to renew goal %d: %v", id, err)
+				continue
+			}
+			if goal.NextID == 0 {
+				continue
+			}
+			goal.Version++
+			tx.s.Goals[id] = goal
+			tx.touch("goal", id)
+			tx.publish(events.GoalUpdated{Goal: goal})
+			renewed++
+		}
+		return nil
+	})
+	return renewed, err
+}
+
+// WatchRecurring продлевает повторяющиеся цели при запуске и затем раз в interval.
+func (s *JSONStorage) WatchRecurring(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RenewGoals(time.Now()); err != nil {
+			log.Printf("storage: failed to renew recurring goals: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,502 @@
+package storage
+
+import (
+	"fmt"
+	"habit-tracker-api/events"
+	"habit-tracker-api/models"
+	"slices"
+	"time"
+)
+
+// Tx изменяет хранилище внутри одной блокировки. Сохранение и откат выполняет Batch.
+type Tx struct {
+	s       *JSONStorage
+	changed bool
+	// pending — события, которые Batch опубликует после сохранения
+	pending []events.Event
+}
+
+func (tx *Tx) publish(event events.Event) {
+	tx.pending = append(tx.pending, event)
+}
+
+// TxHook — подписчик, который выполняется внутри транзакции, до сохранения. Нужен
+// для побочных эффектов, которые должны сохраниться вместе с изменением или не
+// сохраниться вовсе: подписчик шины получает событие уже после записи и, упав,
+// оставил бы данные рассогласованными. Ошибка хука откатывает всю транзакцию.
+type TxHook func(tx *Tx, event events.Event) error
+
+type namedHook struct {
+	name string
+	hook TxHook
+}
+
+// runHooks передаёт хукам события транзакции, включая добавленные самими хуками.
+func (tx *Tx) runHooks() error {
+	for i := 0; i < len(tx.pending); i++ {
+		for _, h := range tx.s.hooks {
+			if err := h.hook(tx, tx.pending[i]); err != nil {
+				return fmt.Errorf("%s: %w", h.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// habitOwner — владелец привычки, к которой относится отметка.
+func (tx *Tx) habitOwner(habitID int) int {
+	return tx.s.Habits[habitID].UserID
+}
+
+func (tx *Tx) GetAllHabits() ([]models.Habit, error) {
+	var habits []models.Habit
+	for _, habit := range tx.s.Habits {
+		habits = append(habits, habit)
+	}
+
+	return habits, nil
+}
+
+func (tx *Tx) GetAllTracks() ([]models.HabitTrack, error) {
+	var tracks []models.HabitTrack
+	for _, track := range tx.s.HabitTracks {
+		tracks = append(tracks, track)
+	}
+
+	return tracks, nil
+}
+
+func (tx *Tx) CreateHabit(habit *models.Habit) error {
+	if err := tx.checkUser(habit.UserID); err != nil {
+		return err
+	}
+
+	habit.ID = tx.s.NextHabitID
+	habit.Version = 1
+	tx.s.NextHabitID++
+	tx.changed = true
+	tx.s.Habits[habit.ID] = *habit
+	tx.touch("habit", habit.ID)
+	tx.publish(events.HabitCreated{Habit: *habit})
+
+	return nil
+}
+
+func (tx *Tx) UpdateHabit(id, version int, update func(habit *models.Habit) error) (*models.Habit, error) {
+	habit, exists := tx.s.Habits[id]
+	if !exists {
+		return nil, fmt.Errorf("habit %d: %w", id, ErrNotFound)
+	}
+
+	if err := checkVersion("habit", id, habit.Version, version); err != nil {
+		return nil, err
+	}
+
+	current := habit.Version
+	if err := update(&habit); err != nil {
+		return nil, err
+	}
+
+	habit.ID = id
+	habit.Version = current + 1
+	tx.changed = true
+	tx.s.Habits[id] = habit
+	tx.touch("habit", id)
+	tx.publish(events.HabitUpdated{Habit: habit})
+
+	return &habit, nil
+}
+
+func (tx *Tx) DeleteHabit(id, version int) (*models.Habit, error) {
+	habit, exists := tx.s.Habits[id]
+	if !exists {
+		return nil, fmt.Errorf("habit %d: %w", id, ErrNotFound)
+	}
+
+	if err := checkVersion("habit", id, habit.Version, version); err != nil {
+		return nil, err
+	}
+
+	tx.changed = true
+	delete(tx.s.Habits, id)
+	tx.bury("habit", id, habit.UserID, habit.Version)
+	tx.publish(events.HabitDeleted{Habit: habit})
+	return &habit, nil
+}
+
+func (tx *Tx) CompleteHabit(id, version int) (*models.Habit, error) {
+	habit, exists := tx.s.Habits[id]
+	if !exists {
+		return nil, fmt.Errorf("habit %d: %w", id, ErrNotFound)
+	}
+
+	if err := checkVersion("habit", id, habit.Version, version); err != nil {
+		return nil, err
+	}
+
+	if habit.Completed {
+		return nil, fmt.Errorf("habit %d: %w", id, ErrAlreadyCompleted)
+	}
+
+	now := time.Now()
+	habit.Completed = true
+	habit.History = recordStatus(habit.History, models.StatusCompleted, now)
+	habit.Version++
+	tx.changed = true
+	tx.s.Habits[id] = habit
+	tx.touch("habit", id)
+	tx.publish(events.HabitCompleted{Habit: habit})
+	return &habit, nil
+}
+
+func (tx *Tx) ReopenHabit(id, version int) (*models.Habit, error) {
+	habit, exists := tx.s.Habits[id]
+	if !exists {
+		return nil, fmt.Errorf("habit %d: %w", id, ErrNotFound)
+	}
+
+	if err := checkVersion("habit", id, habit.Version, version); err != nil {
+		return nil, err
+	}
+
+	if !habit.Completed {
+		return nil, fmt.Errorf("habit %d: %w", id, ErrNotCompleted)
+	}
+
+	habit.Completed = false
+	habit.History = recordStatus(habit.History, models.StatusReopened, time.Now())
+	habit.Version++
+	tx.changed = true
+	tx.s.Habits[id] = habit
+	tx.touch("habit", id)
+	tx.publish(events.HabitReopened{Habit: habit})
+
+	return &habit, nil
+}
+
+func (tx *Tx) CreateGoal(goal *models.Goal) error {
+	if err := tx.checkUser(goal.UserID); err != nil {
+		return err
+	}
+	if err := tx.checkHabits(goal.HabitIDs); err != nil {
+		return err
+	}
+
+	// Вехи новой цели (из набора или продления серии) получают номера здесь
+	for i := range goal.Milestones {
+		if goal.Milestones[i].ID == 0 {
+			goal.Milestones[i].ID = tx.s.NextMilestoneID
+			tx.s.NextMilestoneID++
+		}
+	}
+
+	now := time.Now()
+	refreshGoal(goal, now)
+	if achieved(goal) {
+		completeGoal(goal, now)
+	}
+	goal.ID = tx.s.NextGoalID
+	goal.Version = 1
+	tx.s.NextGoalID++
+	tx.changed = true
+	tx.s.Goals[goal.ID] = *goal
+	tx.touch("goal", goal.ID)
+	tx.publish(events.GoalCreated{Goal: *goal})
+	if goal.Completed {
+		tx.publish(events.GoalCompleted{Goal: *goal})
+	}
+
+	return nil
+}
+
+func (tx *Tx) UpdateGoal(id, version int, update func(goal *models.Goal) error) (*models.Goal, error) {
+	goal, exists := tx.s.Goals[id]
+	if !exists {
+		return nil, fmt.Errorf("goal %d: %w", id, ErrNotFound)
+	}
+
+	if err := checkVersion("goal", id, goal.Version, version); err != nil {
+		return nil, err
+	}
+
+	// Цель выполняется сама только в момент достижения: открытая заново достигнутая
+	// цель остаётся открытой, пока её не выполнят вручную
+	current, completed, linked, wasAchieved := goal.Version, goal.Completed, goal.HabitIDs, achieved(&goal)
+	// Копия цели делит срезы с хранилищем: правка вех или журнала на месте осталась бы
+	// в хранилище, даже если update или проверки ниже вернут ошибку
+	goal.Milestones = slices.Clone(goal.Milestones)
+	goal.Entries = slices.Clone(goal.Entries)
+	goal.HabitIDs = slices.Clone(goal.HabitIDs)
+	if err := update(&goal); err != nil {
+		return nil, err
+	}
+	if err := tx.checkHabits(goal.HabitIDs, linked...); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	refreshGoal(&goal, now)
+	if !goal.Completed && !wasAchieved && achieved(&goal) {
+		completeGoal(&goal, now)
+	}
+	if goal.Completed && !completed {
+		if err := tx.renewGoal(&goal, now); err != nil {
+			return nil, err
+		}
+	}
+
+	goal.ID = id
+	goal.Version = current + 1
+	tx.changed = true
+	tx.s.Goals[id] = goal
+	tx.touch("goal", id)
+	tx.publish(events.GoalUpdated{Goal: goal})
+	// Цель выполнилась сама: все вехи выполнены и включено AutoComplete
+	if goal.Completed && !completed {
+		tx.publish(events.GoalCompleted{Goal: goal})
+	}
+
+	return &goal, nil
+}
+
+func (tx *Tx) DeleteGoal(id, version int) (*models.Goal, error) {
+	goal, exists := tx.s.Goals[id]
+	if !exists {
+		return nil, fmt.Errorf("goal %d: %w", id, ErrNotFound)
+	}
+
+	if err := checkVersion("goal", id, goal.Version, version); err != nil {
+		return nil, err
+	}
+
+	tx.changed = true
+	delete(tx.s.Goals, id)
+	tx.bury("goal", id, goal.UserID, goal.Version)
+	tx.publish(events.GoalDeleted{Goal: goal})
+	return &goal, nil
+}
+
+func (tx *Tx) CompleteGoal(id, version int) (*models.Goal, error) {
+	goal, exists := tx.s.Goals[id]
+	if !exists {
+		return nil, fmt.Errorf("goal %d: %w", id, ErrNotFound)
+	}
+
+	if err := checkVersion("goal", id, goal.Version, version); err != nil {
+		return nil, err
+	}
+
+	if goal.Completed {
+		return nil, fmt.Errorf("goal %d: %w", id, ErrAlreadyCompleted)
+	}
+
+	now := time.Now()
+	completeGoal(&goal, now)
+	if err := tx.renewGoal(&goal, now); err != nil {
+		return nil, err
+	}
+	goal.Version++
+	tx.changed = true
+	tx.s.Goals[id] = goal
+	tx.touch("goal", id)
+	tx.publish(events.GoalCompleted{Goal: goal})
+
+	return &goal, nil
+}
+
+func (tx *Tx) ReopenGoal(id, version int) (*models.Goal, error) {
+	goal, exists := tx.s.Goals[id]
+	if !exists {
+		return nil, fmt.Errorf("goal %d: %w", id, ErrNotFound)
+	}
+
+	if err := checkVersion("goal", id, goal.Version, version); err != nil {
+		return nil, err
+	}
+
+	if !goal.Completed {
+		return nil, fmt.Errorf("goal %d: %w", id, ErrNotCompleted)
+	}
+
+	goal.Completed = false
+	goal.CompletedAt = time.Time{}
+	goal.History = recordStatus(goal.History, models.StatusReopened, time.Now())
+	goal.Version++
+	tx.changed = true
+	tx.s.Goals[id] = goal
+	tx.touch("goal", id)
+	tx.publish(events.GoalReopened{Goal: goal})
+
+	return &goal, nil
+}
+
+func (tx *Tx) CreateTrack(track *models.HabitTrack) error {
+	if _, exists := tx.s.Habits[track.HabitID]; !exists {
+		return fmt.Errorf("habit %d does not exist: %w", track.HabitID, ErrConflict)
+	}
+
+	track.ID = tx.s.NextTrackID
+	track.Version = 1
+	tx.s.NextTrackID++
+	tx.changed = true
+	tx.s.HabitTracks[track.ID] = *track
+	tx.touch("track", track.ID)
+	tx.publish(events.TrackCreated{Track: *track, Owner: tx.habitOwner(track.HabitID)})
+
+	return nil
+}
+
+func (tx *Tx) UpdateTrack(id, version int, update func(track *models.HabitTrack) error) (*models.HabitTrack, error) {
+	track, exists := tx.s.HabitTracks[id]
+	if !exists {
+		return nil, fmt.Errorf("track %d: %w", id, ErrNotFound)
+	}
+
+	if err := checkVersion("track", id, track.Version, version); err != nil {
+		return nil, err
+	}
+
+	current := track.Version
+	if err := update(&track); err != nil {
+		return nil, err
+	}
+
+	if _, exists := tx.s.Habits[track.HabitID]; !exists {
+		return nil, fmt.Errorf("habit %d does not exist: %w", track.HabitID, ErrConflict)
+	}
+
+	track.ID = id
+	track.Version = current + 1
+	tx.changed = true
+	tx.s.HabitTracks[id] = track
+	tx.touch("track", id)
+	tx.publish(events.TrackUpdated{Track: track, Owner: tx.habitOwner(track.HabitID)})
+
+	return &track, nil
+}
+
+func (tx *Tx) DeleteTrack(id, version int) (*models.HabitTrack, error) {
+	track, exists := tx.s.HabitTracks[id]
+	if !exists {
+		return nil, fmt.Errorf("track %d: %w", id, ErrNotFound)
+	}
+
+	if err := checkVersion("track", id, track.Version, version); err != nil {
+		return nil, err
+	}
+
+	tx.changed = true
+	delete(tx.s.HabitTracks, id)
+	tx.bury("track", id, tx.habitOwner(track.HabitID), track.Version)
+	tx.publish(events.TrackDeleted{Track: track, Owner: tx.habitOwner(track.HabitID)})
+	return &track, nil
+}
+
+// checkUser проверяет владельца записи. Записи без владельца (0) остались от однопользовательской версии.
+func (tx *Tx) checkUser(userID int) error {
+	if userID == 0 {
+		return nil
+	}
+	if _, exists := tx.s.Users[userID]; !exists {
+		return fmt.Errorf("user %d does not exist: %w", userID, ErrConflict)
+	}
+	return nil
+}
+
+// checkHabits проверяет привычки, связанные с целью. Уже связанные (linked) не проверяются:
+// удаление привычки не должно мешать изменять цель.
+func (tx *Tx) checkHabits(ids []int, linked ...int) error {
+	for _, id := range ids {
+		if slices.Contains(linked, id) {
+			continue
+		}
+		if _, exists := tx.s.Habits[id]; !exists {
+			return fmt.Errorf("habit %d does not exist: %w", id, ErrConflict)
+		}
+	}
+	return nil
+}
+
+func (tx *Tx) CreateUser(user *models.User) error {
+	user.ID = tx.s.NextUserID
+	user.Version = 1
+	tx.s.NextUserID++
+	tx.changed = true
+	tx.s.Users[user.ID] = *user
+
+	return nil
+}
+
+func (tx *Tx) UpdateUser(id, version int, update func(user *models.User) error) (*models.User, error) {
+	user, exists := tx.s.Users[id]
+	if !exists {
+		return nil, fmt.Errorf("user %d: %w", id, ErrNotFound)
+	}
+
+	if err := checkVersion("user", id, user.Version, version); err != nil {
+		return nil, err
+	}
+
+	current := user.Version
+	if err := update(&user); err != nil {
+		return nil, err
+	}
+
+	user.ID = id
+	user.Version = current + 1
+	tx.changed = true
+	tx.s.Users[id] = user
+
+	return &user, nil
+}
+
+func (tx *Tx) CreateWebhook(webhook *models.Webhook) error {
+	if err := tx.checkUser(webhook.UserID); err != nil {
+		return err
+	}
+
+	webhook.ID = tx.s.NextHookID
+	webhook.Version = 1
+	tx.s.NextHookID++
+	tx.changed = true
+	tx.s.Webhooks[webhook.ID] = *webhook
+
+	return nil
+}
+
+func (tx *Tx) UpdateWebhook(id, version int, update func(webhook *models.Webhook) error) (*models.Webhook, error) {
+	webhook, exists := tx.s.Webhooks[id]
+	if !exists {
+		return nil, fmt.Errorf("webhook %d: %w", id, ErrNotFound)
+	}
+
+	if err := checkVersion("webhook", id, webhook.Version, version); err != nil {
+		return nil, err
+	}
+
+	current := webhook.Version
+	if err := update(&webhook); err != nil {
+		return nil, err
+	}
+
+	webhook.ID = id
+	webhook.Version = current + 1
+	tx.changed = true
+	tx.s.Webhooks[id] = webhook
+
+	return &webhook, nil
+}
+
+func (tx *Tx) DeleteWebhook(id, version int) (*models.Webhook, error) {
+	webhook, exists := tx.s.Webhooks[id]
+	if !exists {
+		return nil, fmt.Errorf("webhook %d: %w", id, ErrNotFound)
+	}
+
+	if err := checkVersion("webhook", id, webhook.Version, version); err != nil {
+		return nil, err
+	}
+
+	tx.changed = true
+	delete(tx.s.Webhooks, id)
+	return &webhook, nil
+}
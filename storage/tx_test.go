@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"errors"
+	"habit-tracker-api/events"
+	"habit-tracker-api/models"
+	"testing"
+	"time"
+)
+
+func TestUpdateGoalFailureKeepsSlices(t *testing.T) {
+	s := newTestStorage(t)
+	habit := models.Habit{Name: "Бег", CreatedAt: time.Now()}
+	if err := s.CreateHabit(&habit); err != nil {
+		t.Fatal(err)
+	}
+	target := 10.0
+	delta := 1.0
+	goal := models.Goal{
+		Title:       "Пробежать 10 км",
+		TargetDate:  time.Now().AddDate(0, 1, 0),
+		CreatedAt:   time.Now(),
+		HabitIDs:    []int{habit.ID},
+		TargetValue: &target,
+		Milestones:  []models.Milestone{{Title: "5 км"}, {Title: "10 км"}},
+		Entries:     []models.ProgressEntry{{ID: 1, Date: time.Now(), Delta: &delta}},
+	}
+	if err := s.CreateGoal(&goal); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := errors.New("rejected")
+	tests := []struct {
+		name   string
+		update func(goal *models.Goal) error
+	}{
+		{"update error", func(goal *models.Goal) error {
+			goal.Milestones[0].Completed = true
+			goal.Entries[0].Note = "правка"
+			goal.HabitIDs[0] = 99
+			return failed
+		}},
+		{"unknown habit", func(goal *models.Goal) error {
+			goal.Milestones[1].Title = "Марафон"
+			goal.HabitIDs[0] = 99
+			return nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.UpdateGoal(goal.ID, AnyVersion, tt.update); err == nil {
+				t.Fatal("update succeeded")
+			}
+
+			stored, err := s.GetGoalByID(goal.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Milestones[0].Completed || stored.Milestones[1].Title != "10 км" {
+				t.Errorf("milestones changed: %+v", stored.Milestones)
+			}
+			if stored.Entries[0].Note != "" {
+				t.Errorf("entries changed: %+v", stored.Entries)
+			}
+			if stored.HabitIDs[0] != habit.ID {
+				t.Errorf("habit ids changed: %v", stored.HabitIDs)
+			}
+			if stored.Version != goal.Version {
+				t.Errorf("version = %d, want %d", stored.Version, goal.Version)
+			}
+		})
+	}
+}
+
+func TestOnCommit(t *testing.T) {
+	s := newTestStorage(t)
+	s.OnCommit("completion-track", events.RecordCompletion)
+	first := models.Habit{Name: "Бег", CreatedAt: time.Now()}
+	second := models.Habit{Name: "Чтение", CreatedAt: time.Now()}
+	for _, habit := range []*models.Habit{&first, &second} {
+		if err := s.CreateHabit(habit); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	completed, err := s.CompleteHabit(first.ID, AnyVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracks, err := s.GetAllTracks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 1 || tracks[0].HabitID != first.ID || tracks[0].Notes != events.CompletionNote ||
+		!tracks[0].Date.Equal(completed.History[len(completed.History)-1].At) {
+		t.Fatalf("tracks = %+v, want a completion track at the completion time", tracks)
+	}
+
+	// Ошибка хука откатывает и само выполнение
+	failed := errors.New("rejected")
+	s.OnCommit("failing", func(tx *Tx, event events.Event) error {
+		if _, ok := event.(events.TrackCreated); ok {
+			return failed
+		}
+		return nil
+	})
+	if _, err := s.CompleteHabit(second.ID, AnyVersion); !errors.Is(err, failed) {
+		t.Fatalf("complete error = %v, want hook error", err)
+	}
+	habit, err := s.GetHabitByID(second.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if habit.Completed {
+		t.Error("habit completed although the hook failed")
+	}
+	if tracks, _ := s.GetAllTracks(); len(tracks) != 1 {
+		t.Errorf("tracks = %+v after rollback", tracks)
+	}
+}
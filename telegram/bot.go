@@ -0,0 +1,411 @@
+package telegram
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"habit-tracker-api/models"
+	"habit-tracker-api/reminders"
+	"habit-tracker-api/storage"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// TrackNote — заметка отметки, созданной через бота.
+const TrackNote = "Marked as done via Telegram"
+
+// Store — операции хранилища, которыми пользуется бот. Реализуется storage.JSONStorage.
+type Store interface {
+	GetAllUsers() ([]models.User, error)
+	UpdateUser(id, version int, update func(user *models.User) error) (*models.User, error)
+	GetAllHabits() ([]models.Habit, error)
+	GetAllTracks() ([]models.HabitTrack, error)
+	CreateTrack(track *models.HabitTrack) error
+	GetUserStatistics(userID int) (*storage.Statistics, error)
+}
+
+// API — методы Bot API, нужные боту. Реализуется Client.
+type API interface {
+	GetUpdates(ctx context.Context, offset int, timeout time.Duration) ([]Update, error)
+	SendMessage(ctx context.Context, chatID int64, text string) error
+}
+
+type Options struct {
+	PollTimeout time.Duration
+	// RetryDelay — пауза после ошибки getUpdates
+	RetryDelay time.Duration
+	Location   *time.Location
+	Now        func() time.Time
+	Logger     *log.Logger
+}
+
+func (o Options) withDefaults() Options {
+	if o.PollTimeout <= 0 {
+		o.PollTimeout = 30 * time.Second
+	}
+	if o.RetryDelay <= 0 {
+		o.RetryDelay = 5 * time.Second
+	}
+	if o.Location == nil {
+		o.Location = time.Local
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	if o.Logger == nil {
+		o.Logger = log.Default()
+	}
+	return o
+}
+
+// Config — настройки бота из TELEGRAM_BOT_TOKEN и TELEGRAM_API_URL. Без токена бот выключен.
+type Config struct {
+	Token  string
+	APIURL string
+}
+
+func ConfigFromEnv() Config {
+	return Config{
+		Token:  os.Getenv("TELEGRAM_BOT_TOKEN"),
+		APIURL: os.Getenv("TELEGRAM_API_URL"),
+	}
+}
+
+func (c Config) Enabled() bool {
+	return c.Token != ""
+}
+
+// Bot принимает команды через long polling и переводит их в операции хранилища.
+// Чат привязывается к пользователю одноразовым кодом из API.
+type Bot struct {
+	api   API
+	store Store
+	opts  Options
+}
+
+func NewBot(api API, store Store, opts Options) *Bot {
+	return &Bot{api: api, store: store, opts: opts.withDefaults()}
+}
+
+// Run опрашивает Bot API до отмены ctx.
+func (b *Bot) Run(ctx context.Context) {
+	offset := 0
+	for {
+		updates, err := b.api.GetUpdates(ctx, offset, b.opts.PollTimeout)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			b.opts.Logger.Printf("telegram: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(b.opts.RetryDelay):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			// Подтверждаем обновление до обработки: сообщение, на котором бот упал, не придёт снова
+			offset = update.UpdateID + 1
+			if update.Message != nil {
+				b.Handle(ctx, update.Message)
+			}
+		}
+	}
+}
+
+// Handle отвечает на одно сообщение.
+func (b *Bot) Handle(ctx context.Context, msg *Message) {
+	command, arg := parseCommand(msg.Text)
+	if command == "" {
+		return
+	}
+
+	user, err := b.userByChat(msg.Chat.ID)
+	if err != nil {
+		b.opts.Logger.Printf("telegram: %v", err)
+		b.reply(ctx, msg.Chat.ID, text(defaultLocale, "error"))
+		return
+	}
+
+	locale := defaultLocale
+	if user != nil {
+		locale = user.Locale
+	} else if msg.From != nil && strings.HasPrefix(msg.From.LanguageCode, "en") {
+		locale = "en"
+	}
+
+	var reply string
+	switch command {
+	case "start":
+		if arg != "" {
+			reply = b.link(msg.Chat.ID, arg)
+		} else {
+			reply = text(locale, "welcome")
+		}
+	case "link":
+		if arg == "" {
+			reply = text(locale, "link_usage")
+		} else {
+			reply = b.link(msg.Chat.ID, arg)
+		}
+	case "help":
+		reply = text(locale, "help")
+	case "unlink", "done", "today", "stats":
+		if user == nil {
+			reply = text(locale, "not_linked")
+			break
+		}
+		switch command {
+		case "unlink":
+			reply = b.unlink(*user)
+		case "done":
+			reply = b.done(*user, arg)
+		case "today":
+			reply = b.today(*user)
+		case "stats":
+			reply = b.stats(*user)
+		}
+	default:
+		reply = text(locale, "unknown")
+	}
+
+	b.reply(ctx, msg.Chat.ID, reply)
+}
+
+func (b *Bot) reply(ctx context.Context, chatID int64, message string) {
+	if err := b.api.SendMessage(ctx, chatID, message); err != nil {
+		b.opts.Logger.Printf("telegram: reply to chat %d: %v", chatID, err)
+	}
+}
+
+// parseCommand разбирает "/done@HabitBot Тренировка" в ("done", "Тренировка").
+func parseCommand(message string) (string, string) {
+	message = strings.TrimSpace(message)
+	if !strings.HasPrefix(message, "/") {
+		return "", ""
+	}
+	command, arg, _ := strings.Cut(message[1:], " ")
+	command, _, _ = strings.Cut(command, "@")
+	return strings.ToLower(command), strings.TrimSpace(arg)
+}
+
+func (b *Bot) userByChat(chatID int64) (*models.User, error) {
+	users, err := b.store.GetAllUsers()
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if user.TelegramChatID == chatID {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *Bot) link(chatID int64, code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	now := b.opts.Now()
+
+	users, err := b.store.GetAllUsers()
+	if err != nil {
+		b.opts.Logger.Printf("telegram: %v", err)
+		return text(defaultLocale, "error")
+	}
+
+	var owner *models.User
+	for _, user := range users {
+		if user.TelegramLinkCode == "" || user.TelegramLinkExpiry == nil || !now.Before(*user.TelegramLinkExpiry) {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(user.TelegramLinkCode), []byte(code)) == 1 {
+			owner = &user
+			break
+		}
+	}
+	if owner == nil {
+		return text(defaultLocale, "link_invalid")
+	}
+
+	// Чат привязан не больше чем к одному пользователю
+	for _, user := range users {
+		if user.TelegramChatID == chatID && user.ID != owner.ID {
+			b.unlink(user)
+		}
+	}
+
+	linked, err := b.store.UpdateUser(owner.ID, storage.AnyVersion, func(user *models.User) error {
+		user.TelegramChatID = chatID
+		user.TelegramLinkCode = ""
+		user.TelegramLinkExpiry = nil
+		return nil
+	})
+	if err != nil {
+		b.opts.Logger.Printf("telegram: link user %d: %v", owner.ID, err)
+		return text(owner.Locale, "error")
+	}
+	return text(linked.Locale, "linked", linked.Name)
+}
+
+func (b *Bot) unlink(user models.User) string {
+	_, err := b.store.UpdateUser(user.ID, storage.AnyVersion, func(user *models.User) error {
+		user.TelegramChatID = 0
+		return nil
+	})
+	if err != nil {
+		b.opts.Logger.Printf("telegram: unlink user %d: %v", user.ID, err)
+		return text(user.Locale, "error")
+	}
+	return text(user.Locale, "unlinked")
+}
+
+// userHabits — активные привычки пользователя и общие привычки.
+func (b *Bot) userHabits(user models.User) ([]models.Habit, error) {
+	habits, err := b.store.GetAllHabits()
+	if err != nil {
+		return nil, err
+	}
+	var result []models.Habit
+	for _, habit := range habits {
+		if habit.Completed || (habit.UserID != 0 && habit.UserID != user.ID) {
+			continue
+		}
+		result = append(result, habit)
+	}
+	return result, nil
+}
+
+// findHabit ищет привычку по точному названию без учёта регистра, затем по подстроке.
+func findHabit(habits []models.Habit, name string) ([]models.Habit, bool) {
+	needle := strings.ToLower(name)
+	var partial []models.Habit
+	for _, habit := range habits {
+		title := strings.ToLower(habit.Name)
+		if title == needle {
+			return []models.Habit{habit}, true
+		}
+		if strings.Contains(title, needle) {
+			partial = append(partial, habit)
+		}
+	}
+	return partial, len(partial) == 1
+}
+
+func (b *Bot) done(user models.User, name string) string {
+	if name == "" {
+		return text(user.Locale, "done_usage")
+	}
+
+	habits, err := b.userHabits(user)
+	if err != nil {
+		b.opts.Logger.Printf("telegram: %v", err)
+		return text(user.Locale, "error")
+	}
+	matches, ok := findHabit(habits, name)
+	if !ok {
+		if len(matches) == 0 {
+			return text(user.Locale, "habit_not_found", name)
+		}
+		names := make([]string, len(matches))
+		for i, habit := range matches {
+			names[i] = habit.Name
+		}
+		return text(user.Locale, "habit_ambiguous", strings.Join(names, ", "))
+	}
+	habit := matches[0]
+
+	tracks, err := b.store.GetAllTracks()
+	if err != nil {
+		b.opts.Logger.Printf("telegram: %v", err)
+		return text(user.Locale, "error")
+	}
+	now := b.opts.Now().In(b.opts.Location)
+	if reminders.Done(models.Habit{ID: habit.ID, Frequency: "daily"}, tracks, now) {
+		return text(user.Locale, "already_done", habit.Name)
+	}
+
+	track := &models.HabitTrack{
+		HabitID:   habit.ID,
+		Date:      now,
+		Completed: true,
+		Notes:     TrackNote,
+	}
+	if err := b.store.CreateTrack(track); err != nil {
+		b.opts.Logger.Printf("telegram: complete habit %d: %v", habit.ID, err)
+		return text(user.Locale, "error")
+	}
+
+	week := reminders.PeriodOf("weekly", now)
+	count := 1
+	for _, t := range tracks {
+		date := t.Date.In(b.opts.Location)
+		if t.HabitID == habit.ID && t.Completed && !date.Before(week.Start) && date.Before(week.End) {
+			count++
+		}
+	}
+	return text(user.Locale, "done", habit.Name, count)
+}
+
+func (b *Bot) today(user models.User) string {
+	habits, err := b.userHabits(user)
+	if err == nil {
+		var tracks []models.HabitTrack
+		if tracks, err = b.store.GetAllTracks(); err == nil {
+			return b.formatToday(user, habits, tracks)
+		}
+	}
+	b.opts.Logger.Printf("telegram: %v", err)
+	return text(user.Locale, "error")
+}
+
+func (b *Bot) formatToday(user models.User, habits []models.Habit, tracks []models.HabitTrack) string {
+	now := b.opts.Now().In(b.opts.Location)
+	layout, ok := dateLayouts[user.Locale]
+	if !ok {
+		layout = dateLayouts[defaultLocale]
+	}
+
+	lines := []string{text(user.Locale, "today_title", now.Format(layout))}
+	if len(habits) == 0 {
+		lines = append(lines, text(user.Locale, "today_empty"))
+	}
+	for _, habit := range habits {
+		mark := "⬜"
+		if reminders.Done(models.Habit{ID: habit.ID, Frequency: "daily"}, tracks, now) {
+			mark = "✅"
+		}
+		lines = append(lines, mark+" "+habit.Name)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *Bot) stats(user models.User) string {
+	stats, err := b.store.GetUserStatistics(user.ID)
+	if err != nil {
+		b.opts.Logger.Printf("telegram: %v", err)
+		return text(user.Locale, "error")
+	}
+	return text(user.Locale, "stats",
+		stats.CompletedHabits, stats.TotalHabits, stats.HabitCompletionRate,
+		stats.CompletedGoals, stats.TotalGoals, stats.GoalCompletionRate, stats.OverdueGoals,
+		stats.TodayCompleted,
+		stats.OverallProgress,
+	)
+}
+
+// Notify отправляет напоминание в привязанный чат владельца привычки.
+func (b *Bot) Notify(ctx context.Context, n reminders.Notification) error {
+	if n.User == nil || n.User.TelegramChatID == 0 {
+		return nil
+	}
+	err := b.api.SendMessage(ctx, n.User.TelegramChatID, text(n.User.Locale, "reminder", n.Habit.Name, n.Habit.Name))
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Code == 403 {
+		// Пользователь заблокировал бота — напоминание не доставить, это не ошибка планировщика
+		return nil
+	}
+	return err
+}
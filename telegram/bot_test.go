@@ -0,0 +1,189 @@
+package telegram_test
+
+import (
+	"context"
+	"habit-tracker-api/models"
+	"habit-tracker-api/storage"
+	"habit-tracker-api/telegram"
+	"habit-tracker-api/telegram/telegramtest"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const chatID = 1001
+
+var now = time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+type fixture struct {
+	store  *storage.JSONStorage
+	server *telegramtest.Server
+	user   models.User
+	other  models.User
+}
+
+// newFixture запускает бота против заглушки Bot API и пустого хранилища
+// с двумя пользователями; у первого есть действующий код привязки.
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	store, err := storage.NewJSONStorage(filepath.Join(t.TempDir(), "habits.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiry := now.Add(10 * time.Minute)
+	user := models.User{Name: "Анна", TelegramLinkCode: "ABC123", TelegramLinkExpiry: &expiry}
+	other := models.User{Name: "Борис"}
+	for _, u := range []*models.User{&user, &other} {
+		if err := store.CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server, err := telegramtest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	bot := telegram.NewBot(telegram.NewClient("test", server.URL), store, telegram.Options{
+		PollTimeout: time.Second,
+		RetryDelay:  10 * time.Millisecond,
+		Location:    time.UTC,
+		Now:         func() time.Time { return now },
+		Logger:      log.New(io.Discard, "", 0),
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go bot.Run(ctx)
+
+	return &fixture{store: store, server: server, user: user, other: other}
+}
+
+// send отправляет сообщение от имени чата и ждёт ответа бота.
+func (f *fixture) send(t *testing.T, message string) string {
+	t.Helper()
+	f.server.SendText(chatID, message)
+	select {
+	case reply := <-f.server.Replies():
+		if reply.ChatID != chatID {
+			t.Fatalf("%s: reply sent to chat %d, want %d", message, reply.ChatID, chatID)
+		}
+		return reply.Text
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s: no reply", message)
+		return ""
+	}
+}
+
+func (f *fixture) habit(t *testing.T, userID int, name string) models.Habit {
+	t.Helper()
+	habit := models.Habit{UserID: userID, Name: name, Category: "здоровье", Frequency: "ежедневно", CreatedAt: now}
+	if err := f.store.CreateHabit(&habit); err != nil {
+		t.Fatal(err)
+	}
+	return habit
+}
+
+func expectContains(t *testing.T, reply string, parts ...string) {
+	t.Helper()
+	for _, part := range parts {
+		if !strings.Contains(reply, part) {
+			t.Errorf("reply %q does not contain %q", reply, part)
+		}
+	}
+}
+
+func TestLink(t *testing.T) {
+	f := newFixture(t)
+
+	expectContains(t, f.send(t, "/link"), "/link КОД")
+	expectContains(t, f.send(t, "/link WRONG"), "Код не подходит")
+	expectContains(t, f.send(t, "/today"), "Чат не привязан")
+
+	// Код не зависит от регистра
+	expectContains(t, f.send(t, "/link abc123"), "Готово, Анна!")
+
+	user, err := f.store.GetUserByID(f.user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.TelegramChatID != chatID {
+		t.Errorf("chat id = %d, want %d", user.TelegramChatID, chatID)
+	}
+	if user.TelegramLinkCode != "" || user.TelegramLinkExpiry != nil {
+		t.Errorf("link code was not cleared: %q, %v", user.TelegramLinkCode, user.TelegramLinkExpiry)
+	}
+
+	// Код одноразовый
+	expectContains(t, f.send(t, "/link ABC123"), "Код не подходит")
+}
+
+func TestLinkExpired(t *testing.T) {
+	f := newFixture(t)
+
+	expiry := now.Add(-time.Minute)
+	_, err := f.store.UpdateUser(f.user.ID, storage.AnyVersion, func(user *models.User) error {
+		user.TelegramLinkExpiry = &expiry
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectContains(t, f.send(t, "/link ABC123"), "Код не подходит")
+}
+
+func TestDone(t *testing.T) {
+	f := newFixture(t)
+	workout := f.habit(t, f.user.ID, "Тренировка")
+	f.habit(t, f.user.ID, "Чтение книги")
+	f.habit(t, f.user.ID, "Чтение статьи")
+	f.habit(t, f.other.ID, "Медитация")
+
+	expectContains(t, f.send(t, "/done Тренировка"), "Чат не привязан")
+	f.send(t, "/link ABC123")
+
+	expectContains(t, f.send(t, "/done"), "Напишите название привычки")
+	expectContains(t, f.send(t, "/done тренировка"), "«Тренировка» выполнена", "Отметок за неделю: 1")
+	expectContains(t, f.send(t, "/done Тренировка"), "«Тренировка» уже отмечена сегодня")
+	expectContains(t, f.send(t, "/done Чтение"), "Подходит несколько привычек", "Чтение книги", "Чтение статьи")
+	// Привычки других пользователей бот не видит
+	expectContains(t, f.send(t, "/done Медитация"), "«Медитация» не найдена")
+
+	tracks, err := f.store.GetAllTracks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracks) != 1 {
+		t.Fatalf("got %d tracks, want 1", len(tracks))
+	}
+	track := tracks[0]
+	if track.HabitID != workout.ID || !track.Completed || track.Notes != telegram.TrackNote || !track.Date.Equal(now) {
+		t.Errorf("unexpected track %+v", track)
+	}
+}
+
+func TestToday(t *testing.T) {
+	f := newFixture(t)
+	f.send(t, "/link ABC123")
+
+	expectContains(t, f.send(t, "/today"), "Сегодня, 10.03:", "Привычек пока нет.")
+
+	workout := f.habit(t, f.user.ID, "Тренировка")
+	f.habit(t, 0, "Вода")
+	f.habit(t, f.other.ID, "Медитация")
+	err := f.store.CreateTrack(&models.HabitTrack{HabitID: workout.ID, Date: now.Add(-time.Hour), Completed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply := f.send(t, "/today")
+	expectContains(t, reply, "✅ Тренировка", "⬜ Вода")
+	if strings.Contains(reply, "Медитация") {
+		t.Errorf("reply %q lists another user's habit", reply)
+	}
+}
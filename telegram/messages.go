@@ -0,0 +1,77 @@
+package telegram
+
+import "fmt"
+
+const defaultLocale = "ru"
+
+var messages = map[string]map[string]string{
+	"ru": {
+		"help": "Команды:\n" +
+			"/done Название — отметить привычку выполненной\n" +
+			"/today — привычки на сегодня\n" +
+			"/stats — статистика\n" +
+			"/link КОД — привязать чат к пользователю\n" +
+			"/unlink — отвязать чат",
+		"welcome":         "Привет! Я отмечаю выполнение привычек.\n\nЧтобы начать, получите код привязки: POST /api/v1/users/:id/telegram-link — и отправьте его сюда командой /link КОД.",
+		"unknown":         "Не знаю такой команды. /help — список команд.",
+		"not_linked":      "Чат не привязан к пользователю. Получите код: POST /api/v1/users/:id/telegram-link — и отправьте /link КОД.",
+		"link_usage":      "Отправьте код привязки: /link КОД",
+		"link_invalid":    "Код не подходит или устарел. Получите новый: POST /api/v1/users/:id/telegram-link",
+		"linked":          "Готово, %s! Чат привязан. /today — привычки на сегодня.",
+		"unlinked":        "Чат отвязан.",
+		"done_usage":      "Напишите название привычки: /done Тренировка",
+		"habit_not_found": "Привычка «%s» не найдена. /today — список привычек.",
+		"habit_ambiguous": "Подходит несколько привычек: %s. Уточните название.",
+		"already_done":    "«%s» уже отмечена сегодня 👍",
+		"done":            "✅ «%s» выполнена! Отметок за неделю: %d.",
+		"today_title":     "Сегодня, %s:",
+		"today_empty":     "Привычек пока нет.",
+		"stats": "Привычки: %d из %d (%.0f%%)\n" +
+			"Цели: %d из %d (%.0f%%), просрочено: %d\n" +
+			"Выполнено сегодня: %d\n" +
+			"Общий прогресс: %.0f%%",
+		"reminder": "⏰ Пора: «%s». Отметить — /done %s",
+		"error":    "Что-то пошло не так, попробуйте ещё раз.",
+	},
+	"en": {
+		"help": "Commands:\n" +
+			"/done Name — mark a habit as done\n" +
+			"/today — today's habits\n" +
+			"/stats — statistics\n" +
+			"/link CODE — link this chat to a user\n" +
+			"/unlink — unlink this chat",
+		"welcome":         "Hi! I log your habit completions.\n\nTo start, get a link code with POST /api/v1/users/:id/telegram-link and send it here as /link CODE.",
+		"unknown":         "Unknown command. /help lists the commands.",
+		"not_linked":      "This chat is not linked to a user. Get a code with POST /api/v1/users/:id/telegram-link and send /link CODE.",
+		"link_usage":      "Send your link code: /link CODE",
+		"link_invalid":    "The code is invalid or expired. Get a new one with POST /api/v1/users/:id/telegram-link",
+		"linked":          "Done, %s! This chat is linked. /today shows today's habits.",
+		"unlinked":        "This chat is unlinked.",
+		"done_usage":      "Add the habit name: /done Workout",
+		"habit_not_found": "Habit \"%s\" not found. /today lists your habits.",
+		"habit_ambiguous": "Several habits match: %s. Please be more specific.",
+		"already_done":    "\"%s\" is already done today 👍",
+		"done":            "✅ \"%s\" done! Check-ins this week: %d.",
+		"today_title":     "Today, %s:",
+		"today_empty":     "No habits yet.",
+		"stats": "Habits: %d of %d (%.0f%%)\n" +
+			"Goals: %d of %d (%.0f%%), overdue: %d\n" +
+			"Done today: %d\n" +
+			"Overall progress: %.0f%%",
+		"reminder": "⏰ Time for \"%s\". Mark it with /done %s",
+		"error":    "Something went wrong, please try again.",
+	},
+}
+
+var dateLayouts = map[string]string{
+	"ru": "02.01",
+	"en": "Jan 2",
+}
+
+func text(locale, key string, args ...interface{}) string {
+	catalog, ok := messages[locale]
+	if !ok {
+		catalog = messages[defaultLocale]
+	}
+	return fmt.Sprintf(catalog[key], args...)
+}
@@ -0,0 +1,164 @@
+// Package telegramtest — заглушка Bot API для проверки бота без Telegram.
+// Сообщения пользователей подкладываются через SendText, ответы бота копятся в памяти.
+package telegramtest
+
+import (
+	"encoding/json"
+	"habit-tracker-api/telegram"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sent — сообщение, которое бот отправил через sendMessage.
+type Sent struct {
+	ChatID int64  `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+type Server struct {
+	URL string
+
+	server   *http.Server
+	listener net.Listener
+
+	mu        sync.Mutex
+	updates   []telegram.Update
+	nextID    int
+	messageID int
+	arrived   chan struct{}
+	sent      []Sent
+	replies   chan Sent
+}
+
+// NewServer запускает заглушку на случайном порту 127.0.0.1.
+func NewServer() (*Server, error) {
+	return Listen("127.0.0.1:0")
+}
+
+func Listen(addr string) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		URL:      "http://" + listener.Addr().String(),
+		listener: listener,
+		nextID:   1,
+		arrived:  make(chan struct{}),
+		replies:  make(chan Sent, 100),
+	}
+	s.server = &http.Server{Handler: http.HandlerFunc(s.handle)}
+	go s.server.Serve(listener)
+	return s, nil
+}
+
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+// SendText добавляет сообщение пользователя из чата chatID.
+func (s *Server) SendText(chatID int64, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messageID++
+	s.updates = append(s.updates, telegram.Update{
+		UpdateID: s.nextID + len(s.updates),
+		Message: &telegram.Message{
+			MessageID: s.messageID,
+			From:      &telegram.From{ID: chatID, FirstName: "Test"},
+			Chat:      telegram.Chat{ID: chatID, Type: "private"},
+			Date:      time.Now().Unix(),
+			Text:      text,
+		},
+	})
+	close(s.arrived)
+	s.arrived = make(chan struct{})
+}
+
+// Sent возвращает копию всех ответов бота.
+func (s *Server) Sent() []Sent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Sent(nil), s.sent...)
+}
+
+// Replies — канал ответов бота, чтобы дождаться обработки сообщения.
+func (s *Server) Replies() <-chan Sent {
+	return s.replies
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	// Путь вида /bot<token>/<method>; токен заглушка не проверяет
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	switch method {
+	case "getUpdates":
+		var params struct {
+			Offset  int `json:"offset"`
+			Timeout int `json:"timeout"`
+		}
+		json.NewDecoder(r.Body).Decode(&params)
+		respond(w, s.getUpdates(r, params.Offset, time.Duration(params.Timeout)*time.Second))
+	case "sendMessage":
+		var sent Sent
+		if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+			fail(w, http.StatusBadRequest, "Bad Request: "+err.Error())
+			return
+		}
+		s.mu.Lock()
+		s.sent = append(s.sent, sent)
+		s.messageID++
+		id := s.messageID
+		s.mu.Unlock()
+		select {
+		case s.replies <- sent:
+		default:
+		}
+		respond(w, map[string]interface{}{"message_id": id, "chat": map[string]interface{}{"id": sent.ChatID}, "text": sent.Text})
+	default:
+		fail(w, http.StatusNotFound, "Not Found: method "+method+" is not supported by the stub")
+	}
+}
+
+// getUpdates отдаёт обновления начиная с offset, а если их нет — ждёт до timeout, как настоящий API.
+func (s *Server) getUpdates(r *http.Request, offset int, timeout time.Duration) []telegram.Update {
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		// Обновления до offset подтверждены и больше не отдаются
+		for len(s.updates) > 0 && s.updates[0].UpdateID < offset {
+			s.updates = s.updates[1:]
+			s.nextID++
+		}
+		updates := append([]telegram.Update{}, s.updates...)
+		arrived := s.arrived
+		s.mu.Unlock()
+
+		if len(updates) > 0 || timeout <= 0 {
+			return updates
+		}
+		select {
+		case <-arrived:
+		case <-deadline:
+			return updates
+		case <-r.Context().Done():
+			return updates
+		}
+	}
+}
+
+func respond(w http.ResponseWriter, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+func fail(w http.ResponseWriter, status int, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": false, "error_code": status, "description": description})
+}
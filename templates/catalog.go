@@ -0,0 +1,355 @@
+package templates
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"habit-tracker-api/calendar"
+	"habit-tracker-api/validation"
+	"maps"
+	"os"
+	"slices"
+	"unicode/utf8"
+)
+
+// DefaultLocale — язык каталога, если запрошенного у шаблона нет.
+const DefaultLocale = "ru"
+
+//go:embed catalog.json
+var builtinCatalog []byte
+
+// Text — название и описание шаблона на одном языке. Частота и единица у привычек
+// тоже переводятся: "ежедневно" и "daily" одинаково понимают напоминания и отчёты.
+// У цели набора Name — её название, Milestones — вехи.
+type Text struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Frequency   string   `json:"frequency,omitempty"`
+	Unit        string   `json:"unit,omitempty"`
+	Milestones  []string `json:"milestones,omitempty"`
+}
+
+// Template — привычка каталога со всеми переводами.
+type Template struct {
+	ID       string          `json:"id"`
+	Category string          `json:"category"`
+	Target   *float64        `json:"target,omitempty"`
+	Text     map[string]Text `json:"text"`
+}
+
+// Pack — стартовый набор из нескольких привычек каталога.
+type Pack struct {
+	ID     string          `json:"id"`
+	Habits []string        `json:"habits"`
+	Text   map[string]Text `json:"text"`
+}
+
+// Setup — готовый набор для начала: цель и привычки каталога, которые к ней ведут.
+type Setup struct {
+	ID     string          `json:"id"`
+	Goal   SetupGoal       `json:"goal"`
+	Habits []string        `json:"habits"`
+	Text   map[string]Text `json:"text"`
+}
+
+// SetupGoal — цель набора со всеми переводами.
+type SetupGoal struct {
+	// Days — срок в днях от применения набора. У повторяющейся цели без Days срок —
+	// конец текущего периода. Цель с вехами выполняется сама, когда выполнены все вехи
+	Days        int             `json:"days,omitempty"`
+	Recurrence  string          `json:"recurrence,omitempty"`
+	TargetValue *float64        `json:"target_value,omitempty"`
+	Text        map[string]Text `json:"text"`
+}
+
+// Catalog — шаблоны привычек, стартовые наборы и наборы целей. Встроенный каталог
+// дополняется файлом того же формата: запись с тем же id заменяет встроенную.
+type Catalog struct {
+	Templates []Template `json:"templates"`
+	Packs     []Pack     `json:"packs"`
+	Setups    []Setup    `json:"setups"`
+}
+
+// PackView — стартовый набор на одном языке, как его отдаёт API.
+type PackView struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Habits      []HabitTemplate `json:"habits"`
+}
+
+// LoadCatalog читает встроенный каталог и дополняет его файлом path, если он задан.
+func LoadCatalog(path string) (*Catalog, error) {
+	var catalog Catalog
+	if err := json.Unmarshal(builtinCatalog, &catalog); err != nil {
+		return nil, fmt.Errorf("built-in catalog: %w", err)
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var extra Catalog
+		if err := json.Unmarshal(data, &extra); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		catalog.merge(extra)
+	}
+
+	if err := catalog.validate(); err != nil {
+		return nil, err
+	}
+	return &catalog, nil
+}
+
+func (c *Catalog) merge(extra Catalog) {
+	c.Templates = mergeByID(c.Templates, extra.Templates, func(t Template) string { return t.ID })
+	c.Packs = mergeByID(c.Packs, extra.Packs, func(p Pack) string { return p.ID })
+	c.Setups = mergeByID(c.Setups, extra.Setups, func(s Setup) string { return s.ID })
+}
+
+// mergeByID заменяет записи items записями extra с тем же id, остальные добавляет в конец.
+func mergeByID[T any](items, extra []T, id func(T) string) []T {
+	for _, item := range extra {
+		replaced := false
+		for i := range items {
+			if id(items[i]) == id(item) {
+				items[i], replaced = item, true
+			}
+		}
+		if !replaced {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// validate проверяет шаблоны теми же правилами, что и создание привычки через API.
+// Номера шаблонов и наборов общие: по ним же применяется POST /templates/:id/apply.
+// Наборы целей применяются через /goal-templates/:id/apply, и номера у них свои.
+func (c *Catalog) validate() error {
+	var errs []error
+	seen := make(map[string]bool)
+	for _, template := range c.Templates {
+		errs = append(errs, checkID(template.ID, seen))
+		if !validation.IsKnownCategory(template.Category) {
+			errs = append(errs, fmt.Errorf("template %q: unknown category %q", template.ID, template.Category))
+		}
+		if template.Target != nil && *template.Target <= 0 {
+			errs = append(errs, fmt.Errorf("template %q: target must be greater than 0", template.ID))
+		}
+		if len(template.Text) == 0 {
+			errs = append(errs, fmt.Errorf("template %q: text is required", template.ID))
+		}
+		for locale, text := range template.Text {
+			if n := utf8.RuneCountInString(text.Name); n == 0 || n > 100 {
+				errs = append(errs, fmt.Errorf("template %q (%s): name must be 1 to 100 characters long", template.ID, locale))
+			}
+			if _, ok := calendar.RRule(text.Frequency); !ok {
+				errs = append(errs, fmt.Errorf("template %q (%s): unknown frequency %q", template.ID, locale, text.Frequency))
+			}
+		}
+	}
+
+	for _, pack := range c.Packs {
+		errs = append(errs, checkID(pack.ID, seen))
+		if len(pack.Habits) == 0 {
+			errs = append(errs, fmt.Errorf("pack %q: habits are required", pack.ID))
+		}
+		for _, id := range pack.Habits {
+			if _, ok := c.template(id); !ok {
+				errs = append(errs, fmt.Errorf("pack %q: unknown template %q", pack.ID, id))
+			}
+		}
+		if len(pack.Text) == 0 {
+			errs = append(errs, fmt.Errorf("pack %q: text is required", pack.ID))
+		}
+	}
+
+	seenSetups := make(map[string]bool)
+	for _, setup := range c.Setups {
+		errs = append(errs, checkID(setup.ID, seenSetups))
+		errs = append(errs, c.validateSetup(setup)...)
+	}
+	return errors.Join(errs...)
+}
+
+// validateSetup проверяет набор целей теми же правилами, что и создание цели через API.
+func (c *Catalog) validateSetup(setup Setup) []error {
+	var errs []error
+	if len(setup.Habits) == 0 {
+		errs = append(errs, fmt.Errorf("setup %q: habits are required", setup.ID))
+	}
+	for _, id := range setup.Habits {
+		if _, ok := c.template(id); !ok {
+			errs = append(errs, fmt.Errorf("setup %q: unknown template %q", setup.ID, id))
+		}
+	}
+	if len(setup.Text) == 0 {
+		errs = append(errs, fmt.Errorf("setup %q: text is required", setup.ID))
+	}
+
+	goal := setup.Goal
+	if goal.Days < 0 {
+		errs = append(errs, fmt.Errorf("setup %q: goal days must not be negative", setup.ID))
+	}
+	if goal.Recurrence != "" && !calendar.IsPeriod(goal.Recurrence) {
+		errs = append(errs, fmt.Errorf("setup %q: unknown goal recurrence %q", setup.ID, goal.Recurrence))
+	}
+	if goal.TargetValue != nil && *goal.TargetValue <= 0 {
+		errs = append(errs, fmt.Errorf("setup %q: goal target_value must be greater than 0", setup.ID))
+	}
+	if len(goal.Text) == 0 {
+		errs = append(errs, fmt.Errorf("setup %q: goal text is required", setup.ID))
+	}
+	for locale, text := range goal.Text {
+		if n := utf8.RuneCountInString(text.Name); n == 0 || n > 200 {
+			errs = append(errs, fmt.Errorf("setup %q (%s): goal name must be 1 to 200 characters long", setup.ID, locale))
+		}
+		if utf8.RuneCountInString(text.Unit) > 20 {
+			errs = append(errs, fmt.Errorf("setup %q (%s): goal unit must be at most 20 characters long", setup.ID, locale))
+		}
+	}
+	return errs
+}
+
+func checkID(id string, seen map[string]bool) error {
+	if id == "" {
+		return errors.New("template or pack without id")
+	}
+	if seen[id] {
+		return fmt.Errorf("duplicate template or pack id %q", id)
+	}
+	seen[id] = true
+	return nil
+}
+
+func (c *Catalog) template(id string) (Template, bool) {
+	for _, template := range c.Templates {
+		if template.ID == id {
+			return template, true
+		}
+	}
+	return Template{}, false
+}
+
+// Habits — шаблоны привычек на языке locale.
+func (c *Catalog) Habits(locale string) []HabitTemplate {
+	habits := make([]HabitTemplate, 0, len(c.Templates))
+	for _, template := range c.Templates {
+		habits = append(habits, template.localize(locale))
+	}
+	return habits
+}
+
+// PackViews — стартовые наборы на языке locale.
+func (c *Catalog) PackViews(locale string) []PackView {
+	packs := make([]PackView, 0, len(c.Packs))
+	for _, pack := range c.Packs {
+		packs = append(packs, c.localizePack(pack, locale))
+	}
+	return packs
+}
+
+// Lookup возвращает привычки, которые создаёт шаблон или стартовый набор id.
+func (c *Catalog) Lookup(id, locale string) ([]HabitTemplate, bool) {
+	if template, ok := c.template(id); ok {
+		return []HabitTemplate{template.localize(locale)}, true
+	}
+	for _, pack := range c.Packs {
+		if pack.ID == id {
+			return c.localizePack(pack, locale).Habits, true
+		}
+	}
+	return nil, false
+}
+
+// SetupViews — наборы целей на языке locale.
+func (c *Catalog) SetupViews(locale string) []SetupView {
+	setups := make([]SetupView, 0, len(c.Setups))
+	for _, setup := range c.Setups {
+		setups = append(setups, c.localizeSetup(setup, locale))
+	}
+	return setups
+}
+
+// LookupSetup возвращает набор целей id на языке locale.
+func (c *Catalog) LookupSetup(id, locale string) (SetupView, bool) {
+	for _, setup := range c.Setups {
+		if setup.ID == id {
+			return c.localizeSetup(setup, locale), true
+		}
+	}
+	return SetupView{}, false
+}
+
+func (t Template) localize(locale string) HabitTemplate {
+	text := translate(t.Text, locale)
+	return HabitTemplate{
+		ID:          t.ID,
+		Name:        text.Name,
+		Description: text.Description,
+		Category:    t.Category,
+		Frequency:   text.Frequency,
+		Target:      t.Target,
+		Unit:        text.Unit,
+	}
+}
+
+func (c *Catalog) localizePack(pack Pack, locale string) PackView {
+	text := translate(pack.Text, locale)
+	view := PackView{
+		ID:          pack.ID,
+		Name:        text.Name,
+		Description: text.Description,
+		Habits:      make([]HabitTemplate, 0, len(pack.Habits)),
+	}
+	for _, id := range pack.Habits {
+		if template, ok := c.template(id); ok {
+			view.Habits = append(view.Habits, template.localize(locale))
+		}
+	}
+	return view
+}
+
+func (c *Catalog) localizeSetup(setup Setup, locale string) SetupView {
+	text := translate(setup.Text, locale)
+	goal := translate(setup.Goal.Text, locale)
+	view := SetupView{
+		ID:          setup.ID,
+		Title:       text.Name,
+		Description: text.Description,
+		Goal: GoalTemplate{
+			Title:       goal.Name,
+			Description: goal.Description,
+			Days:        setup.Goal.Days,
+			Recurrence:  setup.Goal.Recurrence,
+			TargetValue: setup.Goal.TargetValue,
+			Unit:        goal.Unit,
+			Milestones:  goal.Milestones,
+		},
+		Habits: make([]HabitTemplate, 0, len(setup.Habits)),
+	}
+	for _, id := range setup.Habits {
+		if template, ok := c.template(id); ok {
+			view.Habits = append(view.Habits, template.localize(locale))
+		}
+	}
+	return view
+}
+
+// translate выбирает перевод: запрошенный язык, язык по умолчанию или любой имеющийся.
+func translate(texts map[string]Text, locale string) Text {
+	if text, ok := texts[locale]; ok {
+		return text
+	}
+	if text, ok := texts[DefaultLocale]; ok {
+		return text
+	}
+	for _, locale := range slices.Sorted(maps.Keys(texts)) {
+		return texts[locale]
+	}
+	return Text{}
+}
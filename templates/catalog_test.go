@@ -0,0 +1,77 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCatalog(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCatalogSetups(t *testing.T) {
+	path := writeCatalog(t, `{
+		"setups": [{
+			"id": "reading",
+			"habits": ["reading", "journal"],
+			"goal": { "days": 30, "text": { "ru": { "name": "Книга за месяц" } } },
+			"text": { "ru": { "name": "Книга за месяц" } }
+		}]
+	}`)
+	catalog, err := LoadCatalog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setup, ok := catalog.LookupSetup("reading", "en")
+	if !ok {
+		t.Fatal("setup reading not found")
+	}
+	// У замены нет английского текста: берётся русский, а привычки — на английском
+	if setup.Goal.Title != "Книга за месяц" || setup.Goal.Days != 30 || setup.Goal.Recurrence != "" {
+		t.Errorf("goal = %+v, want the setup from the file", setup.Goal)
+	}
+	if len(setup.Habits) != 2 || setup.Habits[0].Name != "Reading" || setup.Habits[1].Frequency != "daily" {
+		t.Errorf("habits = %+v", setup.Habits)
+	}
+
+	english, ok := catalog.LookupSetup("english", "en")
+	if !ok || english.Goal.Title != "English at B1 level" || len(english.Goal.Milestones) != 3 {
+		t.Errorf("built-in setup english = %+v", english)
+	}
+	if n := len(catalog.SetupViews("ru")); n != 5 {
+		t.Errorf("%d setups, want 5 built-in with one replaced", n)
+	}
+}
+
+func TestCatalogSetupValidation(t *testing.T) {
+	path := writeCatalog(t, `{
+		"setups": [{
+			"id": "marathon",
+			"habits": ["run", "swim"],
+			"goal": { "recurrence": "daily", "target_value": -1, "text": { "ru": { "name": "" } } },
+			"text": { "ru": { "name": "Марафон" } }
+		}]
+	}`)
+	_, err := LoadCatalog(path)
+	if err == nil {
+		t.Fatal("invalid setup was loaded")
+	}
+	for _, want := range []string{
+		`unknown template "swim"`,
+		`unknown goal recurrence "daily"`,
+		"goal target_value must be greater than 0",
+		"goal name must be 1 to 200 characters long",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error has no %q:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package templates
+
+import (
+	"fmt"
+	"habit-tracker-api/calendar"
+	"habit-tracker-api/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SetupView — набор целей на одном языке, как его отдаёт API и применяет ApplySetup.
+type SetupView struct {
+	ID          string          `json:"id"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Goal        GoalTemplate    `json:"goal"`
+	Habits      []HabitTemplate `json:"habits"`
+}
+
+// HabitTemplate — привычка набора или каталога на одном языке.
+type HabitTemplate struct {
+	ID          string   `json:"id,omitempty"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Category    string   `json:"category"`
+	Frequency   string   `json:"frequency"`
+	Target      *float64 `json:"target,omitempty"`
+	Unit        string   `json:"unit,omitempty"`
+}
+
+// GoalTemplate — цель набора на одном языке. Days, Recurrence и TargetValue — как у SetupGoal.
+type GoalTemplate struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Days        int      `json:"days,omitempty"`
+	Recurrence  string   `json:"recurrence,omitempty"`
+	TargetValue *float64 `json:"target_value,omitempty"`
+	Unit        string   `json:"unit,omitempty"`
+	Milestones  []string `json:"milestones,omitempty"`
+}
+
+// HabitStore — операции хранилища, нужные созданию привычек. Реализуется storage.Tx.
+type HabitStore interface {
+	GetAllHabits() ([]models.Habit, error)
+	CreateHabit(habit *models.Habit) error
+}
+
+// Store — операции хранилища, нужные применению набора. Реализуется storage.Tx.
+type Store interface {
+	HabitStore
+	CreateGoal(goal *models.Goal) error
+}
+
+type Result struct {
+	Goal   models.Goal    `json:"goal"`
+	Habits []models.Habit `json:"habits"`
+	// HabitsCreated — сколько привычек создано; остальные уже были у пользователя
+	HabitsCreated int `json:"habits_created"`
+}
+
+// ApplySetup создаёт для пользователя userID цель набора, связанную с его привычками.
+func ApplySetup(store Store, setup SetupView, userID int, now time.Time) (*Result, error) {
+	habits, created, err := CreateHabits(store, setup.Habits, userID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	goal := setup.Goal.goal(now)
+	goal.UserID = userID
+	goal.HabitIDs = []int{}
+	for _, habit := range habits {
+		goal.HabitIDs = append(goal.HabitIDs, habit.ID)
+	}
+	if err := store.CreateGoal(&goal); err != nil {
+		return nil, fmt.Errorf("goal %q: %w", goal.Title, err)
+	}
+	return &Result{Goal: goal, Habits: habits, HabitsCreated: created}, nil
+}
+
+// CreateHabits создаёт пользователю userID привычки по шаблонам и возвращает их вместе
+// с числом созданных. Привычка, которая у пользователя уже есть (по имени, как при импорте),
+// не создаётся заново, а возвращается существующая.
+func CreateHabits(store HabitStore, templates []HabitTemplate, userID int, now time.Time) ([]models.Habit, int, error) {
+	habits, err := store.GetAllHabits()
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.Slice(habits, func(i, j int) bool { return habits[i].ID < habits[j].ID })
+	existing := make(map[string]models.Habit, len(habits))
+	for _, habit := range habits {
+		if habit.UserID != 0 && habit.UserID != userID {
+			continue
+		}
+		if _, ok := existing[nameKey(habit.Name)]; !ok {
+			existing[nameKey(habit.Name)] = habit
+		}
+	}
+
+	result := []models.Habit{}
+	created := 0
+	for _, template := range templates {
+		habit, ok := existing[nameKey(template.Name)]
+		if !ok {
+			habit = models.Habit{
+				UserID:      userID,
+				Name:        template.Name,
+				Description: template.Description,
+				Category:    template.Category,
+				Frequency:   template.Frequency,
+				Target:      template.Target,
+				Unit:        template.Unit,
+				CreatedAt:   now,
+			}
+			if err := store.CreateHabit(&habit); err != nil {
+				return nil, 0, fmt.Errorf("habit %q: %w", template.Name, err)
+			}
+			existing[nameKey(habit.Name)] = habit
+			created++
+		}
+		result = append(result, habit)
+	}
+	return result, created, nil
+}
+
+// goal — цель шаблона со сроком от now.
+func (t GoalTemplate) goal(now time.Time) models.Goal {
+	goal := models.Goal{
+		Title:        t.Title,
+		Description:  t.Description,
+		TargetDate:   t.targetDate(now),
+		CreatedAt:    now,
+		AutoComplete: len(t.Milestones) > 0,
+		TargetValue:  t.TargetValue,
+		Unit:         t.Unit,
+		Recurrence:   t.Recurrence,
+	}
+	for _, title := range t.Milestones {
+		goal.Milestones = append(goal.Milestones, models.Milestone{Title: title})
+	}
+	return goal
+}
+
+func (t GoalTemplate) targetDate(now time.Time) time.Time {
+	year, month, day := now.Date()
+	if t.Days > 0 || t.Recurrence == "" {
+		return time.Date(year, month, day+max(t.Days, 1), 0, 0, 0, 0, now.Location())
+	}
+	// В последний день периода цель была бы просрочена сразу: берём следующий период
+	end := calendar.PeriodEnd(now, t.Recurrence)
+	if !end.After(now) {
+		end = calendar.PeriodEnd(now.AddDate(0, 0, 1), t.Recurrence)
+	}
+	return end
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,212 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"habit-tracker-api/apperror"
+	"habit-tracker-api/webhooks"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// KnownCategories — допустимые категории привычек.
+var KnownCategories = []string{
+	"здоровье",
+	"спорт",
+	"обучение",
+	"работа",
+	"финансы",
+	"саморазвитие",
+	"отношения",
+	"хобби",
+	"другое",
+}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// В ошибках используем имена полей из JSON, а не из Go
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+
+	v.RegisterValidation("category", isKnownCategory)
+	v.RegisterValidation("notfuture", isNotFuture)
+	v.RegisterValidation("notbeforefield", isNotBeforeField)
+	v.RegisterValidation("clock", isClock)
+	v.RegisterValidation("webhookevent", isWebhookEvent)
+
+	return v
+}
+
+// Struct проверяет структуры по тегам validate и возвращает все ошибки полей сразу.
+func Struct(values ...interface{}) error {
+	var fields []apperror.FieldError
+	for _, value := range values {
+		err := validate.Struct(value)
+		if err == nil {
+			continue
+		}
+
+		var errs validator.ValidationErrors
+		if !errors.As(err, &errs) {
+			return err
+		}
+		for _, fe := range errs {
+			fields = append(fields, fieldError(fe))
+		}
+	}
+
+	if len(fields) > 0 {
+		return apperror.Validation(fields...)
+	}
+	return nil
+}
+
+func IsKnownCategory(category string) bool {
+	for _, known := range KnownCategories {
+		if strings.EqualFold(category, known) {
+			return true
+		}
+	}
+	return false
+}
+
+func isKnownCategory(fl validator.FieldLevel) bool {
+	return IsKnownCategory(fl.Field().String())
+}
+
+// isNotFuture допускает любую дату до конца сегодняшнего дня.
+func isNotFuture(fl validator.FieldLevel) bool {
+	date, ok := fl.Field().Interface().(time.Time)
+	if !ok || date.IsZero() {
+		return true
+	}
+
+	now := time.Now().In(date.Location())
+	endOfToday := startOfDay(now).AddDate(0, 0, 1)
+	return date.Before(endOfToday)
+}
+
+// isNotBeforeField сравнивает даты (без времени) с другим полем той же структуры.
+func isNotBeforeField(fl validator.FieldLevel) bool {
+	date, ok := fl.Field().Interface().(time.Time)
+	if !ok || date.IsZero() {
+		return true
+	}
+
+	other := fl.Parent().FieldByName(fl.Param())
+	if !other.IsValid() {
+		return true
+	}
+	bound, ok := other.Interface().(time.Time)
+	if !ok || bound.IsZero() {
+		return true
+	}
+
+	return !startOfDay(date).Before(startOfDay(bound.In(date.Location())))
+}
+
+// isClock проверяет время суток в формате "15:04".
+func isClock(fl validator.FieldLevel) bool {
+	_, err := time.Parse("15:04", fl.Field().String())
+	return err == nil
+}
+
+// isWebhookEvent допускает события из webhooks.Events и "*", чтобы список не
+// расходился с тем, что отправляет диспетчер.
+func isWebhookEvent(fl validator.FieldLevel) bool {
+	return webhooks.Known(fl.Field().String())
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func fieldError(fe validator.FieldError) apperror.FieldError {
+	// Namespace вида "BatchRequest.operations[0].op" — отбрасываем имя корневой структуры
+	field := fe.Namespace()
+	if i := strings.Index(field, "."); i >= 0 {
+		field = field[i+1:]
+	}
+
+	return apperror.FieldError{
+		Field:   field,
+		Code:    fe.Tag(),
+		Message: message(fe),
+	}
+}
+
+func message(fe validator.FieldError) string {
+	field := fe.Field()
+	switch fe.Tag() {
+	case "required", "required_unless", "required_if":
+		return field + " is required"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("%s must be at least %s characters long", field, fe.Param())
+		}
+		return fmt.Sprintf("%s must be at least %s", field, fe.Param())
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("%s must be at most %s characters long", field, fe.Param())
+		}
+		return fmt.Sprintf("%s must be at most %s", field, fe.Param())
+	case "url", "http_url":
+		return field + " must be a valid http(s) URL"
+	case "email":
+		return field + " must be a valid email address"
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, fe.Param())
+	case "gte":
+		return fmt.Sprintf("%s must be at least %s", field, fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "category":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.Join(KnownCategories, ", "))
+	case "notfuture":
+		return field + " must not be in the future"
+	case "notbeforefield":
+		return fmt.Sprintf("%s must not be before %s", field, toSnake(fe.Param()))
+	case "clock":
+		return field + " must be a time of day in HH:MM format"
+	case "webhookevent":
+		return fmt.Sprintf("%s must be one of: %s, %s", field, webhooks.AllEvents, strings.Join(webhooks.Events, ", "))
+	case "timezone":
+		return field + " must be an IANA time zone, e.g. Europe/Moscow"
+	case "required_with":
+		return fmt.Sprintf("%s is required together with %s", field, toSnake(fe.Param()))
+	case "required_without":
+		return fmt.Sprintf("%s is required when %s is not set", field, toSnake(fe.Param()))
+	case "excluded_with":
+		return fmt.Sprintf("%s must not be set together with %s", field, toSnake(fe.Param()))
+	case "nefield":
+		return fmt.Sprintf("%s must differ from %s", field, toSnake(fe.Param()))
+	case "unique":
+		return field + " must not contain duplicates"
+	default:
+		return fmt.Sprintf("%s is invalid (%s)", field, fe.Tag())
+	}
+}
+
+// toSnake переводит имя поля Go (CreatedAt) в JSON-имя (created_at).
+func toSnake(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToLower(b.String())
+}
@@ -0,0 +1,334 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"habit-tracker-api/events"
+	"habit-tracker-api/models"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Store — подписки, по которым рассылаются события. Реализуется storage.JSONStorage.
+type Store interface {
+	GetAllWebhooks() ([]models.Webhook, error)
+	GetWebhookByID(id int) (*models.Webhook, error)
+}
+
+type Options struct {
+	Workers     int
+	QueueSize   int
+	MaxAttempts int
+	// Backoff — задержка перед повтором после attempt-й неудачной попытки
+	Backoff func(attempt int) time.Duration
+	Client  *http.Client
+	Now     func() time.Time
+}
+
+func (o Options) withDefaults() Options {
+	if o.Workers <= 0 {
+		o.Workers = 4
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 1000
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.Backoff == nil {
+		o.Backoff = ExponentialBackoff(2*time.Second, 10*time.Minute)
+	}
+	if o.Client == nil {
+		o.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if o.Now == nil {
+		o.Now = time.Now
+	}
+	return o
+}
+
+// ExponentialBackoff удваивает задержку после каждой попытки: base, 2*base, 4*base... но не больше max.
+func ExponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 1; i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+		if delay > max {
+			delay = max
+		}
+		return delay
+	}
+}
+
+type job struct {
+	webhook models.Webhook
+	event   Event
+	body    []byte
+	attempt int
+}
+
+// Dispatcher рассылает события подписчикам в фоне: подписанный HMAC запрос,
+// повторы с растущей задержкой и журнал попыток.
+type Dispatcher struct {
+	store Store
+	opts  Options
+	queue chan job
+	log   *DeliveryLog
+	ctx   context.Context
+}
+
+func NewDispatcher(store Store, opts Options) *Dispatcher {
+	opts = opts.withDefaults()
+	return &Dispatcher{
+		store: store,
+		opts:  opts,
+		queue: make(chan job, opts.QueueSize),
+		log:   NewDeliveryLog(),
+		ctx:   context.Background(),
+	}
+}
+
+// Start запускает обработчики очереди. Они завершаются вместе с ctx.
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.ctx = ctx
+	for i := 0; i < d.opts.Workers; i++ {
+		go d.work(ctx)
+	}
+}
+
+func (d *Dispatcher) Log() *DeliveryLog {
+	return d.log
+}
+
+// Subscribe подписывает рассылку на события хранилища.
+func (d *Dispatcher) Subscribe(bus *events.Bus) (unsubscribe func()) {
+	return bus.Subscribe("webhooks", func(event events.Event) {
+		d.Emit(event.Name(), event.UserID(), event.Payload())
+	})
+}
+
+// Emit ставит событие в очередь для всех активных подписок, которым оно подходит.
+// Подписка пользователя получает события его записей и общих записей, подписка без владельца — все.
+func (d *Dispatcher) Emit(eventType string, userID int, data interface{}) {
+	webhooks, err := d.store.GetAllWebhooks()
+	if err != nil {
+		log.Printf("webhooks: failed to load subscriptions: %v", err)
+		return
+	}
+
+	event := Event{
+		ID:        newEventID(),
+		Type:      eventType,
+		CreatedAt: d.opts.Now(),
+		Data:      data,
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Active || !Matches(webhook.Events, eventType) {
+			continue
+		}
+		if webhook.UserID != 0 && userID != 0 && webhook.UserID != userID {
+			continue
+		}
+		d.Send(webhook, event)
+	}
+}
+
+// Send ставит доставку события одной подписке в очередь.
+func (d *Dispatcher) Send(webhook models.Webhook, event Event) {
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = d.opts.Now()
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("webhooks: failed to encode %s: %v", event.Type, err)
+		return
+	}
+
+	d.enqueue(job{webhook: webhook, event: event, body: body, attempt: 1})
+}
+
+func (d *Dispatcher) enqueue(j job) {
+	select {
+	case d.queue <- j:
+	default:
+		// Очередь переполнена: не блокируем обработку запроса, а отмечаем потерю в журнале
+		d.log.add(Delivery{
+			EventID:     j.event.ID,
+			Event:       j.event.Type,
+			WebhookID:   j.webhook.ID,
+			Attempt:     j.attempt,
+			Status:      DeliveryDropped,
+			Error:       "delivery queue is full",
+			AttemptedAt: d.opts.Now(),
+		})
+	}
+}
+
+func (d *Dispatcher) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-d.queue:
+			d.deliver(ctx, j)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, j job) {
+	if j.attempt > 1 && !d.refresh(&j) {
+		return
+	}
+
+	started := d.opts.Now()
+	statusCode, err := d.post(ctx, j)
+
+	delivery := Delivery{
+		EventID:     j.event.ID,
+		Event:       j.event.Type,
+		WebhookID:   j.webhook.ID,
+		Attempt:     j.attempt,
+		StatusCode:  statusCode,
+		AttemptedAt: started,
+		DurationMS:  d.opts.Now().Sub(started).Milliseconds(),
+	}
+
+	if err == nil {
+		delivery.Status = DeliverySucceeded
+		d.log.add(delivery)
+		return
+	}
+
+	delivery.Error = err.Error()
+	if j.attempt >= d.opts.MaxAttempts {
+		delivery.Status = DeliveryFailed
+		d.log.add(delivery)
+		log.Printf("webhooks: giving up on %s for webhook %d after %d attempts: %v", j.event.Type, j.webhook.ID, j.attempt, err)
+		return
+	}
+
+	delay := d.opts.Backoff(j.attempt)
+	next := started.Add(delay)
+	delivery.Status = DeliveryRetrying
+	delivery.NextRetryAt = &next
+	d.log.add(delivery)
+
+	j.attempt++
+	time.AfterFunc(delay, func() {
+		if d.ctx.Err() == nil {
+			d.enqueue(j)
+		}
+	})
+}
+
+// refresh перечитывает подписку перед повтором: с первой попытки её могли удалить,
+// выключить, сменить адрес или секрет. Возвращает false, если доставку нужно отменить.
+func (d *Dispatcher) refresh(j *job) bool {
+	webhook, err := d.store.GetWebhookByID(j.webhook.ID)
+	if err != nil {
+		// Журнал удалённой подписки уже очищен, записывать в него нечего
+		log.Printf("webhooks: dropping %s for webhook %d: %v", j.event.Type, j.webhook.ID, err)
+		return false
+	}
+
+	// Проверочное событие доставляется и выключенной подписке
+	if j.event.Type != Ping && (!webhook.Active || !Matches(webhook.Events, j.event.Type)) {
+		d.log.add(Delivery{
+			EventID:     j.event.ID,
+			Event:       j.event.Type,
+			WebhookID:   j.webhook.ID,
+			Attempt:     j.attempt,
+			Status:      DeliveryDropped,
+			Error:       "webhook is inactive or no longer subscribed to the event",
+			AttemptedAt: d.opts.Now(),
+		})
+		return false
+	}
+
+	j.webhook = *webhook
+	return true
+}
+
+func (d *Dispatcher) post(ctx context.Context, j job) (int, error) {
+	timestamp := strconv.FormatInt(d.opts.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.webhook.URL, bytes.NewReader(j.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "habit-tracker-api-webhooks/1.0")
+	req.Header.Set(HeaderEvent, j.event.Type)
+	req.Header.Set(HeaderDelivery, j.event.ID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(j.webhook.Secret, timestamp, j.body))
+
+	resp, err := d.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign возвращает подпись "sha256=<hex>" от HMAC-SHA256(secret, timestamp + "." + body).
+// Метка времени входит в подпись, чтобы получатель мог отклонять повторно отправленные запросы.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись запроса. Нужен получателям, написанным на Go, и тестам.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+func newEventID() string {
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return "evt_" + hex.EncodeToString(buf)
+}
+
+// NewSecret генерирует секрет подписи для новой подписки.
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// Forget очищает журнал удалённой подписки.
+func (d *Dispatcher) Forget(webhookID int) {
+	d.log.forget(webhookID)
+}
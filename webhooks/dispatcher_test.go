@@ -0,0 +1,377 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"habit-tracker-api/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStore — подписки в памяти вместо storage.JSONStorage.
+type memoryStore struct {
+	mu       sync.Mutex
+	webhooks map[int]models.Webhook
+}
+
+func newMemoryStore(webhooks ...models.Webhook) *memoryStore {
+	s := &memoryStore{webhooks: make(map[int]models.Webhook)}
+	for _, webhook := range webhooks {
+		s.webhooks[webhook.ID] = webhook
+	}
+	return s
+}
+
+func (s *memoryStore) GetAllWebhooks() ([]models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []models.Webhook
+	for _, webhook := range s.webhooks {
+		list = append(list, webhook)
+	}
+	return list, nil
+}
+
+func (s *memoryStore) GetWebhookByID(id int) (*models.Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	webhook, ok := s.webhooks[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &webhook, nil
+}
+
+func (s *memoryStore) update(id int, update func(webhook *models.Webhook)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	webhook := s.webhooks[id]
+	update(&webhook)
+	s.webhooks[id] = webhook
+}
+
+func (s *memoryStore) delete(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.webhooks, id)
+}
+
+// request — запрос, который получил тестовый подписчик.
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver отвечает статусами из statuses по очереди, после них — 200.
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	requests []request
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	r := &receiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.requests = append(r.requests, request{header: req.Header.Clone(), body: body})
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		r.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) received() []request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]request(nil), r.requests...)
+}
+
+// startDispatcher запускает рассылку с мгновенными повторами. onRetry вызывается
+// после неудачной попытки attempt, до того как повтор встанет в очередь.
+func startDispatcher(t *testing.T, store Store, maxAttempts int, onRetry func(attempt int)) *Dispatcher {
+	d := NewDispatcher(store, Options{
+		Workers:     1,
+		MaxAttempts: maxAttempts,
+		Backoff: func(attempt int) time.Duration {
+			if onRetry != nil {
+				onRetry(attempt)
+			}
+			return time.Millisecond
+		},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	d.Start(ctx)
+	return d
+}
+
+// waitDeliveries ждёт, пока в журнале подписки не станет n записей, и возвращает их от старых к новым.
+func waitDeliveries(t *testing.T, d *Dispatcher, webhookID, n int) []Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		list := d.Log().List(webhookID)
+		if len(list) >= n {
+			for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+				list[i], list[j] = list[j], list[i]
+			}
+			return list
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d deliveries, want %d: %+v", len(list), n, list)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func statuses(deliveries []Delivery) []string {
+	list := make([]string, len(deliveries))
+	for i, delivery := range deliveries {
+		list[i] = delivery.Status
+	}
+	return list
+}
+
+func expectStatuses(t *testing.T, deliveries []Delivery, want ...string) {
+	t.Helper()
+	got := statuses(deliveries)
+	if len(got) != len(want) {
+		t.Fatalf("statuses = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("statuses = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSignature(t *testing.T) {
+	r := newReceiver(t)
+	store := newMemoryStore(models.Webhook{ID: 1, URL: r.URL, Secret: "whsec_test", Events: []string{AllEvents}, Active: true})
+	d := startDispatcher(t, store, 1, nil)
+
+	d.Emit(HabitCreated, 0, map[string]string{"name": "Бег"})
+	waitDeliveries(t, d, 1, 1)
+
+	requests := r.received()
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	req := requests[0]
+
+	timestamp := req.header.Get(HeaderTimestamp)
+	signature := req.header.Get(HeaderSignature)
+	if !Verify("whsec_test", timestamp, req.body, signature) {
+		t.Errorf("signature %q does not match the body", signature)
+	}
+	if Verify("whsec_other", timestamp, req.body, signature) {
+		t.Error("signature matches a different secret")
+	}
+	if Verify("whsec_test", timestamp, append(req.body, ' '), signature) {
+		t.Error("signature matches a modified body")
+	}
+
+	var event Event
+	if err := json.Unmarshal(req.body, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != HabitCreated || req.header.Get(HeaderEvent) != HabitCreated {
+		t.Errorf("event = %q, header = %q, want %q", event.Type, req.header.Get(HeaderEvent), HabitCreated)
+	}
+	if event.ID == "" || req.header.Get(HeaderDelivery) != event.ID {
+		t.Errorf("delivery header = %q, event id = %q", req.header.Get(HeaderDelivery), event.ID)
+	}
+}
+
+func TestEmitFiltersSubscriptions(t *testing.T) {
+	r := newReceiver(t)
+	store := newMemoryStore(
+		models.Webhook{ID: 1, URL: r.URL, Events: []string{GoalCreated}, Active: true},
+		models.Webhook{ID: 2, URL: r.URL, Events: []string{AllEvents}, Active: false},
+		models.Webhook{ID: 3, URL: r.URL, Events: []string{AllEvents}, Active: true, UserID: 2},
+		models.Webhook{ID: 4, URL: r.URL, Events: []string{HabitCreated}, Active: true, UserID: 1},
+	)
+	d := startDispatcher(t, store, 1, nil)
+
+	d.Emit(HabitCreated, 1, nil)
+	waitDeliveries(t, d, 4, 1)
+
+	for _, id := range []int{1, 2, 3} {
+		if list := d.Log().List(id); len(list) != 0 {
+			t.Errorf("webhook %d got deliveries %+v", id, list)
+		}
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(2*time.Second, 10*time.Second)
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{4, 10 * time.Second},
+		{10, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestRetryUntilSuccess(t *testing.T) {
+	r := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+	store := newMemoryStore(models.Webhook{ID: 1, URL: r.URL, Events: []string{AllEvents}, Active: true})
+
+	var mu sync.Mutex
+	var attempts []int
+	d := startDispatcher(t, store, 5, func(attempt int) {
+		mu.Lock()
+		attempts = append(attempts, attempt)
+		mu.Unlock()
+	})
+
+	d.Emit(GoalCompleted, 0, nil)
+	deliveries := waitDeliveries(t, d, 1, 3)
+
+	expectStatuses(t, deliveries, DeliveryRetrying, DeliveryRetrying, DeliverySucceeded)
+	for i, delivery := range deliveries {
+		if delivery.Attempt != i+1 {
+			t.Errorf("delivery %d: attempt = %d", i, delivery.Attempt)
+		}
+		if delivery.EventID != deliveries[0].EventID {
+			t.Errorf("delivery %d: event id changed to %q", i, delivery.EventID)
+		}
+	}
+	if deliveries[0].StatusCode != http.StatusInternalServerError || deliveries[0].NextRetryAt == nil {
+		t.Errorf("first delivery = %+v", deliveries[0])
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Errorf("backoff called for attempts %v, want [1 2]", attempts)
+	}
+}
+
+func TestRetryExhaustion(t *testing.T) {
+	r := newReceiver(t, 500, 500, 500, 500)
+	store := newMemoryStore(models.Webhook{ID: 1, URL: r.URL, Events: []string{AllEvents}, Active: true})
+	d := startDispatcher(t, store, 3, nil)
+
+	d.Emit(TrackCreated, 0, nil)
+	deliveries := waitDeliveries(t, d, 1, 3)
+	expectStatuses(t, deliveries, DeliveryRetrying, DeliveryRetrying, DeliveryFailed)
+	if deliveries[2].NextRetryAt != nil {
+		t.Errorf("last delivery schedules a retry: %+v", deliveries[2])
+	}
+
+	// После последней попытки запросов больше нет
+	time.Sleep(50 * time.Millisecond)
+	if n := len(r.received()); n != 3 {
+		t.Errorf("receiver got %d requests, want 3", n)
+	}
+}
+
+func TestRetryUsesCurrentSecret(t *testing.T) {
+	r := newReceiver(t, http.StatusServiceUnavailable)
+	store := newMemoryStore(models.Webhook{ID: 1, URL: r.URL, Secret: "whsec_old", Events: []string{AllEvents}, Active: true})
+	d := startDispatcher(t, store, 3, func(int) {
+		store.update(1, func(webhook *models.Webhook) { webhook.Secret = "whsec_new" })
+	})
+
+	d.Emit(HabitUpdated, 0, nil)
+	waitDeliveries(t, d, 1, 2)
+
+	requests := r.received()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+	for i, secret := range []string{"whsec_old", "whsec_new"} {
+		req := requests[i]
+		if !Verify(secret, req.header.Get(HeaderTimestamp), req.body, req.header.Get(HeaderSignature)) {
+			t.Errorf("request %d is not signed with %s", i+1, secret)
+		}
+	}
+}
+
+func TestRetryDropped(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(store *memoryStore)
+		want   []string
+	}{
+		{
+			name:   "inactive",
+			change: func(store *memoryStore) { store.update(1, func(webhook *models.Webhook) { webhook.Active = false }) },
+			want:   []string{DeliveryRetrying, DeliveryDropped},
+		},
+		{
+			name: "unsubscribed",
+			change: func(store *memoryStore) {
+				store.update(1, func(webhook *models.Webhook) { webhook.Events = []string{GoalCreated} })
+			},
+			want: []string{DeliveryRetrying, DeliveryDropped},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newReceiver(t, http.StatusInternalServerError)
+			store := newMemoryStore(models.Webhook{ID: 1, URL: r.URL, Events: []string{AllEvents}, Active: true})
+			d := startDispatcher(t, store, 3, func(int) { tt.change(store) })
+
+			d.Emit(HabitDeleted, 0, nil)
+			expectStatuses(t, waitDeliveries(t, d, 1, len(tt.want)), tt.want...)
+
+			time.Sleep(50 * time.Millisecond)
+			if n := len(r.received()); n != 1 {
+				t.Errorf("receiver got %d requests, want 1", n)
+			}
+		})
+	}
+}
+
+func TestRetryDroppedForDeletedWebhook(t *testing.T) {
+	r := newReceiver(t, http.StatusInternalServerError)
+	store := newMemoryStore(models.Webhook{ID: 1, URL: r.URL, Events: []string{AllEvents}, Active: true})
+	d := startDispatcher(t, store, 3, func(int) { store.delete(1) })
+
+	d.Emit(HabitDeleted, 0, nil)
+	waitDeliveries(t, d, 1, 1)
+	// Так журнал очищает обработчик DELETE /webhooks/:id
+	d.Forget(1)
+
+	time.Sleep(50 * time.Millisecond)
+	if n := len(r.received()); n != 1 {
+		t.Errorf("receiver got %d requests, want 1", n)
+	}
+	if list := d.Log().List(1); len(list) != 0 {
+		t.Errorf("deleted webhook has deliveries %+v", list)
+	}
+}
+
+func TestPingRetriesInactiveWebhook(t *testing.T) {
+	r := newReceiver(t, http.StatusInternalServerError)
+	webhook := models.Webhook{ID: 1, URL: r.URL, Events: []string{GoalCreated}, Active: false}
+	store := newMemoryStore(webhook)
+	d := startDispatcher(t, store, 3, nil)
+
+	d.Send(webhook, Event{Type: Ping})
+	expectStatuses(t, waitDeliveries(t, d, 1, 2), DeliveryRetrying, DeliverySucceeded)
+}
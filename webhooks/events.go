@@ -0,0 +1,61 @@
+package webhooks
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	HabitCreated   = "habit.created"
+	HabitUpdated   = "habit.updated"
+	HabitDeleted   = "habit.deleted"
+	HabitCompleted = "habit.completed"
+	HabitReopened  = "habit.reopened"
+	GoalCreated    = "goal.created"
+	GoalUpdated    = "goal.updated"
+	GoalDeleted    = "goal.deleted"
+	GoalCompleted  = "goal.completed"
+	GoalReopened   = "goal.reopened"
+	GoalOverdue    = "goal.overdue"
+	TrackCreated   = "track.created"
+	TrackUpdated   = "track.updated"
+	TrackDeleted   = "track.deleted"
+	Ping           = "ping"
+)
+
+// AllEvents в подписке означает любое событие.
+const AllEvents = "*"
+
+// Events — события, на которые можно подписаться.
+var Events = []string{
+	HabitCreated, HabitUpdated, HabitDeleted, HabitCompleted, HabitReopened,
+	GoalCreated, GoalUpdated, GoalDeleted, GoalCompleted, GoalReopened, GoalOverdue,
+	TrackCreated, TrackUpdated, TrackDeleted,
+}
+
+// Known проверяет, можно ли подписаться на событие: это одно из Events или AllEvents.
+func Known(event string) bool {
+	return event == AllEvents || slices.Contains(Events, event)
+}
+
+// Event — тело запроса, который получает подписчик.
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Matches проверяет, подходит ли событие под фильтр подписки.
+func Matches(filter []string, event string) bool {
+	// Проверочное событие отправляется любой подписке
+	if event == Ping {
+		return true
+	}
+	for _, name := range filter {
+		if name == AllEvents || name == event {
+			return true
+		}
+	}
+	return false
+}
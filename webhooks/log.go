@@ -0,0 +1,71 @@
+package webhooks
+
+import (
+	"sync"
+	"time"
+)
+
+// deliveryLogSize — сколько последних попыток доставки хранится для каждой подписки.
+const deliveryLogSize = 100
+
+const (
+	DeliverySucceeded = "succeeded"
+	DeliveryRetrying  = "retrying"
+	DeliveryFailed    = "failed"
+	DeliveryDropped   = "dropped"
+)
+
+// Delivery — одна попытка доставки события.
+type Delivery struct {
+	EventID     string     `json:"event_id"`
+	Event       string     `json:"event"`
+	WebhookID   int        `json:"webhook_id"`
+	Attempt     int        `json:"attempt"`
+	Status      string     `json:"status"`
+	StatusCode  int        `json:"status_code,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	DurationMS  int64      `json:"duration_ms"`
+	AttemptedAt time.Time  `json:"attempted_at"`
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty"`
+}
+
+// DeliveryLog хранит последние попытки доставки в памяти, после перезапуска журнал пуст.
+type DeliveryLog struct {
+	mu      sync.Mutex
+	entries map[int][]Delivery
+}
+
+func NewDeliveryLog() *DeliveryLog {
+	return &DeliveryLog{entries: make(map[int][]Delivery)}
+}
+
+func (l *DeliveryLog) add(delivery Delivery) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := append(l.entries[delivery.WebhookID], delivery)
+	if len(entries) > deliveryLogSize {
+		entries = entries[len(entries)-deliveryLogSize:]
+	}
+	l.entries[delivery.WebhookID] = entries
+}
+
+// List возвращает попытки доставки подписки, новые первыми.
+func (l *DeliveryLog) List(webhookID int) []Delivery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := l.entries[webhookID]
+	list := make([]Delivery, len(entries))
+	for i, delivery := range entries {
+		list[len(entries)-1-i] = delivery
+	}
+	return list
+}
+
+func (l *DeliveryLog) forget(webhookID int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.entries, webhookID)
+}
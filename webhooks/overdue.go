@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"habit-tracker-api/models"
+	"log"
+	"time"
+)
+
+// GoalSource — цели, которые проверяются на просрочку. Реализуется storage.JSONStorage.
+type GoalSource interface {
+	GetAllGoals() ([]models.Goal, error)
+}
+
+// WatchOverdue раз в interval ищет невыполненные цели с прошедшим TargetDate
+// и отправляет goal.overdue один раз на каждый срок цели. Цели, просроченные
+// на момент запуска, считаются уже известными, чтобы перезапуск не повторял события.
+func (d *Dispatcher) WatchOverdue(ctx context.Context, goals GoalSource, interval time.Duration) {
+	seen := make(map[string]bool)
+	d.checkOverdue(goals, seen, false)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.checkOverdue(goals, seen, true)
+		}
+	}
+}
+
+func (d *Dispatcher) checkOverdue(source GoalSource, seen map[string]bool, emit bool) {
+	goals, err := source.GetAllGoals()
+	if err != nil {
+		log.Printf("webhooks: failed to load goals: %v", err)
+		return
+	}
+
+	now := d.opts.Now()
+	for _, goal := range goals {
+		if goal.Completed || goal.TargetDate.IsZero() || !goal.TargetDate.Before(now) {
+			continue
+		}
+
+		// Если срок цели перенесли, новая просрочка — новое событие
+		key := fmt.Sprintf("%d/%d", goal.ID, goal.TargetDate.Unix())
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if emit {
+			d.Emit(GoalOverdue, goal.UserID, goal)
+		}
+	}
+}